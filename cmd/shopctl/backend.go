@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/repository/inmemory"
+	sqlrepo "github.com/alextanhongpin/go-domain-test/repository/sql"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+)
+
+// backend wires the usecases to the repositories.
+type backend struct {
+	product  *usecase.ProductUsecase
+	purchase *usecase.PurchaseUsecase
+	close    func() error
+}
+
+// openBackend opens the repository backend:
+//   - sqlite migrates and uses the database at the dsn.
+//   - memory uses an empty store that is discarded on exit, which is useful
+//     for dry runs.
+func openBackend(ctx context.Context, name, dsn string) (*backend, error) {
+	switch name {
+	case "sqlite":
+		db, err := sqlrepo.Open(dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sqlrepo.Migrate(ctx, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+
+		// The purchase events are stored in the outbox table. The product
+		// events are best-effort and only kept for the command.
+		return &backend{
+			product:  usecase.NewProduct(sqlrepo.NewProductRepository(db), event.NewInMemoryPublisher()),
+			purchase: usecase.NewPurchaseUsecase(sqlrepo.NewPurchaseRepository(db), sqlrepo.NewIdempotencyRepository(db)),
+			close:    db.Close,
+		}, nil
+	case "memory":
+		store := inmemory.NewStore()
+
+		return &backend{
+			product:  usecase.NewProduct(inmemory.NewProductRepository(store), event.NewInMemoryPublisher()),
+			purchase: usecase.NewPurchaseUsecase(inmemory.NewPurchaseRepository(store), inmemory.NewIdempotencyRepository(store)),
+			close:    func() error { return nil },
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", errUsage, name)
+	}
+}
+
+func (b *backend) Close() error {
+	return b.close()
+}
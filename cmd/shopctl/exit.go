@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alextanhongpin/errors/causes"
+	"github.com/alextanhongpin/errors/codes"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+)
+
+// The exit codes follow sysexits.h.
+const (
+	exitOK       = 0
+	exitUsage    = 64 // EX_USAGE
+	exitDataErr  = 65 // EX_DATAERR
+	exitNoInput  = 66 // EX_NOINPUT
+	exitSoftware = 70 // EX_SOFTWARE
+	exitTempFail = 75 // EX_TEMPFAIL
+	exitNoPerm   = 77 // EX_NOPERM
+)
+
+var errUsage = errors.New("usage")
+
+var exitCodeByCode = map[codes.Code]int{
+	codes.BadRequest:         exitDataErr,
+	codes.OutOfRange:         exitDataErr,
+	codes.PreconditionFailed: exitDataErr,
+	codes.NotFound:           exitNoInput,
+	codes.Unauthorized:       exitNoPerm,
+	codes.Forbidden:          exitNoPerm,
+	codes.Aborted:            exitTempFail,
+	codes.Conflict:           exitTempFail,
+	codes.Exists:             exitTempFail,
+	codes.DeadlineExceeded:   exitTempFail,
+	codes.TooManyRequests:    exitTempFail,
+	codes.Unavailable:        exitTempFail,
+}
+
+// exitCode returns the exit code for the error. Errors without a cause are
+// unexpected, and exit with EX_SOFTWARE.
+func exitCode(err error) int {
+	if errors.Is(err, errUsage) {
+		return exitUsage
+	}
+
+	var d causes.Detail
+	if !errors.As(err, &d) {
+		return exitSoftware
+	}
+
+	code, ok := exitCodeByCode[d.Detail().Code()]
+	if !ok {
+		return exitSoftware
+	}
+
+	return code
+}
+
+// fail prints the error, and returns the exit code.
+func fail(w io.Writer, err error) int {
+	var d causes.Detail
+	if errors.As(err, &d) {
+		det := d.Detail()
+		if remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(det); ok {
+			fmt.Fprintf(w, "shopctl: %s (%s, %d remaining)\n", det.Message(), det.Kind(), remaining)
+		} else {
+			fmt.Fprintf(w, "shopctl: %s (%s)\n", det.Message(), det.Kind())
+		}
+	} else {
+		fmt.Fprintf(w, "shopctl: %s\n", err)
+	}
+
+	return exitCode(err)
+}
@@ -0,0 +1,553 @@
+// Command shopctl manages products and purchases through the usecases.
+//
+// Usage:
+//
+//	shopctl [flags] product create <name>
+//	shopctl [flags] product view <id>
+//	shopctl [flags] product list [-owner id] [-published bool] [-min-price n] [-max-price n] [-currency code] [-name-prefix s] [-sort s] [-cursor c] [-limit n]
+//	shopctl [flags] product update <id> -name <name> -price <amount> -currency <code> -version <n>
+//	shopctl [flags] product publish <id> [-at time] [-until time]
+//	shopctl [flags] product unpublish <id>
+//	shopctl [flags] product delete <id>
+//	shopctl [flags] product restore <id>
+//	shopctl [flags] purchase preview -product <id> [-unit n] [-coupon code]...
+//	shopctl [flags] purchase create -product <id> [-unit n] [-coupon code]... [-idempotency-key key]
+//
+// The exit code is derived from the error code, see exitCode.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+const usage = `Usage: shopctl [flags] <command> [args]
+
+Commands:
+  product create <name>        Create an unpublished product.
+  product view <id>            View a published product.
+  product list [flags]         List the products, a page at a time.
+  product update <id> [flags]  Rename and reprice the product.
+  product publish <id> [flags] Publish the product now, or schedule it.
+  product unpublish <id>       Unpublish the product.
+  product delete <id>          Delete the product.
+  product restore <id>         Restore the deleted product.
+  purchase preview [flags]     Price the purchase without placing it.
+  purchase create [flags]      Place the purchase.
+
+Flags:
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command, and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	var cfg config
+
+	fs := flag.NewFlagSet("shopctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.backend, "backend", "sqlite", "The repository backend, sqlite or memory.")
+	fs.StringVar(&cfg.dsn, "dsn", "shop.db", "The sqlite data source name.")
+	fs.StringVar(&cfg.output, "output", "table", "The output format, table or json.")
+	fs.StringVar(&cfg.user, "user", "", "The id of the user performing the command.")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	out, err := newPrinter(cfg.output, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "shopctl: %s\n", err)
+		return exitUsage
+	}
+
+	cmd, err := parseCommand(fs.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "shopctl: %s\n", err)
+		fs.Usage()
+		return exitUsage
+	}
+
+	b, err := openBackend(ctx, cfg.backend, cfg.dsn)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	defer b.Close()
+
+	v, err := cmd(ctx, b, cfg)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	if err := out.print(v); err != nil {
+		return fail(stderr, err)
+	}
+
+	return exitOK
+}
+
+type config struct {
+	backend string
+	dsn     string
+	output  string
+	user    string
+}
+
+// userID returns the id of the user performing the command.
+func (c config) userID() (uuid.UUID, error) {
+	if c.user == "" {
+		return uuid.Nil, fmt.Errorf("%w: -user is required", errUsage)
+	}
+
+	id, err := uuid.Parse(c.user)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: -user must be a valid UUID", errUsage)
+	}
+
+	return id, nil
+}
+
+// command returns the value to print.
+type command func(ctx context.Context, b *backend, cfg config) (any, error)
+
+func parseCommand(args []string) (command, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	name, args := strings.Join(args[:2], " "), args[2:]
+	switch name {
+	case "product create":
+		return productCreate(args)
+	case "product view":
+		return productView(args)
+	case "product list":
+		return productList(args)
+	case "product update":
+		return productUpdate(args)
+	case "product publish":
+		return productPublish(args)
+	case "product unpublish":
+		return productUnpublish(args)
+	case "product delete":
+		return productDelete(args)
+	case "product restore":
+		return productRestore(args)
+	case "purchase preview":
+		return purchasePreview(args)
+	case "purchase create":
+		return purchaseCreate(args)
+	default:
+		return nil, fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+}
+
+func productCreate(args []string) (command, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: product create requires a name", errUsage)
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := b.product.Create(ctx, usecase.CreateProductDto{
+			Name:   args[0],
+			UserID: userID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func productView(args []string) (command, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		p, err := b.product.View(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func productList(args []string) (command, error) {
+	var (
+		dto                usecase.ListProductsDto
+		minPrice, maxPrice int64
+		currency           string
+	)
+
+	fs := flag.NewFlagSet("product list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("owner", "The id of the owner.", func(s string) error {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return err
+		}
+
+		dto.Filter.OwnerID = &id
+		return nil
+	})
+	fs.Func("published", "Lists only the published, or unpublished products.", func(s string) error {
+		published, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		dto.Filter.Published = &published
+		return nil
+	})
+	fs.Int64Var(&minPrice, "min-price", 0, "The minimum price in the minor unit of the currency.")
+	fs.Int64Var(&maxPrice, "max-price", 0, "The maximum price in the minor unit of the currency.")
+	fs.StringVar(&currency, "currency", "", "The currency of the price range, e.g. MYR.")
+	fs.StringVar(&dto.Filter.NamePrefix, "name-prefix", "", "The prefix of the name.")
+	fs.Func("sort", "One of name, price or -price.", func(s string) error {
+		dto.Sort = usecase.ProductSort(s)
+		return nil
+	})
+	fs.StringVar(&dto.Cursor, "cursor", "", "The cursor of the next page.")
+	fs.IntVar(&dto.Limit, "limit", 0, "The number of products per page.")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errUsage, fs.Name(), err)
+	}
+
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("%w: %s: unexpected arguments %q", errUsage, fs.Name(), fs.Args())
+	}
+
+	// The price range is only set when the flags are passed, since zero is
+	// a valid price.
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "min-price":
+			dto.Filter.MinPrice = types.Ptr(domain.NewMoney(minPrice, domain.Currency(currency)))
+		case "max-price":
+			dto.Filter.MaxPrice = types.Ptr(domain.NewMoney(maxPrice, domain.Currency(currency)))
+		}
+	})
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		// The user is optional, and only needed to list their unpublished
+		// products.
+		if cfg.user != "" {
+			userID, err := cfg.userID()
+			if err != nil {
+				return nil, err
+			}
+			dto.UserID = userID
+		}
+
+		page, err := b.product.List(ctx, dto)
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductListOutput(page), nil
+	}, nil
+}
+
+func productUpdate(args []string) (command, error) {
+	var dto usecase.UpdateProductDto
+
+	fs := flag.NewFlagSet("product update", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&dto.Name, "name", "", "The new name.")
+	fs.Int64Var(&dto.Price.Amount, "price", 0, "The new price in the minor unit of the currency.")
+	fs.Func("currency", "The currency of the price, e.g. MYR.", func(s string) error {
+		dto.Price.Currency = domain.Currency(s)
+		return nil
+	})
+	fs.IntVar(&dto.Version, "version", 0, "The version that the changes are based on.")
+
+	id, err := parseIDFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	dto.ID = id
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+		dto.UserID = userID
+
+		p, err := b.product.Update(ctx, dto)
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func productPublish(args []string) (command, error) {
+	var publishAt, unpublishAt *time.Time
+
+	fs := flag.NewFlagSet("product publish", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("at", "The RFC3339 time to publish from. Defaults to now.", timeFlag(&publishAt))
+	fs.Func("until", "The RFC3339 time to unpublish at. Optional.", timeFlag(&unpublishAt))
+
+	id, err := parseIDFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+
+		var p *domain.Product
+		if publishAt == nil && unpublishAt == nil {
+			p, err = b.product.Publish(ctx, id, userID)
+		} else {
+			dto := usecase.SchedulePublishDto{
+				ID:          id,
+				UserID:      userID,
+				UnpublishAt: unpublishAt,
+			}
+			if publishAt != nil {
+				dto.PublishAt = *publishAt
+			}
+
+			p, err = b.product.SchedulePublish(ctx, dto)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func productUnpublish(args []string) (command, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := b.product.Unpublish(ctx, id, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func productDelete(args []string) (command, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := b.product.Delete(ctx, id, userID); err != nil {
+			return nil, err
+		}
+
+		return deletedOutput{ID: id, Deleted: true}, nil
+	}, nil
+}
+
+func productRestore(args []string) (command, error) {
+	id, err := parseID(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := b.product.Restore(ctx, id, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		return newProductOutput(p), nil
+	}, nil
+}
+
+func purchasePreview(args []string) (command, error) {
+	fs, dto := purchaseFlags("purchase preview")
+	if err := parsePurchaseFlags(fs, dto, args); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+		dto.UserID = userID
+
+		p, err := b.purchase.Preview(ctx, *dto)
+		if err != nil {
+			return nil, err
+		}
+
+		return newPurchaseOutput(p)
+	}, nil
+}
+
+func purchaseCreate(args []string) (command, error) {
+	fs, dto := purchaseFlags("purchase create")
+	fs.StringVar(&dto.IdempotencyKey, "idempotency-key", "", "Makes retries of the purchase safe.")
+	if err := parsePurchaseFlags(fs, dto, args); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, b *backend, cfg config) (any, error) {
+		userID, err := cfg.userID()
+		if err != nil {
+			return nil, err
+		}
+		dto.UserID = userID
+
+		p, err := b.purchase.Purchase(ctx, *dto)
+		if err != nil {
+			return nil, err
+		}
+
+		return newPurchaseOutput(p)
+	}, nil
+}
+
+// purchaseFlags binds the flags to the returned dto, which is populated once
+// the flags are parsed.
+func purchaseFlags(name string) (*flag.FlagSet, *usecase.PurchaseDto) {
+	dto := &usecase.PurchaseDto{Unit: 1}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Func("product", "The id of the product.", func(s string) error {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return err
+		}
+
+		dto.ProductID = id
+		return nil
+	})
+	fs.IntVar(&dto.Unit, "unit", dto.Unit, "The number of units.")
+	fs.Func("coupon", "The coupon code, may be repeated.", func(s string) error {
+		dto.CouponCodes = append(dto.CouponCodes, s)
+		return nil
+	})
+
+	return fs, dto
+}
+
+func parsePurchaseFlags(fs *flag.FlagSet, dto *usecase.PurchaseDto, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s: %s", errUsage, fs.Name(), err)
+	}
+
+	if dto.ProductID == uuid.Nil {
+		return fmt.Errorf("%w: %s requires -product", errUsage, fs.Name())
+	}
+
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: %s: unexpected arguments %q", errUsage, fs.Name(), fs.Args())
+	}
+
+	return nil
+}
+
+// parseIDFlags parses the id, followed by the flags.
+func parseIDFlags(fs *flag.FlagSet, args []string) (uuid.UUID, error) {
+	if len(args) == 0 {
+		return uuid.Nil, fmt.Errorf("%w: %s requires an id", errUsage, fs.Name())
+	}
+
+	id, err := parseID(args[:1])
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s: %s", errUsage, fs.Name(), err)
+	}
+
+	if fs.NArg() != 0 {
+		return uuid.Nil, fmt.Errorf("%w: %s: unexpected arguments %q", errUsage, fs.Name(), fs.Args())
+	}
+
+	return id, nil
+}
+
+func timeFlag(t **time.Time) func(string) error {
+	return func(s string) error {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+
+		*t = &v
+		return nil
+	}
+}
+
+func parseID(args []string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.Nil, fmt.Errorf("%w: requires an id", errUsage)
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: the id must be a valid UUID", errUsage)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	sqlrepo "github.com/alextanhongpin/go-domain-test/repository/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunProduct(t *testing.T) {
+	dsn := newDSN(t)
+	owner := uuid.NewString()
+
+	res := shopctl(t, "-dsn", dsn, "-user", owner, "-output", "json", "product", "create", "colorful socks")
+
+	as := assert.New(t)
+	as.Equal(exitOK, res.code, res.stderr)
+
+	var p productOutput
+	as.Nil(json.Unmarshal([]byte(res.stdout), &p))
+	as.Equal("colorful socks", p.Name)
+	as.Equal(owner, p.UserID.String())
+	as.Nil(p.PublishedAt)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "create", "!@#")
+	as.Equal(exitDataErr, res.code)
+
+	// The product is not published.
+	res = shopctl(t, "-dsn", dsn, "product", "view", p.ID.String())
+	as.Equal(exitNoInput, res.code)
+	as.Equal("shopctl: Product does not exist or may have been deleted. (product_not_found)\n", res.stderr)
+
+	update := []string{"-dsn", dsn, "-user", owner, "product", "update", p.ID.String(), "-name", "striped socks", "-price", "12", "-currency", "MYR", "-version", "0"}
+	res = shopctl(t, update...)
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "PRICE         MYR 12\n")
+	as.Contains(res.stdout, "VERSION       1\n")
+
+	// The same edit is now based on a stale version.
+	res = shopctl(t, update...)
+	as.Equal(exitTempFail, res.code)
+	as.Contains(res.stderr, "product_version_conflict")
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "publish", p.ID.String())
+	as.Equal(exitOK, res.code, res.stderr)
+
+	res = shopctl(t, "-dsn", dsn, "product", "view", p.ID.String())
+	as.Equal(exitOK, res.code, res.stderr)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "publish", p.ID.String(), "-at", "2030-01-01T00:00:00Z", "-until", "2029-01-01T00:00:00Z")
+	as.Equal(exitDataErr, res.code)
+	as.Contains(res.stderr, "product_schedule_invalid")
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "publish", p.ID.String(), "-at", "2030-01-01T00:00:00Z", "-until", "2031-01-01T00:00:00Z")
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "PUBLISHED AT  2030-01-01T00:00:00Z\n")
+	as.Contains(res.stdout, "UNPUBLISH AT  2031-01-01T00:00:00Z\n")
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "unpublish", p.ID.String())
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "PUBLISHED AT  -\n")
+
+	res = shopctl(t, "-dsn", dsn, "-user", uuid.NewString(), "product", "delete", p.ID.String())
+	as.Equal(exitNoPerm, res.code)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "delete", p.ID.String())
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Equal("ID       "+p.ID.String()+"\nDELETED  true\n", res.stdout)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "delete", p.ID.String())
+	as.Equal(exitNoInput, res.code)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "restore", p.ID.String())
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "DELETED AT    -\n")
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "restore", p.ID.String())
+	as.Equal(exitTempFail, res.code)
+	as.Contains(res.stderr, "product_not_deleted")
+}
+
+func TestRunProductList(t *testing.T) {
+	dsn := newDSN(t)
+	owner := uuid.NewString()
+
+	create := func(name string, publish bool) string {
+		t.Helper()
+
+		res := shopctl(t, "-dsn", dsn, "-user", owner, "-output", "json", "product", "create", name)
+		if res.code != exitOK {
+			t.Fatal(res.stderr)
+		}
+
+		var p productOutput
+		if err := json.Unmarshal([]byte(res.stdout), &p); err != nil {
+			t.Fatal(err)
+		}
+
+		if publish {
+			if res := shopctl(t, "-dsn", dsn, "-user", owner, "product", "publish", p.ID.String()); res.code != exitOK {
+				t.Fatal(res.stderr)
+			}
+		}
+
+		return p.ID.String()
+	}
+
+	shirt := create("shirt", true)
+	socks := create("socks", true)
+	scarf := create("scarf", false)
+
+	res := shopctl(t, "-dsn", dsn, "product", "list", "-limit", "1")
+
+	as := assert.New(t)
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "ID                                    NAME   USER")
+	as.Contains(res.stdout, shirt)
+	as.Contains(res.stdout, "\nNext page: -cursor ")
+
+	cursor := res.stdout[strings.LastIndex(res.stdout, " ")+1 : len(res.stdout)-1]
+	res = shopctl(t, "-dsn", dsn, "-output", "json", "product", "list", "-limit", "1", "-cursor", cursor)
+	as.Equal(exitOK, res.code, res.stderr)
+
+	var page productListOutput
+	as.Nil(json.Unmarshal([]byte(res.stdout), &page))
+	if as.Len(page.Products, 1) {
+		as.Equal(socks, page.Products[0].ID.String())
+	}
+	as.Empty(page.NextCursor)
+
+	res = shopctl(t, "-dsn", dsn, "-user", owner, "product", "list", "-published", "false")
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, scarf)
+	as.NotContains(res.stdout, shirt)
+
+	res = shopctl(t, "-dsn", dsn, "product", "list", "-min-price", "1")
+	as.Equal(exitDataErr, res.code)
+	as.Contains(res.stderr, "product_query_invalid")
+}
+
+func TestRunPurchase(t *testing.T) {
+	dsn := newDSN(t)
+	user := factories.NewUser()
+	p := factories.NewProduct("published")
+	limited := factories.NewProduct("published", "limited")
+	seed(t, dsn, func(db *sql.DB) error {
+		if _, err := db.Exec(`INSERT INTO users (id, name, status) VALUES (?, ?, ?)`, user.ID.String(), user.Name, string(user.Status)); err != nil {
+			return err
+		}
+
+		for _, p := range []*domain.Product{p, limited} {
+			limits, err := json.Marshal(p.PurchaseLimits)
+			if err != nil {
+				return err
+			}
+
+			if _, err := db.Exec(`
+				INSERT INTO products (id, name, user_id, published_at, price_amount, price_currency, price_tiers, tax_category, purchase_limits)
+				VALUES (?, ?, ?, ?, ?, ?, '[]', '', ?)`,
+				p.ID.String(), string(p.Name), p.UserID.String(), p.PublishedAt, p.Price.Amount, string(p.Price.Currency), string(limits)); err != nil {
+				return err
+			}
+
+			if _, err := db.Exec(`INSERT INTO inventories (product_id, stock) VALUES (?, ?)`, p.ID.String(), 2); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	args := []string{"-dsn", dsn, "-user", user.ID.String(), "-output", "json", "purchase"}
+
+	t.Run("preview", func(t *testing.T) {
+		res := shopctl(t, append(args, "preview", "-product", p.ID.String(), "-unit", "2")...)
+
+		as := assert.New(t)
+		as.Equal(exitOK, res.code, res.stderr)
+
+		var got purchaseOutput
+		as.Nil(json.Unmarshal([]byte(res.stdout), &got))
+		as.Equal(2, got.Unit)
+		as.Equal("MYR 20", got.Total)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		create := append(args, "create", "-product", p.ID.String(), "-unit", "2", "-idempotency-key", "order-1")
+
+		res := shopctl(t, create...)
+		as := assert.New(t)
+		as.Equal(exitOK, res.code, res.stderr)
+
+		// The retry with the same key succeeds although the stock is used up.
+		retry := shopctl(t, create...)
+		as.Equal(exitOK, retry.code, retry.stderr)
+		as.JSONEq(res.stdout, retry.stdout)
+	})
+
+	t.Run("out of stock", func(t *testing.T) {
+		res := shopctl(t, append(args, "create", "-product", p.ID.String())...)
+		assert.Equal(t, exitTempFail, res.code)
+		assert.Contains(t, res.stderr, "product_out_of_stock")
+	})
+
+	t.Run("purchase limit exceeded", func(t *testing.T) {
+		res := shopctl(t, append(args, "create", "-product", limited.ID.String(), "-unit", "2")...)
+		assert.Equal(t, exitDataErr, res.code)
+		assert.Equal(t, "shopctl: You have reached the purchase limit for this product. (purchase_limit_exceeded, 1 remaining)\n", res.stderr)
+	})
+
+	t.Run("ineligible user", func(t *testing.T) {
+		res := shopctl(t, "-dsn", dsn, "-user", uuid.NewString(), "purchase", "preview", "-product", p.ID.String())
+		assert.Equal(t, exitNoPerm, res.code)
+	})
+
+	t.Run("unknown coupon", func(t *testing.T) {
+		res := shopctl(t, append(args, "preview", "-product", p.ID.String(), "-coupon", "NOPE")...)
+		assert.Equal(t, exitNoInput, res.code)
+		assert.Contains(t, res.stderr, "coupon_unknown")
+	})
+}
+
+func TestRunMemoryBackend(t *testing.T) {
+	res := shopctl(t, "-backend", "memory", "-user", uuid.NewString(), "product", "create", "colorful socks")
+
+	as := assert.New(t)
+	as.Equal(exitOK, res.code, res.stderr)
+	as.Contains(res.stdout, "NAME          colorful socks\n")
+	as.Contains(res.stdout, "PUBLISHED AT  -\n")
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := map[string][]string{
+		"no command":        {},
+		"unknown command":   {"product", "publish"},
+		"unknown backend":   {"-backend", "postgres", "product", "view", uuid.NewString()},
+		"unknown output":    {"-output", "yaml", "product", "view", uuid.NewString()},
+		"unknown flag":      {"-verbose"},
+		"invalid id":        {"-backend", "memory", "product", "view", "1"},
+		"missing user":      {"-backend", "memory", "product", "create", "socks"},
+		"invalid user":      {"-backend", "memory", "-user", "1", "product", "create", "socks"},
+		"missing update id": {"-backend", "memory", "product", "update"},
+		"invalid published": {"-backend", "memory", "product", "list", "-published", "maybe"},
+		"missing product":   {"-backend", "memory", "purchase", "preview", "-unit", "1"},
+	}
+
+	for name, args := range tests {
+		args := args
+
+		t.Run(name, func(t *testing.T) {
+			res := shopctl(t, args...)
+			assert.Equal(t, exitUsage, res.code, res.stderr)
+		})
+	}
+}
+
+type result struct {
+	code           int
+	stdout, stderr string
+}
+
+func shopctl(t *testing.T, args ...string) result {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+
+	return result{
+		code:   code,
+		stdout: stdout.String(),
+		stderr: stderr.String(),
+	}
+}
+
+func newDSN(t *testing.T) string {
+	t.Helper()
+
+	return "file:" + filepath.Join(t.TempDir(), "shop.db") + "?_pragma=busy_timeout(5000)"
+}
+
+// seed migrates the database before seeding, since the tables are otherwise
+// only created on the first command.
+func seed(t *testing.T, dsn string, fn func(db *sql.DB) error) {
+	t.Helper()
+
+	db, err := sqlrepo.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := sqlrepo.Migrate(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fn(db); err != nil {
+		t.Fatal(err)
+	}
+}
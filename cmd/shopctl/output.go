@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+// row is implemented by the values that can be printed as a table, with a
+// line per field.
+type row interface {
+	header() []string
+	values() []string
+}
+
+// table is implemented by the values that can be printed as a table, with a
+// line per row. The footer is printed after the rows, unless empty.
+type table interface {
+	header() []string
+	rows() [][]string
+	footer() string
+}
+
+type printer struct {
+	format string
+	w      io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case "table", "json":
+		return &printer{format: format, w: w}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown output %q", errUsage, format)
+	}
+}
+
+func (p *printer) print(v any) error {
+	if p.format == "json" {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	switch v := v.(type) {
+	case row:
+		for i, h := range v.header() {
+			fmt.Fprintf(tw, "%s\t%s\n", h, v.values()[i])
+		}
+	case table:
+		fmt.Fprintln(tw, strings.Join(v.header(), "\t"))
+		for _, r := range v.rows() {
+			fmt.Fprintln(tw, strings.Join(r, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if f := v.footer(); f != "" {
+			fmt.Fprintf(p.w, "\n%s\n", f)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("cannot print %T as a table", v)
+	}
+
+	return tw.Flush()
+}
+
+type productOutput struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	UserID      uuid.UUID  `json:"user_id"`
+	PublishedAt *time.Time `json:"published_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Price       string     `json:"price"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+func newProductOutput(p *domain.Product) productOutput {
+	return productOutput{
+		ID:          p.ID,
+		Name:        string(p.Name),
+		UserID:      p.UserID,
+		PublishedAt: p.PublishedAt,
+		UnpublishAt: p.UnpublishAt,
+		Price:       p.Price.String(),
+		Version:     p.Version,
+		DeletedAt:   p.DeletedAt,
+	}
+}
+
+func (v productOutput) header() []string {
+	return []string{"ID", "NAME", "USER", "PUBLISHED AT", "UNPUBLISH AT", "PRICE", "VERSION", "DELETED AT"}
+}
+
+func (v productOutput) values() []string {
+	return []string{v.ID.String(), v.Name, v.UserID.String(), formatTime(v.PublishedAt), formatTime(v.UnpublishAt), v.Price, fmt.Sprint(v.Version), formatTime(v.DeletedAt)}
+}
+
+type productListOutput struct {
+	Products   []productOutput `json:"products"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+func newProductListOutput(page *usecase.ProductPage) productListOutput {
+	res := productListOutput{
+		Products:   make([]productOutput, len(page.Products)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Products {
+		res.Products[i] = newProductOutput(&page.Products[i])
+	}
+
+	return res
+}
+
+func (v productListOutput) header() []string {
+	return []string{"ID", "NAME", "USER", "PUBLISHED AT", "PRICE"}
+}
+
+func (v productListOutput) rows() [][]string {
+	rows := make([][]string, len(v.Products))
+	for i, p := range v.Products {
+		rows[i] = []string{p.ID.String(), p.Name, p.UserID.String(), formatTime(p.PublishedAt), p.Price}
+	}
+
+	return rows
+}
+
+func (v productListOutput) footer() string {
+	if v.NextCursor == "" {
+		return ""
+	}
+
+	return "Next page: -cursor " + v.NextCursor
+}
+
+type purchaseOutput struct {
+	ID          uuid.UUID `json:"id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Unit        int       `json:"unit"`
+	BasePrice   string    `json:"base_price"`
+	Discount    string    `json:"discount"`
+	Tax         string    `json:"tax"`
+	Total       string    `json:"total"`
+	Status      string    `json:"status"`
+	CouponCodes []string  `json:"coupon_codes"`
+}
+
+func newPurchaseOutput(p *domain.Purchase) (purchaseOutput, error) {
+	total, err := p.Total()
+	if err != nil {
+		return purchaseOutput{}, err
+	}
+
+	codes := p.CouponCodes
+	if codes == nil {
+		codes = []string{}
+	}
+
+	return purchaseOutput{
+		ID:          p.ID,
+		ProductID:   p.ProductID,
+		UserID:      p.UserID,
+		Unit:        p.Unit,
+		BasePrice:   p.BasePrice.String(),
+		Discount:    p.Discount.String(),
+		Tax:         p.Tax.String(),
+		Total:       total.String(),
+		Status:      string(p.Status),
+		CouponCodes: codes,
+	}, nil
+}
+
+func (v purchaseOutput) header() []string {
+	return []string{"ID", "PRODUCT", "USER", "UNIT", "BASE PRICE", "DISCOUNT", "TAX", "TOTAL", "STATUS", "COUPONS"}
+}
+
+func (v purchaseOutput) values() []string {
+	return []string{
+		v.ID.String(),
+		v.ProductID.String(),
+		v.UserID.String(),
+		fmt.Sprint(v.Unit),
+		v.BasePrice,
+		v.Discount,
+		v.Tax,
+		v.Total,
+		v.Status,
+		fmt.Sprint(v.CouponCodes),
+	}
+}
+
+type deletedOutput struct {
+	ID      uuid.UUID `json:"id"`
+	Deleted bool      `json:"deleted"`
+}
+
+func (v deletedOutput) header() []string {
+	return []string{"ID", "DELETED"}
+}
+
+func (v deletedOutput) values() []string {
+	return []string{v.ID.String(), fmt.Sprint(v.Deleted)}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
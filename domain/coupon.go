@@ -0,0 +1,31 @@
+package domain
+
+// Coupon gates a discount behind a code that has to be redeemed by the user.
+type Coupon struct {
+	Code           string
+	SingleUse      bool // Can only be redeemed once across all users.
+	MaxPerUser     int  // 0 for unlimited.
+	MaxRedemptions int  // 0 for unlimited.
+}
+
+// CouponUsage is the number of times a coupon has been redeemed.
+type CouponUsage struct {
+	Redemptions     int // By all users.
+	UserRedemptions int // By the current user.
+}
+
+func (c *Coupon) CanRedeem(usage CouponUsage) bool {
+	if c.SingleUse && usage.Redemptions > 0 {
+		return false
+	}
+
+	if c.MaxRedemptions > 0 && usage.Redemptions >= c.MaxRedemptions {
+		return false
+	}
+
+	if c.MaxPerUser > 0 && usage.UserRedemptions >= c.MaxPerUser {
+		return false
+	}
+
+	return true
+}
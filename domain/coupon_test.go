@@ -0,0 +1,42 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCouponCanRedeem(t *testing.T) {
+	t.Run("unlimited", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SAVE5"}
+
+		as := assert.New(t)
+		as.True(c.CanRedeem(domain.CouponUsage{}))
+		as.True(c.CanRedeem(domain.CouponUsage{Redemptions: 100, UserRedemptions: 100}))
+	})
+
+	t.Run("single use", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SAVE5", SingleUse: true}
+
+		as := assert.New(t)
+		as.True(c.CanRedeem(domain.CouponUsage{}))
+		as.False(c.CanRedeem(domain.CouponUsage{Redemptions: 1}))
+	})
+
+	t.Run("max per user", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SAVE5", MaxPerUser: 2}
+
+		as := assert.New(t)
+		as.True(c.CanRedeem(domain.CouponUsage{Redemptions: 10, UserRedemptions: 1}))
+		as.False(c.CanRedeem(domain.CouponUsage{Redemptions: 10, UserRedemptions: 2}))
+	})
+
+	t.Run("max redemptions", func(t *testing.T) {
+		c := &domain.Coupon{Code: "SAVE5", MaxRedemptions: 10}
+
+		as := assert.New(t)
+		as.True(c.CanRedeem(domain.CouponUsage{Redemptions: 9}))
+		as.False(c.CanRedeem(domain.CouponUsage{Redemptions: 10}))
+	})
+}
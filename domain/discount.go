@@ -1,15 +1,209 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDiscountInvalid        = errors.New("invalid discount")
+	ErrDiscountMinPurchaseQty = errors.New("minimum purchase quantity not met")
+	ErrDiscountOutperformed   = errors.New("a better discount was applied")
+	ErrDiscountsExceeded      = errors.New("too many discounts")
+)
+
+// MaxDiscounts caps the discounts that are resolved for a purchase of a
+// product, including the redeemed coupons.
+const MaxDiscounts = 50
+
+type DiscountKind string
+
+const (
+	// DiscountKindFixedAmount deducts a fixed amount from the price.
+	DiscountKindFixedAmount DiscountKind = "fixed_amount"
+	// DiscountKindPercentage deducts a percentage of the price.
+	DiscountKindPercentage DiscountKind = "percentage"
+	// DiscountKindFixedPrice replaces the price with a fixed final price.
+	DiscountKindFixedPrice DiscountKind = "fixed_price"
+)
+
+// StackingPolicy decides how a discount is combined with other discounts.
+type StackingPolicy string
+
+const (
+	// StackingStackable applies together with other stackable discounts.
+	StackingStackable StackingPolicy = "stackable"
+	// StackingExclusive cannot be combined with any other discount.
+	StackingExclusive StackingPolicy = "exclusive"
+	// StackingBestOfGroup stacks, but only the best discount in the same
+	// group is applied.
+	StackingBestOfGroup StackingPolicy = "best_of_group"
+)
+
+// Rounding decides what to do with the fraction of the minor unit when
+// computing percentage discounts.
+type Rounding string
+
+const (
+	RoundHalfUp Rounding = "half_up"
+	RoundDown   Rounding = "down"
+	RoundUp     Rounding = "up"
+)
+
+func (r Rounding) Valid() bool {
+	switch r {
+	case "", RoundHalfUp, RoundDown, RoundUp:
+		return true
+	default:
+		return false
+	}
+}
+
+// round divides n by d, rounding the remainder according to the rule.
+// Empty rounding defaults to RoundHalfUp.
+func (r Rounding) round(n, d int64) int64 {
+	q, rem := n/d, n%d
+	if rem == 0 {
+		return q
+	}
+
+	switch r {
+	case RoundDown:
+		return q
+	case RoundUp:
+		return q + 1
+	default:
+		if rem*2 >= d {
+			return q + 1
+		}
+		return q
+	}
+}
 
 type Discount struct {
 	ID             int64
 	Name           string
 	ProductID      uuid.UUID
-	Amount         int // -tive amount for price deduction.
+	Kind           DiscountKind
+	Amount         Money    // -tive amount for price deduction, or the final price for fixed price.
+	Percent        int      // Percentage off, only for percentage discount.
+	Rounding       Rounding // Defaults to RoundHalfUp.
+	Cap            *Money   // The maximum amount that can be deducted, optional.
 	MinPurchaseQty int
+	Stacking       StackingPolicy
+	Group          string // Only for best of group.
+	Priority       int    // Higher priority wins when the prices are equal, and applies first.
+	StartsAt       *time.Time
+	EndsAt         *time.Time // Exclusive.
+	Coupon         *Coupon    // Only applies when the coupon is redeemed, optional.
+}
+
+// DiscountRejection explains why a discount is not applied.
+type DiscountRejection struct {
+	DiscountID int64
+	Reason     error
 }
 
 func (d *Discount) IsValid() bool {
-	return d.Amount < 0 && d.MinPurchaseQty > 0
+	if d.MinPurchaseQty <= 0 || !d.Rounding.Valid() {
+		return false
+	}
+
+	if d.Cap != nil && (d.Cap.Amount <= 0 || !d.Cap.Currency.Valid()) {
+		return false
+	}
+
+	if d.StartsAt != nil && d.EndsAt != nil && !d.StartsAt.Before(*d.EndsAt) {
+		return false
+	}
+
+	switch d.Stacking {
+	case StackingStackable, StackingExclusive:
+	case StackingBestOfGroup:
+		if d.Group == "" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	switch d.Kind {
+	case DiscountKindFixedAmount:
+		return d.Amount.IsNegative() && d.Amount.Currency.Valid() && d.Percent == 0
+	case DiscountKindPercentage:
+		return d.Percent > 0 && d.Percent <= 100 && d.Amount.IsZero()
+	case DiscountKindFixedPrice:
+		return !d.Amount.IsNegative() && d.Amount.Currency.Valid() && d.Percent == 0
+	default:
+		return false
+	}
+}
+
+// IsActiveAt returns true if t is within the validity window. A discount
+// without StartsAt or EndsAt is unbounded on that end.
+func (d *Discount) IsActiveAt(t time.Time) bool {
+	if d.StartsAt != nil && t.Before(*d.StartsAt) {
+		return false
+	}
+
+	if d.EndsAt != nil && !t.Before(*d.EndsAt) {
+		return false
+	}
+
+	return true
+}
+
+// Deduction returns the -tive amount to be added to the price.
+func (d *Discount) Deduction(price Money) (Money, error) {
+	var amount Money
+	switch d.Kind {
+	case DiscountKindPercentage:
+		m, err := price.Mul(d.Percent)
+		if err != nil {
+			return price, err
+		}
+
+		amount = NewMoney(-d.Rounding.round(m.Amount, 100), price.Currency)
+	case DiscountKindFixedPrice:
+		m, err := d.Amount.Sub(price)
+		if err != nil {
+			return price, err
+		}
+
+		// Fixed price never increases the price.
+		if m.Amount > 0 {
+			m.Amount = 0
+		}
+		amount = m
+	default:
+		if err := price.checkCurrency(d.Amount); err != nil {
+			return price, err
+		}
+
+		amount = d.Amount
+	}
+
+	if d.Cap != nil {
+		if err := price.checkCurrency(*d.Cap); err != nil {
+			return price, err
+		}
+
+		if -amount.Amount > d.Cap.Amount {
+			amount.Amount = -d.Cap.Amount
+		}
+	}
+
+	return amount, nil
+}
+
+// Apply returns the price after the discount.
+func (d *Discount) Apply(price Money) (Money, error) {
+	amount, err := d.Deduction(price)
+	if err != nil {
+		return price, err
+	}
+
+	return price.Add(amount)
 }
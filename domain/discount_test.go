@@ -2,19 +2,116 @@ package domain_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/alextanhongpin/go-domain-test/domain/factories"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDiscount(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
-		dis := factories.NewDiscount()
-		assert.True(t, dis.IsValid())
+		as := assert.New(t)
+		as.True(factories.NewDiscount().IsValid())
+		as.True(factories.NewDiscount("percentage").IsValid())
+		as.True(factories.NewDiscount("percentage", "capped").IsValid())
+		as.True(factories.NewDiscount("fixed_price").IsValid())
 	})
 
 	t.Run("invalid", func(t *testing.T) {
-		assert.False(t, factories.NewDiscount("positive_amount").IsValid())
-		assert.False(t, factories.NewDiscount("negative_purchase_qty").IsValid())
+		as := assert.New(t)
+		as.False(factories.NewDiscount("positive_amount").IsValid())
+		as.False(factories.NewDiscount("negative_purchase_qty").IsValid())
+		as.False(factories.NewDiscount("percentage_out_of_range").IsValid())
+
+		d := factories.NewDiscount("fixed_price")
+		d.Amount.Amount = -1
+		as.False(d.IsValid())
+
+		d = factories.NewDiscount("capped")
+		d.Cap.Amount = 0
+		as.False(d.IsValid())
+
+		d = factories.NewDiscount()
+		d.Kind = "unknown"
+		as.False(d.IsValid())
+
+		d = factories.NewDiscount("expired")
+		d.StartsAt, d.EndsAt = d.EndsAt, d.StartsAt
+		as.False(d.IsValid())
+	})
+}
+
+func TestDiscountIsActiveAt(t *testing.T) {
+	startsAt := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(24 * time.Hour)
+
+	d := factories.NewDiscount()
+	d.StartsAt = &startsAt
+	d.EndsAt = &endsAt
+
+	as := assert.New(t)
+	as.False(d.IsActiveAt(startsAt.Add(-time.Nanosecond)))
+	as.True(d.IsActiveAt(startsAt))
+	as.True(d.IsActiveAt(endsAt.Add(-time.Nanosecond)))
+	as.False(d.IsActiveAt(endsAt))
+
+	t.Run("unbounded", func(t *testing.T) {
+		d := factories.NewDiscount()
+
+		as := assert.New(t)
+		as.True(d.IsActiveAt(time.Time{}))
+		as.True(d.IsActiveAt(endsAt))
+	})
+}
+
+func TestDiscountApply(t *testing.T) {
+	myr := func(amount int64) domain.Money {
+		return domain.NewMoney(amount, "MYR")
+	}
+
+	percentage := func(rounding domain.Rounding) *domain.Discount {
+		d := factories.NewDiscount("percentage")
+		d.Rounding = rounding
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		discount *domain.Discount
+		price    domain.Money
+		want     domain.Money
+	}{
+		{"fixed amount", factories.NewDiscount(), myr(10), myr(5)},
+		{"fixed amount capped", factories.NewDiscount("capped"), myr(10), myr(9)},
+		{"percentage rounds half up by default", percentage(""), myr(10), myr(8)},
+		{"percentage round half up", percentage(domain.RoundHalfUp), myr(10), myr(8)},
+		{"percentage round down", percentage(domain.RoundDown), myr(10), myr(9)},
+		{"percentage round up", percentage(domain.RoundUp), myr(10), myr(8)},
+		{"percentage without remainder", percentage(domain.RoundUp), myr(100), myr(85)},
+		{"percentage capped", factories.NewDiscount("percentage", "capped"), myr(100), myr(99)},
+		{"fixed price", factories.NewDiscount("fixed_price"), myr(10), myr(7)},
+		{"fixed price above price", factories.NewDiscount("fixed_price"), myr(5), myr(5)},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			as := assert.New(t)
+			got, err := tc.discount.Apply(tc.price)
+			as.Nil(err)
+			as.Equal(tc.want, got)
+		})
+	}
+
+	t.Run("different currency", func(t *testing.T) {
+		as := assert.New(t)
+		usd := domain.NewMoney(10, "USD")
+
+		_, err := factories.NewDiscount("fixed_price").Apply(usd)
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
+
+		_, err = factories.NewDiscount("percentage", "capped").Apply(usd)
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
 	})
 }
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserInactive         = errors.New("user is not active")
+	ErrUserBanned           = errors.New("user is banned")
+	ErrPurchaseLimitReached = errors.New("purchase limit reached")
+	ErrUserUnderage         = errors.New("user is under the minimum age")
+)
+
+// EligibilityRequest is what the eligibility policies are evaluated against.
+type EligibilityRequest struct {
+	User    User
+	Product Product
+	Unit    int
+	// PurchaseTimes are the times of the purchases by the user within the
+	// lookback of the policies, excluding the cancelled purchases.
+	PurchaseTimes []time.Time
+	At            time.Time
+}
+
+// EligibilityPolicy returns an error naming the rule when the user cannot
+// make the purchase.
+type EligibilityPolicy interface {
+	Check(req EligibilityRequest) error
+}
+
+// EligibilityPolicies passes only when all the policies pass, and returns
+// the error of the first policy that fails.
+type EligibilityPolicies []EligibilityPolicy
+
+func (ps EligibilityPolicies) Check(req EligibilityRequest) error {
+	for _, p := range ps {
+		if err := p.Check(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lookback returns how far back the purchases of the user are needed, which
+// is 0 when no policy needs them.
+func (ps EligibilityPolicies) Lookback() time.Duration {
+	var d time.Duration
+	for _, p := range ps {
+		if l, ok := p.(interface{ Lookback() time.Duration }); ok && l.Lookback() > d {
+			d = l.Lookback()
+		}
+	}
+
+	return d
+}
+
+// DefaultEligibilityPolicies are the policies that do not need any
+// configuration.
+func DefaultEligibilityPolicies() EligibilityPolicies {
+	return EligibilityPolicies{
+		AccountStatusPolicy{},
+		BannedUserPolicy{},
+		AgeRestrictionPolicy{},
+	}
+}
+
+// AccountStatusPolicy only allows the active users.
+type AccountStatusPolicy struct{}
+
+func (AccountStatusPolicy) Check(req EligibilityRequest) error {
+	if !req.User.IsActive() {
+		return ErrUserInactive
+	}
+
+	return nil
+}
+
+// BannedUserPolicy rejects the users while they are banned.
+type BannedUserPolicy struct{}
+
+func (BannedUserPolicy) Check(req EligibilityRequest) error {
+	if req.User.IsBannedAt(req.At) {
+		return ErrUserBanned
+	}
+
+	return nil
+}
+
+// MaxPurchasesPolicy limits the number of purchases by the user within the
+// rolling period.
+type MaxPurchasesPolicy struct {
+	Max    int
+	Period time.Duration
+}
+
+func (p MaxPurchasesPolicy) Check(req EligibilityRequest) error {
+	since := req.At.Add(-p.Period)
+
+	var n int
+	for _, t := range req.PurchaseTimes {
+		if t.After(since) {
+			n++
+		}
+	}
+
+	if n >= p.Max {
+		return ErrPurchaseLimitReached
+	}
+
+	return nil
+}
+
+func (p MaxPurchasesPolicy) Lookback() time.Duration {
+	return p.Period
+}
+
+// AgeRestrictionPolicy requires the user to be at least the MinAge of the
+// product. Users without a birth date cannot purchase the age restricted
+// products.
+type AgeRestrictionPolicy struct{}
+
+func (AgeRestrictionPolicy) Check(req EligibilityRequest) error {
+	if req.Product.MinAge <= 0 {
+		return nil
+	}
+
+	age, ok := req.User.AgeAt(req.At)
+	if !ok || age < req.Product.MinAge {
+		return ErrUserUnderage
+	}
+
+	return nil
+}
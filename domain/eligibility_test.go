@@ -0,0 +1,95 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEligibilityPolicies(t *testing.T) {
+	now := time.Now()
+
+	newRequest := func(userVariants ...string) domain.EligibilityRequest {
+		return domain.EligibilityRequest{
+			User:    *factories.NewUser(userVariants...),
+			Product: *factories.NewProduct("published"),
+			Unit:    1,
+			At:      now,
+		}
+	}
+
+	policies := domain.EligibilityPolicies{
+		domain.AccountStatusPolicy{},
+		domain.BannedUserPolicy{},
+		domain.MaxPurchasesPolicy{Max: 2, Period: 24 * time.Hour},
+		domain.AgeRestrictionPolicy{},
+	}
+
+	t.Run("eligible", func(t *testing.T) {
+		assert.Nil(t, policies.Check(newRequest()))
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		assert.ErrorIs(t, policies.Check(newRequest("suspended")), domain.ErrUserInactive)
+	})
+
+	t.Run("banned", func(t *testing.T) {
+		assert.ErrorIs(t, policies.Check(newRequest("banned")), domain.ErrUserBanned)
+
+		// The ban is lifted once it ends.
+		req := newRequest("banned")
+		req.At = req.User.BannedUntil.Add(time.Second)
+		assert.Nil(t, policies.Check(req))
+	})
+
+	t.Run("max purchases", func(t *testing.T) {
+		req := newRequest()
+		req.PurchaseTimes = []time.Time{now.Add(-time.Hour), now.Add(-48 * time.Hour)}
+		assert.Nil(t, policies.Check(req))
+
+		req.PurchaseTimes = append(req.PurchaseTimes, now.Add(-23*time.Hour))
+		assert.ErrorIs(t, policies.Check(req), domain.ErrPurchaseLimitReached)
+	})
+
+	t.Run("age restricted", func(t *testing.T) {
+		req := newRequest("adult")
+		req.Product = *factories.NewProduct("published", "age_restricted")
+		assert.Nil(t, policies.Check(req))
+
+		req.User = *factories.NewUser("minor")
+		assert.ErrorIs(t, policies.Check(req), domain.ErrUserUnderage)
+
+		req.User = *factories.NewUser()
+		assert.ErrorIs(t, policies.Check(req), domain.ErrUserUnderage)
+	})
+
+	t.Run("first failure", func(t *testing.T) {
+		assert.ErrorIs(t, policies.Check(newRequest("suspended", "banned")), domain.ErrUserInactive)
+	})
+
+	t.Run("lookback", func(t *testing.T) {
+		as := assert.New(t)
+		as.Equal(24*time.Hour, policies.Lookback())
+		as.Equal(time.Duration(0), domain.DefaultEligibilityPolicies().Lookback())
+	})
+}
+
+func TestUserAgeAt(t *testing.T) {
+	u := factories.NewUser()
+	u.BirthDate = types.Ptr(time.Date(2000, 7, 15, 0, 0, 0, 0, time.UTC))
+
+	as := assert.New(t)
+	age, ok := u.AgeAt(time.Date(2018, 7, 14, 0, 0, 0, 0, time.UTC))
+	as.True(ok)
+	as.Equal(17, age)
+
+	age, _ = u.AgeAt(time.Date(2018, 7, 15, 0, 0, 0, 0, time.UTC))
+	as.Equal(18, age)
+
+	_, ok = factories.NewUser().AgeAt(time.Now())
+	as.False(ok)
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a fact that happened in the domain.
+type Event interface {
+	EventName() string
+	OccurredAt() time.Time
+}
+
+// EventRecorder records the events raised by an aggregate, to be published
+// once the aggregate is persisted.
+type EventRecorder struct {
+	events []Event
+}
+
+func (r *EventRecorder) record(evt Event) {
+	r.events = append(r.events, evt)
+}
+
+// Events returns the recorded events.
+func (r *EventRecorder) Events() []Event {
+	return r.events
+}
+
+// PullEvents returns the recorded events and clears them.
+func (r *EventRecorder) PullEvents() []Event {
+	events := r.events
+	r.events = nil
+
+	return events
+}
+
+type ProductCreated struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	Name      ProductName
+	At        time.Time
+}
+
+func (e ProductCreated) EventName() string     { return "product.created" }
+func (e ProductCreated) OccurredAt() time.Time { return e.At }
+
+type ProductDeleted struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	At        time.Time
+}
+
+func (e ProductDeleted) EventName() string     { return "product.deleted" }
+func (e ProductDeleted) OccurredAt() time.Time { return e.At }
+
+type ProductRestored struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	At        time.Time
+}
+
+func (e ProductRestored) EventName() string     { return "product.restored" }
+func (e ProductRestored) OccurredAt() time.Time { return e.At }
+
+type ProductUpdated struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	Name      ProductName
+	Price     Money
+	Version   int
+	At        time.Time
+}
+
+func (e ProductUpdated) EventName() string     { return "product.updated" }
+func (e ProductUpdated) OccurredAt() time.Time { return e.At }
+
+type ProductPriceTiersUpdated struct {
+	ProductID  uuid.UUID
+	UserID     uuid.UUID
+	PriceTiers PriceTiers
+	Version    int
+	At         time.Time
+}
+
+func (e ProductPriceTiersUpdated) EventName() string     { return "product.price_tiers_updated" }
+func (e ProductPriceTiersUpdated) OccurredAt() time.Time { return e.At }
+
+type ProductPublished struct {
+	ProductID   uuid.UUID
+	PublishedAt time.Time
+	At          time.Time
+}
+
+func (e ProductPublished) EventName() string     { return "product.published" }
+func (e ProductPublished) OccurredAt() time.Time { return e.At }
+
+type ProductScheduled struct {
+	ProductID   uuid.UUID
+	PublishAt   time.Time
+	UnpublishAt *time.Time
+	At          time.Time
+}
+
+func (e ProductScheduled) EventName() string     { return "product.scheduled" }
+func (e ProductScheduled) OccurredAt() time.Time { return e.At }
+
+type ProductUnpublished struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	At        time.Time
+}
+
+func (e ProductUnpublished) EventName() string     { return "product.unpublished" }
+func (e ProductUnpublished) OccurredAt() time.Time { return e.At }
+
+type ProductExpired struct {
+	ProductID uuid.UUID
+	ExpiredAt time.Time
+	At        time.Time
+}
+
+func (e ProductExpired) EventName() string     { return "product.expired" }
+func (e ProductExpired) OccurredAt() time.Time { return e.At }
+
+type PurchaseCreated struct {
+	PurchaseID uuid.UUID
+	OrderID    *uuid.UUID
+	ProductID  uuid.UUID
+	UserID     uuid.UUID
+	Unit       int
+	Total      Money
+	At         time.Time
+}
+
+func (e PurchaseCreated) EventName() string     { return "purchase.created" }
+func (e PurchaseCreated) OccurredAt() time.Time { return e.At }
+
+type PurchaseStatusChanged struct {
+	PurchaseID uuid.UUID
+	From       PurchaseStatus
+	To         PurchaseStatus
+	At         time.Time
+}
+
+func (e PurchaseStatusChanged) EventName() string     { return "purchase.status_changed" }
+func (e PurchaseStatusChanged) OccurredAt() time.Time { return e.At }
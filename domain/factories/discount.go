@@ -2,8 +2,10 @@ package factories
 
 import (
 	"log"
+	"time"
 
 	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/types"
 	"github.com/google/uuid"
 )
 
@@ -13,16 +15,51 @@ func NewDiscount(variants ...string) *domain.Discount {
 		ID:             1,
 		Name:           "5$ off if you buy 2",
 		ProductID:      uuid.New(),
-		Amount:         -5,
+		Kind:           domain.DiscountKindFixedAmount,
+		Amount:         domain.NewMoney(-5, "MYR"),
 		MinPurchaseQty: 2,
+		Stacking:       domain.StackingStackable,
 	}
 
 	for _, v := range variants {
 		switch v {
 		case "positive_amount":
-			dis.Amount = 5
+			dis.Amount.Amount = 5
+		case "usd":
+			dis.Amount.Currency = "USD"
 		case "negative_purchase_qty":
 			dis.MinPurchaseQty = -1
+		case "percentage":
+			dis.Name = "15% off if you buy 2"
+			dis.Kind = domain.DiscountKindPercentage
+			dis.Amount.Amount = 0
+			dis.Percent = 15
+		case "percentage_out_of_range":
+			dis.Kind = domain.DiscountKindPercentage
+			dis.Amount.Amount = 0
+			dis.Percent = 101
+		case "fixed_price":
+			dis.Name = "7$ each if you buy 2"
+			dis.Kind = domain.DiscountKindFixedPrice
+			dis.Amount.Amount = 7
+		case "exclusive":
+			dis.Stacking = domain.StackingExclusive
+		case "best_of_group":
+			dis.Stacking = domain.StackingBestOfGroup
+			dis.Group = "members"
+		case "expired":
+			dis.StartsAt = types.Ptr(time.Now().Add(-2 * time.Hour))
+			dis.EndsAt = types.Ptr(time.Now().Add(-1 * time.Hour))
+		case "upcoming":
+			dis.StartsAt = types.Ptr(time.Now().Add(1 * time.Hour))
+		case "coupon":
+			dis.Name = "5$ off with coupon"
+			dis.Coupon = &domain.Coupon{
+				Code:       "SAVE5",
+				MaxPerUser: 1,
+			}
+		case "capped":
+			dis.Cap = types.Ptr(domain.NewMoney(1, dis.Amount.Currency))
 		default:
 			log.Fatalf("unknown Discount variant: %s", v)
 		}
@@ -17,7 +17,7 @@ func NewProduct(variants ...string) *domain.Product {
 		Name:        "colorful socks",
 		PublishedAt: types.Ptr(time.Now()),
 		UserID:      NewUser("john").ID, // Belongs to John.
-		Price:       10,
+		Price:       domain.NewMoney(10, "MYR"),
 	}
 
 	for _, v := range variants {
@@ -28,10 +28,33 @@ func NewProduct(variants ...string) *domain.Product {
 			p.PublishedAt = types.Ptr(time.Now().Add(-1 * time.Second))
 		case "no_published_at":
 			p.PublishedAt = nil
+		case "deleted":
+			p.DeletedAt = types.Ptr(time.Now().Add(-1 * time.Second))
+		case "expired":
+			p.PublishedAt = types.Ptr(time.Now().Add(-2 * time.Second))
+			p.UnpublishAt = types.Ptr(time.Now().Add(-1 * time.Second))
+		case "expiring":
+			p.PublishedAt = types.Ptr(time.Now().Add(-1 * time.Second))
+			p.UnpublishAt = types.Ptr(time.Now().Add(1 * time.Hour))
 		// TODO:
 		case "chair":
 		// implement specific product.
 		// If there are nested entities, we can use the factory to create them, e.g. with_discount.
+		case "tiered":
+			p.PriceTiers = domain.PriceTiers{
+				{MinQty: 1, MaxQty: 9, Price: domain.NewMoney(10, "MYR")},
+				{MinQty: 10, MaxQty: 49, Price: domain.NewMoney(9, "MYR")},
+				{MinQty: 50, Price: domain.NewMoney(8, "MYR")},
+			}
+		case "usd":
+			p.Price.Currency = "USD"
+		case "limited":
+			p.PurchaseLimits = domain.PurchaseLimits{
+				{Max: 2},
+				{Max: 1, Period: 24 * time.Hour},
+			}
+		case "age_restricted":
+			p.MinAge = 18
 		case "unknown_user":
 			p.UserID = uuid.New()
 		default:
@@ -0,0 +1,44 @@
+package factories
+
+import (
+	"log"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+func NewPurchase(variants ...string) *domain.Purchase {
+	// Pending purchase of 2 units at 5$ off by John.
+	now := time.Now()
+	p := &domain.Purchase{
+		ID:        uuid.New(),
+		UserID:    NewUser("john").ID,
+		ProductID: uuid.New(),
+		BasePrice: domain.NewMoney(10, "MYR"),
+		Discount:  domain.NewMoney(-5, "MYR"),
+		Unit:      2,
+		Status:    domain.PurchaseStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	for _, v := range variants {
+		switch v {
+		case "paid":
+			p.Status = domain.PurchaseStatusPaid
+		case "fulfilled":
+			p.Status = domain.PurchaseStatusFulfilled
+		case "cancelled":
+			p.Status = domain.PurchaseStatusCancelled
+		case "partially_refunded":
+			p.Status = domain.PurchaseStatusPartiallyRefunded
+		case "refunded":
+			p.Status = domain.PurchaseStatusRefunded
+		default:
+			log.Fatalf("unknown Purchase variant: %s", v)
+		}
+	}
+
+	return p
+}
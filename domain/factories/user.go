@@ -1,21 +1,33 @@
 package factories
 
 import (
+	"time"
+
 	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/types"
 	"github.com/google/uuid"
 )
 
 func NewUser(variants ...string) *domain.User {
 	// Random user.
 	u := &domain.User{
-		ID:   uuid.New(),
-		Name: "John Appleseed",
+		ID:     uuid.New(),
+		Name:   "John Appleseed",
+		Status: domain.UserStatusActive,
 	}
 
 	for _, v := range variants {
 		switch v {
 		case "john":
 			u.ID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+		case "suspended":
+			u.Status = domain.UserStatusSuspended
+		case "banned":
+			u.BannedUntil = types.Ptr(time.Now().Add(24 * time.Hour))
+		case "adult":
+			u.BirthDate = types.Ptr(time.Now().AddDate(-30, 0, 0))
+		case "minor":
+			u.BirthDate = types.Ptr(time.Now().AddDate(-16, 0, 0))
 		}
 	}
 
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyLockTimeout is how long a key stays in progress. A key that
+// is not completed by then, e.g. because the request crashed before the
+// outcome was stored, can be claimed again.
+const IdempotencyKeyLockTimeout = time.Minute
+
+// IdempotencyKey records the outcome of a purchase request, so that retries
+// with the same key return the same purchase instead of creating another.
+// Keys are scoped to the user, so that users cannot collide on the same key.
+type IdempotencyKey struct {
+	UserID      uuid.UUID
+	Key         string
+	RequestHash string    // Fingerprint of the request payload.
+	Purchase    *Purchase // Empty while the request is in progress.
+	CreatedAt   time.Time
+}
+
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.Purchase != nil
+}
+
+// IsExpired returns true if the key is still in progress after the lock
+// timeout.
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return !k.IsCompleted() && !now.Before(k.CreatedAt.Add(IdempotencyKeyLockTimeout))
+}
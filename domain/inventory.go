@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrInsufficientReserved = errors.New("cannot release more than reserved")
+	ErrNonPositiveUnit      = errors.New("unit must be positive")
+)
+
+type Inventory struct {
+	ProductID uuid.UUID
+	Stock     int // Units on hand.
+	Reserved  int // Units held for purchases.
+}
+
+func (i *Inventory) Available() int {
+	return i.Stock - i.Reserved
+}
+
+func (i *Inventory) Reserve(unit int) error {
+	if unit <= 0 {
+		return ErrNonPositiveUnit
+	}
+
+	if unit > i.Available() {
+		return ErrInsufficientStock
+	}
+
+	i.Reserved += unit
+
+	return nil
+}
+
+func (i *Inventory) Release(unit int) error {
+	if unit <= 0 {
+		return ErrNonPositiveUnit
+	}
+
+	if unit > i.Reserved {
+		return ErrInsufficientReserved
+	}
+
+	i.Reserved -= unit
+
+	return nil
+}
+
+// Commit removes the reserved units from the stock once they are sold.
+func (i *Inventory) Commit(unit int) error {
+	if unit <= 0 {
+		return ErrNonPositiveUnit
+	}
+
+	if unit > i.Reserved {
+		return ErrInsufficientReserved
+	}
+
+	i.Stock -= unit
+	i.Reserved -= unit
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory(t *testing.T) {
+	t.Run("reserve", func(t *testing.T) {
+		inv := &domain.Inventory{Stock: 3}
+
+		as := assert.New(t)
+		as.Nil(inv.Reserve(2))
+		as.Equal(1, inv.Available())
+		as.ErrorIs(inv.Reserve(2), domain.ErrInsufficientStock)
+		as.Nil(inv.Reserve(1))
+		as.Equal(0, inv.Available())
+		as.Equal(3, inv.Reserved)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		inv := &domain.Inventory{Stock: 3, Reserved: 2}
+
+		as := assert.New(t)
+		as.ErrorIs(inv.Release(3), domain.ErrInsufficientReserved)
+		as.Nil(inv.Release(2))
+		as.Equal(3, inv.Available())
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		inv := &domain.Inventory{Stock: 3, Reserved: 2}
+
+		as := assert.New(t)
+		as.ErrorIs(inv.Commit(3), domain.ErrInsufficientReserved)
+		as.Nil(inv.Commit(2))
+		as.Equal(1, inv.Stock)
+		as.Equal(0, inv.Reserved)
+		as.Equal(1, inv.Available())
+	})
+
+	t.Run("non-positive unit", func(t *testing.T) {
+		inv := &domain.Inventory{Stock: 3}
+
+		as := assert.New(t)
+		as.ErrorIs(inv.Reserve(0), domain.ErrNonPositiveUnit)
+		as.ErrorIs(inv.Release(-1), domain.ErrNonPositiveUnit)
+		as.ErrorIs(inv.Commit(0), domain.ErrNonPositiveUnit)
+	})
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrMoneyOverflow    = errors.New("money overflow")
+)
+
+var regexpCurrency = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Currency is the ISO-4217 alphabetic code, e.g. MYR, USD.
+type Currency string
+
+func (c Currency) Valid() bool {
+	return regexpCurrency.MatchString(string(c))
+}
+
+// Money is an amount in the minor unit of the currency, e.g. cents for USD
+// and sen for MYR.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return m, err
+	}
+
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) ||
+		(o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return m, ErrMoneyOverflow
+	}
+
+	return NewMoney(m.Amount+o.Amount, m.Currency), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return m, ErrMoneyOverflow
+	}
+
+	return m.Add(NewMoney(-o.Amount, o.Currency))
+}
+
+func (m Money) Mul(n int) (Money, error) {
+	a, b := m.Amount, int64(n)
+	if a == 0 || b == 0 {
+		return NewMoney(0, m.Currency), nil
+	}
+
+	r := a * b
+	if r/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return m, ErrMoneyOverflow
+	}
+
+	return NewMoney(r, m.Currency), nil
+}
+
+func (m Money) checkCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	return nil
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+}
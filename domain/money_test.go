@@ -0,0 +1,65 @@
+package domain_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrency(t *testing.T) {
+	as := assert.New(t)
+	as.True(domain.Currency("MYR").Valid())
+	as.False(domain.Currency("myr").Valid())
+	as.False(domain.Currency("RM").Valid())
+}
+
+func TestMoney(t *testing.T) {
+	myr := func(amount int64) domain.Money {
+		return domain.NewMoney(amount, "MYR")
+	}
+
+	t.Run("add", func(t *testing.T) {
+		as := assert.New(t)
+		m, err := myr(10).Add(myr(-15))
+		as.Nil(err)
+		as.Equal(myr(-5), m)
+		as.True(m.IsNegative())
+	})
+
+	t.Run("sub", func(t *testing.T) {
+		as := assert.New(t)
+		m, err := myr(10).Sub(myr(10))
+		as.Nil(err)
+		as.True(m.IsZero())
+	})
+
+	t.Run("mul", func(t *testing.T) {
+		as := assert.New(t)
+		m, err := myr(10).Mul(3)
+		as.Nil(err)
+		as.Equal(myr(30), m)
+	})
+
+	t.Run("different currency", func(t *testing.T) {
+		as := assert.New(t)
+		_, err := myr(10).Add(domain.NewMoney(10, "USD"))
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
+
+		_, err = myr(10).Sub(domain.NewMoney(10, "USD"))
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		as := assert.New(t)
+		_, err := myr(math.MaxInt64).Add(myr(1))
+		as.ErrorIs(err, domain.ErrMoneyOverflow)
+
+		_, err = myr(math.MinInt64).Sub(myr(1))
+		as.ErrorIs(err, domain.ErrMoneyOverflow)
+
+		_, err = myr(math.MaxInt64 / 2).Mul(3)
+		as.ErrorIs(err, domain.ErrMoneyOverflow)
+	})
+}
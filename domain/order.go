@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrOrderEmpty            = errors.New("order has no lines")
+	ErrOrderDuplicateProduct = errors.New("order has duplicate products")
+)
+
+// Order is the checkout of one or more products. Each line is priced as a
+// purchase, and the lines are created together.
+type Order struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Lines     []Purchase
+	CreatedAt time.Time
+}
+
+func NewOrder(userID uuid.UUID, lines []Purchase, now time.Time) (*Order, error) {
+	if len(lines) == 0 {
+		return nil, ErrOrderEmpty
+	}
+
+	o := &Order{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Lines:     make([]Purchase, len(lines)),
+		CreatedAt: now,
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for i, line := range lines {
+		if seen[line.ProductID] {
+			return nil, ErrOrderDuplicateProduct
+		}
+		seen[line.ProductID] = true
+
+		line.OrderID = &o.ID
+		line.UserID = userID
+		o.Lines[i] = line
+	}
+
+	// Lines must be in the same currency.
+	if _, err := o.Total(); err != nil {
+		return nil, err
+	}
+
+	for i := range o.Lines {
+		if err := o.Lines[i].MarkCreated(now); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// Total returns the amount paid for all lines.
+func (o *Order) Total() (Money, error) {
+	var total Money
+	for i, line := range o.Lines {
+		t, err := line.Total()
+		if err != nil {
+			return total, err
+		}
+
+		if i == 0 {
+			total = t
+			continue
+		}
+
+		total, err = total.Add(t)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
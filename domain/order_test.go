@@ -0,0 +1,58 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("valid", func(t *testing.T) {
+		a := factories.NewPurchase()
+		b := factories.NewPurchase()
+		b.Unit = 3
+
+		as := assert.New(t)
+		o, err := domain.NewOrder(userID, []domain.Purchase{*a, *b}, time.Now())
+		as.Nil(err)
+		as.Len(o.Lines, 2)
+		for _, line := range o.Lines {
+			as.Equal(o.ID, *line.OrderID)
+			as.Equal(userID, line.UserID)
+		}
+
+		total, err := o.Total()
+		as.Nil(err)
+		as.Equal(domain.NewMoney(25, "MYR"), total)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := domain.NewOrder(userID, nil, time.Now())
+		assert.ErrorIs(t, err, domain.ErrOrderEmpty)
+	})
+
+	t.Run("duplicate product", func(t *testing.T) {
+		a := factories.NewPurchase()
+		b := factories.NewPurchase()
+		b.ProductID = a.ProductID
+
+		_, err := domain.NewOrder(userID, []domain.Purchase{*a, *b}, time.Now())
+		assert.ErrorIs(t, err, domain.ErrOrderDuplicateProduct)
+	})
+
+	t.Run("different currency", func(t *testing.T) {
+		a := factories.NewPurchase()
+		b := factories.NewPurchase()
+		b.BasePrice.Currency = "USD"
+		b.Discount.Currency = "USD"
+
+		_, err := domain.NewOrder(userID, []domain.Purchase{*a, *b}, time.Now())
+		assert.ErrorIs(t, err, domain.ErrCurrencyMismatch)
+	})
+}
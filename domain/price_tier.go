@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPriceTierInvalid  = errors.New("invalid price tier")
+	ErrPriceTierUnsorted = errors.New("price tiers are not sorted by quantity")
+	ErrPriceTierOverlap  = errors.New("price tiers overlap")
+)
+
+// PriceTier is the unit price when buying between MinQty and MaxQty units.
+type PriceTier struct {
+	MinQty int
+	MaxQty int // Inclusive, 0 for no upper bound.
+	Price  Money
+}
+
+func (t PriceTier) Contains(unit int) bool {
+	return unit >= t.MinQty && (t.MaxQty == 0 || unit <= t.MaxQty)
+}
+
+// PriceTiers is the price schedule, sorted by quantity.
+type PriceTiers []PriceTier
+
+// Validate checks that the tiers are sorted, do not overlap and are priced
+// in the given currency.
+func (ts PriceTiers) Validate(currency Currency) error {
+	for i, t := range ts {
+		if t.MinQty <= 0 || (t.MaxQty != 0 && t.MaxQty < t.MinQty) || t.Price.IsNegative() {
+			return fmt.Errorf("%w: tier %d", ErrPriceTierInvalid, i)
+		}
+
+		if t.Price.Currency != currency {
+			return fmt.Errorf("%w: tier %d: %s and %s", ErrCurrencyMismatch, i, t.Price.Currency, currency)
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		prev := ts[i-1]
+		if t.MinQty < prev.MinQty {
+			return fmt.Errorf("%w: tier %d", ErrPriceTierUnsorted, i)
+		}
+
+		if prev.MaxQty == 0 || t.MinQty <= prev.MaxQty {
+			return fmt.Errorf("%w: tier %d and %d", ErrPriceTierOverlap, i-1, i)
+		}
+	}
+
+	return nil
+}
+
+// Find returns the tier that contains the unit.
+func (ts PriceTiers) Find(unit int) (PriceTier, bool) {
+	for _, t := range ts {
+		if t.Contains(unit) {
+			return t, true
+		}
+	}
+
+	return PriceTier{}, false
+}
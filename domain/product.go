@@ -2,13 +2,20 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-var ErrNegativePrice = errors.New("-tive price")
+var (
+	ErrNegativePrice        = errors.New("-tive price")
+	ErrPublishWindowInvalid = errors.New("unpublish time is not after the publish time")
+	ErrProductNotDeleted    = errors.New("product is not deleted")
+	ErrRestoreExpired       = errors.New("restore grace period has passed")
+	ErrPriceTiersInvalid    = errors.New("invalid price tiers")
+)
 
 var regexpProductName = regexp.MustCompile(`^[a-zA-Z0-9 ]+$`)
 
@@ -21,33 +28,224 @@ func (p ProductName) Valid() bool {
 }
 
 type Product struct {
+	EventRecorder
+
 	ID          uuid.UUID
 	Name        ProductName
 	PublishedAt *time.Time
+	UnpublishAt *time.Time // Optional, hides the product from this time.
 	UserID      uuid.UUID
-	Price       int
+	Price       Money
+	PriceTiers  PriceTiers // Optional, overrides the price for matching quantities.
+	TaxCategory TaxCategory
+	Version     int        // Incremented on every update, for optimistic concurrency.
+	DeletedAt   *time.Time // Set when the product is soft deleted.
+	MinAge      int        // The minimum age of the buyer, 0 for no restriction.
+	// PurchaseLimits caps the units that each user can buy. Optional.
+	PurchaseLimits PurchaseLimits
 }
 
-func (p *Product) IsPublished() bool {
-	if p.PublishedAt == nil {
+// IsPublishedAt returns true between the PublishedAt and the UnpublishAt,
+// unless the product is deleted.
+func (p *Product) IsPublishedAt(now time.Time) bool {
+	if p.PublishedAt == nil || p.IsDeleted() {
 		return false
 	}
 
-	return p.PublishedAt.Before(time.Now())
+	if !p.PublishedAt.Before(now) {
+		return false
+	}
+
+	return p.UnpublishAt == nil || now.Before(*p.UnpublishAt)
+}
+
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt != nil
 }
 
 func (p *Product) IsMine(userID uuid.UUID) bool {
 	return p.UserID == userID
 }
 
+// MarkCreated records that the product is created.
+func (p *Product) MarkCreated(now time.Time) {
+	p.record(ProductCreated{
+		ProductID: p.ID,
+		UserID:    p.UserID,
+		Name:      p.Name,
+		At:        now,
+	})
+}
+
+// Delete soft deletes the product, so that it can still be restored.
+func (p *Product) Delete(now time.Time) {
+	p.DeletedAt = &now
+	p.Version++
+	p.record(ProductDeleted{
+		ProductID: p.ID,
+		UserID:    p.UserID,
+		At:        now,
+	})
+}
+
+// Restore undoes the deletion within the grace period after the DeletedAt.
+func (p *Product) Restore(now time.Time, gracePeriod time.Duration) error {
+	if !p.IsDeleted() {
+		return ErrProductNotDeleted
+	}
+
+	if now.After(p.DeletedAt.Add(gracePeriod)) {
+		return ErrRestoreExpired
+	}
+
+	p.DeletedAt = nil
+	p.Version++
+	p.record(ProductRestored{
+		ProductID: p.ID,
+		UserID:    p.UserID,
+		At:        now,
+	})
+
+	return nil
+}
+
+// Update renames and reprices the product, and increments the version. The
+// price tiers must remain valid in the currency of the new price.
+func (p *Product) Update(name ProductName, price Money, now time.Time) error {
+	if err := validatePriceTiers(p.PriceTiers, price.Currency); err != nil {
+		return err
+	}
+
+	p.Name = name
+	p.Price = price
+	p.Version++
+	p.record(ProductUpdated{
+		ProductID: p.ID,
+		UserID:    p.UserID,
+		Name:      name,
+		Price:     price,
+		Version:   p.Version,
+		At:        now,
+	})
+
+	return nil
+}
+
+// SetPriceTiers replaces the price tiers, which must be priced in the
+// currency of the product.
+func (p *Product) SetPriceTiers(tiers PriceTiers, now time.Time) error {
+	if err := validatePriceTiers(tiers, p.Price.Currency); err != nil {
+		return err
+	}
+
+	p.PriceTiers = append(PriceTiers(nil), tiers...)
+	p.Version++
+	p.record(ProductPriceTiersUpdated{
+		ProductID:  p.ID,
+		UserID:     p.UserID,
+		PriceTiers: p.PriceTiers,
+		Version:    p.Version,
+		At:         now,
+	})
+
+	return nil
+}
+
+// Schedule publishes the product from publishAt, until the optional
+// unpublishAt. The transitions are recorded by Publish and Expire once the
+// times have passed.
+func (p *Product) Schedule(publishAt time.Time, unpublishAt *time.Time, now time.Time) error {
+	if unpublishAt != nil && !unpublishAt.After(publishAt) {
+		return ErrPublishWindowInvalid
+	}
+
+	p.PublishedAt = &publishAt
+	p.UnpublishAt = unpublishAt
+	p.Version++
+	p.record(ProductScheduled{
+		ProductID:   p.ID,
+		PublishAt:   publishAt,
+		UnpublishAt: unpublishAt,
+		At:          now,
+	})
+
+	return nil
+}
+
+// Unpublish hides the product immediately, and cancels the schedule.
+func (p *Product) Unpublish(now time.Time) {
+	p.PublishedAt = nil
+	p.UnpublishAt = nil
+	p.Version++
+	p.record(ProductUnpublished{
+		ProductID: p.ID,
+		UserID:    p.UserID,
+		At:        now,
+	})
+}
+
+// Expire records that the product is hidden after the UnpublishAt.
+func (p *Product) Expire(now time.Time) {
+	if p.UnpublishAt == nil {
+		return
+	}
+
+	p.record(ProductExpired{
+		ProductID: p.ID,
+		ExpiredAt: *p.UnpublishAt,
+		At:        now,
+	})
+}
+
+// Publish makes the product visible from the given time. The scheduler calls
+// it once the PublishedAt has passed, to record the transition.
+func (p *Product) Publish(at, now time.Time) {
+	p.PublishedAt = &at
+	p.record(ProductPublished{
+		ProductID:   p.ID,
+		PublishedAt: at,
+		At:          now,
+	})
+}
+
+// ValidatePriceTiers returns ErrPriceTiersInvalid if the stored tiers are
+// invalid. The tiers are validated when they are written, so this only guards
+// against the data that bypassed Update and SetPriceTiers.
+func (p *Product) ValidatePriceTiers() error {
+	return validatePriceTiers(p.PriceTiers, p.Price.Currency)
+}
+
+func validatePriceTiers(tiers PriceTiers, currency Currency) error {
+	if err := tiers.Validate(currency); err != nil {
+		return fmt.Errorf("%w: %w", ErrPriceTiersInvalid, err)
+	}
+
+	return nil
+}
+
+// UnitPrice returns the price of the tier that matches the unit, or the
+// product price when no tier matches.
+func (p *Product) UnitPrice(unit int) Money {
+	if t, ok := p.PriceTiers.Find(unit); ok {
+		return t.Price
+	}
+
+	return p.Price
+}
+
 func (p *Product) WithDiscount(discounts ...Discount) (*Product, error) {
 	pc := *p
 
 	for _, d := range discounts {
-		pc.Price += d.Amount
+		price, err := d.Apply(pc.Price)
+		if err != nil {
+			return p, err
+		}
+
+		pc.Price = price
 	}
 
-	if pc.Price < 0 {
+	if pc.Price.IsNegative() {
 		return p, ErrNegativePrice
 	}
 
@@ -2,39 +2,372 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-type ProductService struct{}
+type ProductService struct {
+	tax      TaxCalculator
+	policies EligibilityPolicies
+	now      func() time.Time
+}
+
+type ProductServiceOption func(*ProductService)
+
+func WithTaxCalculator(tax TaxCalculator) ProductServiceOption {
+	return func(svc *ProductService) {
+		svc.tax = tax
+	}
+}
 
-func NewProductService() *ProductService {
-	return &ProductService{}
+// WithClock sets the clock for the time-bound rules, such as the discount
+// validity. Defaults to time.Now.
+func WithClock(now func() time.Time) ProductServiceOption {
+	return func(svc *ProductService) {
+		svc.now = now
+	}
+}
+
+// WithEligibilityPolicies replaces the DefaultEligibilityPolicies.
+func WithEligibilityPolicies(policies ...EligibilityPolicy) ProductServiceOption {
+	return func(svc *ProductService) {
+		svc.policies = policies
+	}
+}
+
+func NewProductService(opts ...ProductServiceOption) *ProductService {
+	svc := &ProductService{
+		tax:      NoTaxCalculator{},
+		policies: DefaultEligibilityPolicies(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
+}
+
+// Now returns the current time of the clock.
+func (svc *ProductService) Now() time.Time {
+	return svc.now()
+}
+
+// CheckEligibility returns the error of the first eligibility policy that
+// fails.
+func (svc *ProductService) CheckEligibility(req EligibilityRequest) error {
+	return svc.policies.Check(req)
+}
+
+// EligibilityLookback returns how far back the purchases of the user are
+// needed by the eligibility policies.
+func (svc *ProductService) EligibilityLookback() time.Duration {
+	return svc.policies.Lookback()
 }
 
 func (svc *ProductService) PreparePurchase(ctx context.Context, unit int, p *Product, discounts []Discount) (*Purchase, error) {
+	if err := p.ValidatePriceTiers(); err != nil {
+		return nil, err
+	}
+
+	if len(discounts) > MaxDiscounts {
+		return nil, ErrDiscountsExceeded
+	}
+
+	// Discounts apply to the tier price.
+	pc := *p
+	pc.Price = p.UnitPrice(unit)
+	p = &pc
+
 	basePrice := p.Price
 
-	var validDiscounts []Discount
-	for _, d := range discounts {
+	now := svc.now()
+
+	// The reasons are indexed like the discounts, since the IDs may repeat.
+	reasons := make([]error, len(discounts))
+
+	var valid []int
+	for i, d := range discounts {
 		if !d.IsValid() {
-			return nil, ErrNegativePrice
+			return nil, ErrDiscountInvalid
+		}
+
+		// Expired or upcoming discounts are skipped silently.
+		if !d.IsActiveAt(now) {
+			continue
 		}
 
 		if unit < d.MinPurchaseQty {
+			reasons[i] = ErrDiscountMinPurchaseQty
 			continue
 		}
 
-		validDiscounts = append(validDiscounts, d)
+		valid = append(valid, i)
+	}
+
+	best, err := svc.bestDiscounts(p, discounts, valid, reasons)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]Discount, len(best))
+	for j, i := range best {
+		applied[j] = discounts[i]
+	}
+
+	p, err = p.WithDiscount(applied...)
+	if err != nil {
+		return nil, err
+	}
+
+	discount, err := p.Price.Sub(basePrice)
+	if err != nil {
+		return nil, err
+	}
+
+	var appliedIDs []int64
+	for _, d := range applied {
+		appliedIDs = append(appliedIDs, d.ID)
+	}
+
+	var rejected []DiscountRejection
+	for i, reason := range reasons {
+		if reason != nil {
+			rejected = append(rejected, DiscountRejection{
+				DiscountID: discounts[i].ID,
+				Reason:     reason,
+			})
+		}
+	}
+
+	purchase := &Purchase{
+		ID:                 uuid.New(),
+		ProductID:          p.ID,
+		BasePrice:          basePrice,
+		Discount:           discount,
+		Unit:               unit,
+		Status:             PurchaseStatusPending,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		AppliedDiscountIDs: appliedIDs,
+		RejectedDiscounts:  rejected,
 	}
 
-	p, err := p.WithDiscount(validDiscounts...)
+	tax, err := svc.tax.CalculateTax(p.TaxCategory, *purchase)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Purchase{
-		ProductID: p.ID,
-		BasePrice: basePrice,
-		Discount:  p.Price - basePrice,
-		Unit:      unit,
-	}, nil
+	purchase.Tax = tax.Amount
+	purchase.TaxRate = tax.Rate
+	purchase.TaxInclusive = tax.Inclusive
+
+	return purchase, nil
+}
+
+// bestDiscounts returns the indices of the valid discounts that together give
+// the lowest non-negative price, in the order they should be applied.
+//
+// The stacked candidate takes the stackable discounts from the largest
+// saving, then the best discount of each group on top of them, skipping any
+// discount that makes the price negative. It is compared with the best
+// exclusive discount on its own. When the prices are equal, the candidate
+// whose first discount has the higher priority wins, then the candidate with
+// more discounts. Every step prices the candidate once per discount, so the
+// cost grows with the square of the discounts, and not with their subsets.
+//
+// The reason for each valid discount that is left out is recorded in reasons.
+func (svc *ProductService) bestDiscounts(p *Product, discounts []Discount, valid []int, reasons []error) ([]int, error) {
+	idx := make([]int, len(valid))
+	copy(idx, valid)
+	sort.SliceStable(idx, func(a, b int) bool {
+		return discounts[idx[a]].Priority > discounts[idx[b]].Priority
+	})
+
+	rank := make(map[int]int, len(idx))
+	for r, i := range idx {
+		rank[i] = r
+	}
+
+	// price returns the price after the candidate, applied by priority, and
+	// false when it is negative.
+	price := func(c []int) (Money, bool, error) {
+		c = append([]int(nil), c...)
+		sort.Slice(c, func(a, b int) bool {
+			return rank[c[a]] < rank[c[b]]
+		})
+
+		ds := make([]Discount, len(c))
+		for j, i := range c {
+			ds[j] = discounts[i]
+		}
+
+		pc, err := p.WithDiscount(ds...)
+		if errors.Is(err, ErrNegativePrice) {
+			return Money{}, false, nil
+		}
+		if err != nil {
+			return Money{}, false, err
+		}
+
+		return pc.Price, true, nil
+	}
+
+	var (
+		stackable []int
+		exclusive []int
+		groups    [][]int
+	)
+	group := make(map[string]int)
+	for _, i := range idx {
+		switch d := discounts[i]; d.Stacking {
+		case StackingExclusive:
+			exclusive = append(exclusive, i)
+		case StackingBestOfGroup:
+			g, ok := group[d.Group]
+			if !ok {
+				g = len(groups)
+				group[d.Group] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		default:
+			stackable = append(stackable, i)
+		}
+	}
+
+	// The stackable discounts with the largest saving are taken first, so
+	// that the smaller ones are left out when the price turns negative.
+	alone := make(map[int]Money, len(stackable))
+	for _, i := range stackable {
+		m, ok, err := price([]int{i})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			alone[i] = m
+		}
+	}
+
+	sort.SliceStable(stackable, func(a, b int) bool {
+		ma, oka := alone[stackable[a]]
+		mb, okb := alone[stackable[b]]
+		if oka != okb {
+			return oka
+		}
+
+		return ma.Amount < mb.Amount
+	})
+
+	var (
+		stacked      []int
+		stackedPrice = p.Price
+	)
+	for _, i := range stackable {
+		m, ok, err := price(append(stacked, i))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			stacked, stackedPrice = append(stacked, i), m
+		}
+	}
+
+	// The best discount of each group is picked on top of the discounts
+	// before it, since a percentage saves less on a lower price.
+	for _, g := range groups {
+		best, bestPrice := -1, stackedPrice
+		for _, i := range g {
+			m, ok, err := price(append(stacked, i))
+			if err != nil {
+				return nil, err
+			}
+			if ok && (best == -1 || m.Amount < bestPrice.Amount) {
+				best, bestPrice = i, m
+			}
+		}
+
+		if best != -1 {
+			stacked, stackedPrice = append(stacked, best), bestPrice
+		}
+	}
+
+	var (
+		best      []int
+		bestPrice = p.Price
+	)
+
+	consider := func(c []int, m Money) {
+		if len(c) == 0 {
+			return
+		}
+
+		c = append([]int(nil), c...)
+		sort.Slice(c, func(a, b int) bool {
+			return rank[c[a]] < rank[c[b]]
+		})
+
+		better := m.Amount < bestPrice.Amount
+		if best != nil && m.Amount == bestPrice.Amount {
+			pc, pb := discounts[c[0]].Priority, discounts[best[0]].Priority
+			better = pc > pb || (pc == pb && len(c) > len(best))
+		}
+
+		if better {
+			best, bestPrice = c, m
+		}
+	}
+
+	consider(stacked, stackedPrice)
+	for _, i := range exclusive {
+		m, ok, err := price([]int{i})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			consider([]int{i}, m)
+		}
+	}
+
+	// A discount that is left out is outperformed, unless adding it to the
+	// best candidate makes the price negative.
+	applied := make(map[int]bool, len(best))
+	isStacked := len(best) > 0 && discounts[best[0]].Stacking != StackingExclusive
+	for _, i := range best {
+		applied[i] = true
+	}
+
+	for _, i := range idx {
+		if applied[i] {
+			continue
+		}
+
+		c := []int{i}
+		if d := discounts[i]; d.Stacking != StackingExclusive && isStacked {
+			for _, j := range best {
+				if o := discounts[j]; o.Stacking == StackingBestOfGroup && d.Stacking == StackingBestOfGroup && o.Group == d.Group {
+					continue
+				}
+
+				c = append(c, j)
+			}
+		}
+
+		_, ok, err := price(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			reasons[i] = ErrDiscountOutperformed
+		} else {
+			reasons[i] = ErrNegativePrice
+		}
+	}
+
+	return best, nil
 }
@@ -0,0 +1,327 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductServicePreparePurchase(t *testing.T) {
+	ctx := context.Background()
+	svc := domain.NewProductService()
+
+	tests := []struct {
+		name     string
+		discount *domain.Discount
+		want     domain.Money
+	}{
+		{"fixed amount", factories.NewDiscount(), domain.NewMoney(-5, "MYR")},
+		{"percentage", factories.NewDiscount("percentage"), domain.NewMoney(-2, "MYR")},
+		{"fixed price", factories.NewDiscount("fixed_price"), domain.NewMoney(-3, "MYR")},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			as := assert.New(t)
+
+			p := factories.NewProduct()
+			purchase, err := svc.PreparePurchase(ctx, 2, p, []domain.Discount{*tc.discount})
+			as.Nil(err)
+			as.Equal(p.Price, purchase.BasePrice)
+			as.Equal(tc.want, purchase.Discount)
+		})
+	}
+
+	t.Run("invalid discount", func(t *testing.T) {
+		d := factories.NewDiscount("percentage_out_of_range")
+		_, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{*d})
+		assert.ErrorIs(t, err, domain.ErrDiscountInvalid)
+	})
+}
+
+func TestProductServicePreparePurchaseTiered(t *testing.T) {
+	ctx := context.Background()
+	svc := domain.NewProductService()
+
+	t.Run("discount applies to tier price", func(t *testing.T) {
+		as := assert.New(t)
+
+		p := factories.NewProduct("tiered")
+		d := factories.NewDiscount("percentage")
+		d.Percent = 50
+
+		purchase, err := svc.PreparePurchase(ctx, 10, p, []domain.Discount{*d})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(9, "MYR"), purchase.BasePrice)
+		as.Equal(domain.NewMoney(-5, "MYR"), purchase.Discount)
+	})
+
+	t.Run("invalid tiers", func(t *testing.T) {
+		p := factories.NewProduct("tiered")
+		p.PriceTiers[0].MaxQty = 10
+
+		_, err := svc.PreparePurchase(ctx, 10, p, nil)
+		assert.ErrorIs(t, err, domain.ErrPriceTierOverlap)
+	})
+}
+
+func TestProductServicePreparePurchaseTax(t *testing.T) {
+	ctx := context.Background()
+	svc := domain.NewProductService(domain.WithTaxCalculator(&domain.RateTaxCalculator{
+		Rates: map[domain.TaxCategory]int{"": 600},
+	}))
+
+	p := factories.NewProduct()
+	p.Price.Amount = 1000
+	d := factories.NewDiscount()
+	d.Amount.Amount = -500
+
+	as := assert.New(t)
+	purchase, err := svc.PreparePurchase(ctx, 2, p, []domain.Discount{*d})
+	as.Nil(err)
+	as.Equal(domain.NewMoney(60, "MYR"), purchase.Tax)
+	as.Equal(600, purchase.TaxRate)
+	as.False(purchase.TaxInclusive)
+
+	total, err := purchase.Total()
+	as.Nil(err)
+	as.Equal(domain.NewMoney(1060, "MYR"), total)
+
+	t.Run("unknown category", func(t *testing.T) {
+		p := factories.NewProduct()
+		p.TaxCategory = "unknown"
+
+		_, err := svc.PreparePurchase(ctx, 2, p, nil)
+		assert.ErrorIs(t, err, domain.ErrTaxCategoryUnknown)
+	})
+}
+
+func TestProductServicePreparePurchaseStacking(t *testing.T) {
+	ctx := context.Background()
+	svc := domain.NewProductService()
+
+	newDiscount := func(id int64, variants ...string) domain.Discount {
+		d := factories.NewDiscount(variants...)
+		d.ID = id
+		return *d
+	}
+
+	t.Run("stackable discounts are combined", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		a.Amount.Amount = -2
+		b := newDiscount(2)
+		b.Amount.Amount = -3
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-5, "MYR"), purchase.Discount)
+		as.Equal([]int64{1, 2}, purchase.AppliedDiscountIDs)
+		as.Empty(purchase.RejectedDiscounts)
+	})
+
+	t.Run("exclusive discount is better", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		a.Amount.Amount = -2
+		b := newDiscount(2, "fixed_price", "exclusive")
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-3, "MYR"), purchase.Discount)
+		as.Equal([]int64{2}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 1, Reason: domain.ErrDiscountOutperformed}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("stacked discounts are better", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		b := newDiscount(2)
+		b.Amount.Amount = -1
+		c := newDiscount(3, "fixed_price", "exclusive")
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b, c})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-6, "MYR"), purchase.Discount)
+		as.Equal([]int64{1, 2}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 3, Reason: domain.ErrDiscountOutperformed}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("best of group", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1, "best_of_group")
+		b := newDiscount(2, "percentage", "best_of_group")
+		c := newDiscount(3)
+		c.Amount.Amount = -1
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b, c})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-6, "MYR"), purchase.Discount)
+		as.Equal([]int64{1, 3}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 2, Reason: domain.ErrDiscountOutperformed}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("higher priority wins on equal price", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1, "exclusive")
+		b := newDiscount(2, "exclusive")
+		b.Priority = 1
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b})
+		as.Nil(err)
+		as.Equal([]int64{2}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 1, Reason: domain.ErrDiscountOutperformed}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("higher priority applies first", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1, "percentage")
+		b := newDiscount(2)
+		b.Priority = 1
+
+		// 10 - 5 = 5, then 15% off 5 is 0.75, rounded to 1.
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-6, "MYR"), purchase.Discount)
+		as.Equal([]int64{2, 1}, purchase.AppliedDiscountIDs)
+	})
+
+	t.Run("min purchase qty not met", func(t *testing.T) {
+		as := assert.New(t)
+
+		purchase, err := svc.PreparePurchase(ctx, 1, factories.NewProduct(), []domain.Discount{newDiscount(1)})
+		as.Nil(err)
+		as.True(purchase.Discount.IsZero())
+		as.Empty(purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 1, Reason: domain.ErrDiscountMinPurchaseQty}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("negative price", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		b := newDiscount(2)
+		b.Amount.Amount = -6
+
+		// Both together is negative, so the better one applies alone.
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-6, "MYR"), purchase.Discount)
+		as.Equal([]int64{2}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 1, Reason: domain.ErrNegativePrice}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("group winner after stacked discounts", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		a.Priority = 1
+		b := newDiscount(2, "percentage", "best_of_group")
+		b.Percent = 40
+		c := newDiscount(3, "best_of_group")
+		c.Amount.Amount = -3
+
+		// 40% off is better on 10, but 3$ off is better on 10 - 5 = 5.
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b, c})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-8, "MYR"), purchase.Discount)
+		as.Equal([]int64{1, 3}, purchase.AppliedDiscountIDs)
+		as.Equal([]domain.DiscountRejection{{DiscountID: 2, Reason: domain.ErrDiscountOutperformed}}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("same ids", func(t *testing.T) {
+		as := assert.New(t)
+
+		a := newDiscount(1)
+		b := newDiscount(1)
+		b.MinPurchaseQty = 3
+		c := newDiscount(1, "exclusive")
+		c.Amount.Amount = -1
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{a, b, c})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-5, "MYR"), purchase.Discount)
+		as.Equal([]domain.DiscountRejection{
+			{DiscountID: 1, Reason: domain.ErrDiscountMinPurchaseQty},
+			{DiscountID: 1, Reason: domain.ErrDiscountOutperformed},
+		}, purchase.RejectedDiscounts)
+	})
+
+	t.Run("too many discounts", func(t *testing.T) {
+		ds := make([]domain.Discount, domain.MaxDiscounts+1)
+		for i := range ds {
+			ds[i] = newDiscount(int64(i))
+		}
+
+		_, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), ds)
+		assert.ErrorIs(t, err, domain.ErrDiscountsExceeded)
+	})
+}
+
+func BenchmarkProductServicePreparePurchase(b *testing.B) {
+	ctx := context.Background()
+	svc := domain.NewProductService()
+
+	p := factories.NewProduct()
+	p.Price.Amount = 1_000_000
+
+	variants := [][]string{nil, {"percentage"}, {"best_of_group"}, {"percentage", "best_of_group"}, {"exclusive"}}
+	ds := make([]domain.Discount, domain.MaxDiscounts)
+	for i := range ds {
+		d := factories.NewDiscount(variants[i%len(variants)]...)
+		d.ID = int64(i)
+		d.Priority = i % 3
+		ds[i] = *d
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := svc.PreparePurchase(ctx, 2, p, ds); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestProductServicePreparePurchaseValidity(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	svc := domain.NewProductService(domain.WithClock(func() time.Time { return now }))
+
+	prepare := func(startsAt, endsAt *time.Time) *domain.Purchase {
+		d := factories.NewDiscount()
+		d.StartsAt = startsAt
+		d.EndsAt = endsAt
+
+		purchase, err := svc.PreparePurchase(ctx, 2, factories.NewProduct(), []domain.Discount{*d})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Inactive discounts are skipped silently.
+		assert.Empty(t, purchase.RejectedDiscounts)
+
+		return purchase
+	}
+
+	before := now.Add(-time.Nanosecond)
+	after := now.Add(time.Nanosecond)
+
+	as := assert.New(t)
+	as.False(prepare(&now, nil).Discount.IsZero(), "starts now")
+	as.True(prepare(&after, nil).Discount.IsZero(), "not yet started")
+	as.False(prepare(nil, &after).Discount.IsZero(), "ends after now")
+	as.True(prepare(nil, &now).Discount.IsZero(), "ends now")
+	as.True(prepare(&before, &now).Discount.IsZero(), "expired")
+}
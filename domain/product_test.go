@@ -2,6 +2,7 @@ package domain_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/alextanhongpin/go-domain-test/domain/factories"
@@ -9,11 +10,87 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestProductIsPublished(t *testing.T) {
+func TestProductIsPublishedAt(t *testing.T) {
+	now := time.Now()
+
 	as := assert.New(t)
-	as.False(factories.NewProduct("no_published_at").IsPublished())
-	as.True(factories.NewProduct("published").IsPublished())
-	as.False(factories.NewProduct("published_in_the_future").IsPublished())
+	as.False(factories.NewProduct("no_published_at").IsPublishedAt(now))
+	as.True(factories.NewProduct("published").IsPublishedAt(now))
+	as.False(factories.NewProduct("published_in_the_future").IsPublishedAt(now))
+	as.True(factories.NewProduct("expiring").IsPublishedAt(now))
+	as.False(factories.NewProduct("expired").IsPublishedAt(now))
+	as.False(factories.NewProduct("published", "deleted").IsPublishedAt(now))
+}
+
+func TestProductRestore(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		p.Delete(now)
+		as := assert.New(t)
+		as.True(p.IsDeleted())
+		as.False(p.IsPublishedAt(now))
+
+		as.Nil(p.Restore(now.Add(time.Hour), time.Hour))
+		as.False(p.IsDeleted())
+		as.Equal(2, p.Version)
+
+		events := p.PullEvents()
+		if as.Len(events, 2) {
+			as.Equal("product.deleted", events[0].EventName())
+			as.Equal("product.restored", events[1].EventName())
+		}
+	})
+
+	t.Run("not deleted", func(t *testing.T) {
+		p := factories.NewProduct()
+		assert.ErrorIs(t, p.Restore(now, time.Hour), domain.ErrProductNotDeleted)
+	})
+
+	t.Run("after the grace period", func(t *testing.T) {
+		p := factories.NewProduct()
+		p.Delete(now)
+
+		assert.ErrorIs(t, p.Restore(now.Add(time.Hour+time.Nanosecond), time.Hour), domain.ErrRestoreExpired)
+		assert.True(t, p.IsDeleted())
+	})
+}
+
+func TestProductSchedule(t *testing.T) {
+	now := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		p := factories.NewProduct("no_published_at")
+		until := now.Add(time.Hour)
+
+		as := assert.New(t)
+		as.Nil(p.Schedule(now.Add(-time.Second), &until, now))
+		as.True(p.IsPublishedAt(now))
+		as.Equal(1, p.Version)
+
+		p.Expire(now)
+		p.Unpublish(now)
+		as.False(p.IsPublishedAt(now))
+		as.Nil(p.UnpublishAt)
+		as.Equal(2, p.Version)
+
+		events := p.PullEvents()
+		if as.Len(events, 3) {
+			as.Equal("product.scheduled", events[0].EventName())
+			as.Equal("product.expired", events[1].EventName())
+			as.Equal("product.unpublished", events[2].EventName())
+		}
+	})
+
+	t.Run("unpublish before publish", func(t *testing.T) {
+		p := factories.NewProduct("no_published_at")
+
+		as := assert.New(t)
+		as.ErrorIs(p.Schedule(now, &now, now), domain.ErrPublishWindowInvalid)
+		as.Nil(p.PublishedAt)
+		as.Empty(p.Events())
+	})
 }
 
 func TestProductIsMine(t *testing.T) {
@@ -24,12 +101,126 @@ func TestProductIsMine(t *testing.T) {
 	as.False(p.IsMine(uuid.New()))
 }
 
+func TestProductEvents(t *testing.T) {
+	now := time.Now()
+	p := factories.NewProduct("no_published_at")
+	p.MarkCreated(now)
+
+	at := now.Add(time.Hour)
+	p.Publish(at, at)
+	p.Delete(at)
+
+	as := assert.New(t)
+	as.Equal(&at, p.PublishedAt)
+
+	events := p.PullEvents()
+	if as.Len(events, 3) {
+		as.Equal("product.created", events[0].EventName())
+		as.Equal("product.published", events[1].EventName())
+		as.Equal("product.deleted", events[2].EventName())
+	}
+	as.Empty(p.PullEvents())
+}
+
+func TestProductUpdate(t *testing.T) {
+	p := factories.NewProduct()
+
+	as := assert.New(t)
+	as.Nil(p.Update("striped socks", domain.NewMoney(12, "MYR"), time.Now()))
+	as.Equal(domain.ProductName("striped socks"), p.Name)
+	as.Equal(domain.NewMoney(12, "MYR"), p.Price)
+	as.Equal(1, p.Version)
+
+	events := p.PullEvents()
+	if as.Len(events, 1) {
+		evt, ok := events[0].(domain.ProductUpdated)
+		as.True(ok)
+		as.Equal(p.ID, evt.ProductID)
+		as.Equal(1, evt.Version)
+	}
+}
+
+func TestProductSetPriceTiers(t *testing.T) {
+	tiers := factories.NewProduct("tiered").PriceTiers
+
+	p := factories.NewProduct()
+
+	as := assert.New(t)
+	as.Nil(p.SetPriceTiers(tiers, time.Now()))
+	as.Equal(tiers, p.PriceTiers)
+	as.Equal(1, p.Version)
+
+	events := p.PullEvents()
+	if as.Len(events, 1) {
+		evt, ok := events[0].(domain.ProductPriceTiersUpdated)
+		as.True(ok)
+		as.Equal(tiers, evt.PriceTiers)
+		as.Equal(1, evt.Version)
+	}
+
+	// The tiers are validated when written.
+	unsorted := domain.PriceTiers{tiers[1], tiers[0]}
+	as.ErrorIs(p.SetPriceTiers(unsorted, time.Now()), domain.ErrPriceTiersInvalid)
+	as.ErrorIs(p.SetPriceTiers(unsorted, time.Now()), domain.ErrPriceTierUnsorted)
+	as.ErrorIs(p.Update(p.Name, domain.NewMoney(12, "USD"), time.Now()), domain.ErrCurrencyMismatch)
+	as.Equal(tiers, p.PriceTiers)
+	as.Equal(domain.NewMoney(10, "MYR"), p.Price)
+}
+
 func TestProductName(t *testing.T) {
 	as := assert.New(t)
 	as.True(domain.ProductName("colorful stocks").Valid())
 	as.False(domain.ProductName("%!@").Valid())
 }
 
+func TestProductUnitPrice(t *testing.T) {
+	p := factories.NewProduct("tiered")
+	p.Price = domain.NewMoney(11, "MYR")
+
+	as := assert.New(t)
+	as.Nil(p.ValidatePriceTiers())
+	as.Equal(domain.NewMoney(11, "MYR"), p.UnitPrice(0))
+	as.Equal(domain.NewMoney(10, "MYR"), p.UnitPrice(1))
+	as.Equal(domain.NewMoney(10, "MYR"), p.UnitPrice(9))
+	as.Equal(domain.NewMoney(9, "MYR"), p.UnitPrice(10))
+	as.Equal(domain.NewMoney(9, "MYR"), p.UnitPrice(49))
+	as.Equal(domain.NewMoney(8, "MYR"), p.UnitPrice(50))
+	as.Equal(domain.NewMoney(8, "MYR"), p.UnitPrice(1000))
+
+	t.Run("without tiers", func(t *testing.T) {
+		p := factories.NewProduct()
+
+		as := assert.New(t)
+		as.Nil(p.ValidatePriceTiers())
+		as.Equal(p.Price, p.UnitPrice(100))
+	})
+}
+
+func TestProductValidatePriceTiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(domain.PriceTiers)
+		wantErr error
+	}{
+		{"unsorted", func(ts domain.PriceTiers) { ts[0], ts[1] = ts[1], ts[0] }, domain.ErrPriceTierUnsorted},
+		{"overlap", func(ts domain.PriceTiers) { ts[0].MaxQty = 10 }, domain.ErrPriceTierOverlap},
+		{"unbounded before last", func(ts domain.PriceTiers) { ts[1].MaxQty = 0 }, domain.ErrPriceTierOverlap},
+		{"max before min", func(ts domain.PriceTiers) { ts[1].MaxQty = 5 }, domain.ErrPriceTierInvalid},
+		{"zero min qty", func(ts domain.PriceTiers) { ts[0].MinQty = 0 }, domain.ErrPriceTierInvalid},
+		{"negative price", func(ts domain.PriceTiers) { ts[2].Price.Amount = -1 }, domain.ErrPriceTierInvalid},
+		{"different currency", func(ts domain.PriceTiers) { ts[2].Price.Currency = "USD" }, domain.ErrCurrencyMismatch},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := factories.NewProduct("tiered")
+			tc.fn(p.PriceTiers)
+			assert.ErrorIs(t, p.ValidatePriceTiers(), tc.wantErr)
+		})
+	}
+}
+
 func TestProductDiscount(t *testing.T) {
 	t.Run("+tive price after discount", func(t *testing.T) {
 		d := factories.NewDiscount()
@@ -37,7 +228,7 @@ func TestProductDiscount(t *testing.T) {
 		as := assert.New(t)
 		p, err := p.WithDiscount(*d)
 		as.Nil(err)
-		as.Equal(5, p.Price)
+		as.Equal(domain.NewMoney(5, "MYR"), p.Price)
 	})
 
 	t.Run("0 price after discount", func(t *testing.T) {
@@ -46,7 +237,7 @@ func TestProductDiscount(t *testing.T) {
 		as := assert.New(t)
 		p, err := p.WithDiscount(*d, *d)
 		as.Nil(err)
-		as.Equal(0, p.Price)
+		as.Equal(domain.NewMoney(0, "MYR"), p.Price)
 	})
 
 	t.Run("-tive price after discount", func(t *testing.T) {
@@ -55,6 +246,15 @@ func TestProductDiscount(t *testing.T) {
 		as := assert.New(t)
 		p, err := p.WithDiscount(*d, *d, *d)
 		as.ErrorIs(err, domain.ErrNegativePrice)
-		as.Equal(10, p.Price)
+		as.Equal(domain.NewMoney(10, "MYR"), p.Price)
+	})
+
+	t.Run("different currency", func(t *testing.T) {
+		d := factories.NewDiscount("usd")
+		p := factories.NewProduct()
+		as := assert.New(t)
+		p, err := p.WithDiscount(*d)
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
+		as.Equal(domain.NewMoney(10, "MYR"), p.Price)
 	})
 }
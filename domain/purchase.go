@@ -1,10 +1,157 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrPurchaseInvalidTransition = errors.New("invalid purchase status transition")
+
+type PurchaseStatus string
+
+const (
+	PurchaseStatusPending   PurchaseStatus = "pending"
+	PurchaseStatusPaid      PurchaseStatus = "paid"
+	PurchaseStatusFulfilled PurchaseStatus = "fulfilled"
+	PurchaseStatusCancelled PurchaseStatus = "cancelled"
+	PurchaseStatusRefunded  PurchaseStatus = "refunded"
+
+	PurchaseStatusPartiallyRefunded PurchaseStatus = "partially_refunded"
+)
+
+// purchaseTransitions lists the next statuses allowed for each status.
+var purchaseTransitions = map[PurchaseStatus][]PurchaseStatus{
+	PurchaseStatusPending:   {PurchaseStatusPaid, PurchaseStatusCancelled},
+	PurchaseStatusPaid:      {PurchaseStatusFulfilled, PurchaseStatusPartiallyRefunded, PurchaseStatusRefunded},
+	PurchaseStatusFulfilled: {PurchaseStatusPartiallyRefunded, PurchaseStatusRefunded},
+
+	PurchaseStatusPartiallyRefunded: {PurchaseStatusPartiallyRefunded, PurchaseStatusRefunded},
+}
+
+func (s PurchaseStatus) CanTransitionTo(next PurchaseStatus) bool {
+	for _, status := range purchaseTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
 
 type Purchase struct {
+	EventRecorder
+
+	ID        uuid.UUID
+	OrderID   *uuid.UUID // Only when the purchase is part of an order.
+	UserID    uuid.UUID
 	ProductID uuid.UUID
-	BasePrice int
-	Discount  int
+	BasePrice Money
+	Discount  Money
 	Unit      int
+	Tax       Money // For all units.
+	TaxRate   int   // In basis points.
+	Status    PurchaseStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	AppliedDiscountIDs []int64
+	RejectedDiscounts  []DiscountRejection
+	CouponCodes        []string // The redeemed coupon codes.
+	TaxInclusive       bool     // Whether the tax is included in the price.
+}
+
+// Total returns the amount paid for all units, including tax.
+func (p *Purchase) Total() (Money, error) {
+	price, err := p.BasePrice.Add(p.Discount)
+	if err != nil {
+		return p.BasePrice, err
+	}
+
+	total, err := price.Mul(p.Unit)
+	if err != nil {
+		return total, err
+	}
+
+	if p.TaxInclusive || p.Tax.IsZero() {
+		return total, nil
+	}
+
+	return total.Add(p.Tax)
+}
+
+// MarkCreated records that the purchase is created.
+func (p *Purchase) MarkCreated(now time.Time) error {
+	total, err := p.Total()
+	if err != nil {
+		return err
+	}
+
+	p.record(PurchaseCreated{
+		PurchaseID: p.ID,
+		OrderID:    p.OrderID,
+		ProductID:  p.ProductID,
+		UserID:     p.UserID,
+		Unit:       p.Unit,
+		Total:      total,
+		At:         now,
+	})
+
+	return nil
+}
+
+func (p *Purchase) IsMine(userID uuid.UUID) bool {
+	return p.UserID == userID
+}
+
+func (p *Purchase) MarkPaid(now time.Time) error {
+	return p.transition(PurchaseStatusPaid, now)
+}
+
+func (p *Purchase) Fulfill(now time.Time) error {
+	return p.transition(PurchaseStatusFulfilled, now)
+}
+
+func (p *Purchase) Cancel(now time.Time) error {
+	return p.transition(PurchaseStatusCancelled, now)
+}
+
+func (p *Purchase) Refund(now time.Time) error {
+	return p.transition(PurchaseStatusRefunded, now)
+}
+
+func (p *Purchase) PartiallyRefund(now time.Time) error {
+	return p.transition(PurchaseStatusPartiallyRefunded, now)
+}
+
+// PreviousStatus returns the status before the transitions recorded in the
+// events, which is the status that the stored purchase is expected to have.
+func (p *Purchase) PreviousStatus() PurchaseStatus {
+	for _, evt := range p.Events() {
+		if c, ok := evt.(PurchaseStatusChanged); ok {
+			return c.From
+		}
+	}
+
+	return p.Status
+}
+
+func (p *Purchase) transition(next PurchaseStatus, now time.Time) error {
+	if !p.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: from %s to %s", ErrPurchaseInvalidTransition, p.Status, next)
+	}
+
+	p.record(PurchaseStatusChanged{
+		PurchaseID: p.ID,
+		From:       p.Status,
+		To:         next,
+		At:         now,
+	})
+
+	p.Status = next
+	p.UpdatedAt = now
+
+	return nil
 }
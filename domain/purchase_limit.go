@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrPurchaseLimitInvalid  = errors.New("invalid purchase limit")
+	ErrPurchaseLimitExceeded = errors.New("purchase limit exceeded")
+)
+
+// PurchaseLimit caps the units of a product that a user can buy, e.g. 2 per
+// customer, or 10 per customer every 24 hours.
+type PurchaseLimit struct {
+	Max    int
+	Period time.Duration // The rolling window, 0 to count all the purchases.
+}
+
+// Since returns the start of the window that ends at the time.
+func (l PurchaseLimit) Since(at time.Time) time.Time {
+	if l.Period == 0 {
+		return time.Time{}
+	}
+
+	return at.Add(-l.Period)
+}
+
+// PurchasedUnits are the units of a prior purchase that count towards the
+// purchase limits.
+type PurchasedUnits struct {
+	Unit int
+	At   time.Time
+}
+
+// PurchaseLimits must all be satisfied.
+type PurchaseLimits []PurchaseLimit
+
+func (ls PurchaseLimits) Validate() error {
+	for i, l := range ls {
+		if l.Max <= 0 || l.Period < 0 {
+			return fmt.Errorf("%w: limit %d", ErrPurchaseLimitInvalid, i)
+		}
+	}
+
+	return nil
+}
+
+// Since returns the start of the longest window, which is where the prior
+// purchases are needed from, and false when there are no limits.
+func (ls PurchaseLimits) Since(at time.Time) (time.Time, bool) {
+	if len(ls) == 0 {
+		return time.Time{}, false
+	}
+
+	since := at
+	for _, l := range ls {
+		if s := l.Since(at); s.Before(since) {
+			since = s
+		}
+	}
+
+	return since, true
+}
+
+// Remaining returns the units that can still be bought at the time under all
+// the limits, and false when there are no limits. Purchases within (since,
+// at] of a limit count towards it.
+func (ls PurchaseLimits) Remaining(purchased []PurchasedUnits, at time.Time) (int, bool) {
+	if len(ls) == 0 {
+		return 0, false
+	}
+
+	remaining := -1
+	for _, l := range ls {
+		since := l.Since(at)
+
+		n := l.Max
+		for _, p := range purchased {
+			if p.At.After(since) && !p.At.After(at) {
+				n -= p.Unit
+			}
+		}
+
+		if n < 0 {
+			n = 0
+		}
+
+		if remaining == -1 || n < remaining {
+			remaining = n
+		}
+	}
+
+	return remaining, true
+}
+
+// Check returns a PurchaseLimitExceededError when buying the unit exceeds any
+// of the limits.
+func (ls PurchaseLimits) Check(unit int, purchased []PurchasedUnits, at time.Time) error {
+	remaining, ok := ls.Remaining(purchased, at)
+	if ok && unit > remaining {
+		return &PurchaseLimitExceededError{Remaining: remaining}
+	}
+
+	return nil
+}
+
+// PurchaseLimitExceededError carries the units that can still be bought. It
+// matches ErrPurchaseLimitExceeded.
+type PurchaseLimitExceededError struct {
+	Remaining int
+}
+
+func (e *PurchaseLimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %d remaining", ErrPurchaseLimitExceeded, e.Remaining)
+}
+
+func (e *PurchaseLimitExceededError) Unwrap() error {
+	return ErrPurchaseLimitExceeded
+}
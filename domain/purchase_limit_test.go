@@ -0,0 +1,72 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseLimits(t *testing.T) {
+	now := time.Now()
+	limits := factories.NewProduct("limited").PurchaseLimits
+
+	tests := []struct {
+		name      string
+		purchased []domain.PurchasedUnits
+		unit      int
+		remaining int
+		wantErr   error
+	}{
+		{"no purchases", nil, 1, 1, nil},
+		{"within the window", []domain.PurchasedUnits{{Unit: 1, At: now.Add(-time.Hour)}}, 1, 0, domain.ErrPurchaseLimitExceeded},
+		{"before the window", []domain.PurchasedUnits{{Unit: 1, At: now.Add(-48 * time.Hour)}}, 1, 1, nil},
+		{"lifetime", []domain.PurchasedUnits{{Unit: 2, At: now.Add(-48 * time.Hour)}}, 1, 0, domain.ErrPurchaseLimitExceeded},
+		{"more than allowed", nil, 2, 1, domain.ErrPurchaseLimitExceeded},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			remaining, ok := limits.Remaining(tc.purchased, now)
+
+			as := assert.New(t)
+			as.True(ok)
+			as.Equal(tc.remaining, remaining)
+
+			err := limits.Check(tc.unit, tc.purchased, now)
+			as.ErrorIs(err, tc.wantErr)
+
+			var limitErr *domain.PurchaseLimitExceededError
+			if errors.As(err, &limitErr) {
+				as.Equal(tc.remaining, limitErr.Remaining)
+			}
+		})
+	}
+
+	t.Run("since", func(t *testing.T) {
+		since, ok := limits.Since(now)
+		assert.True(t, ok)
+		assert.True(t, since.IsZero())
+
+		since, ok = domain.PurchaseLimits{{Max: 1, Period: time.Hour}}.Since(now)
+		assert.True(t, ok)
+		assert.Equal(t, now.Add(-time.Hour), since)
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		var none domain.PurchaseLimits
+		_, ok := none.Remaining(nil, now)
+		assert.False(t, ok)
+		assert.Nil(t, none.Check(100, nil, now))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Nil(t, limits.Validate())
+		assert.ErrorIs(t, domain.PurchaseLimits{{Max: 0}}.Validate(), domain.ErrPurchaseLimitInvalid)
+		assert.ErrorIs(t, domain.PurchaseLimits{{Max: 1, Period: -time.Hour}}.Validate(), domain.ErrPurchaseLimitInvalid)
+	})
+}
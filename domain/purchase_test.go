@@ -0,0 +1,116 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseTransition(t *testing.T) {
+	now := time.Now().Add(time.Hour)
+
+	transitions := map[string]func(*domain.Purchase, time.Time) error{
+		"mark paid": (*domain.Purchase).MarkPaid,
+		"fulfill":   (*domain.Purchase).Fulfill,
+		"cancel":    (*domain.Purchase).Cancel,
+		"refund":    (*domain.Purchase).Refund,
+
+		"partially refund": (*domain.Purchase).PartiallyRefund,
+	}
+
+	tests := []struct {
+		variant    string
+		transition string
+		want       domain.PurchaseStatus
+	}{
+		{"", "mark paid", domain.PurchaseStatusPaid},
+		{"", "cancel", domain.PurchaseStatusCancelled},
+		{"", "fulfill", ""},
+		{"", "refund", ""},
+		{"paid", "fulfill", domain.PurchaseStatusFulfilled},
+		{"paid", "refund", domain.PurchaseStatusRefunded},
+		{"paid", "mark paid", ""},
+		{"paid", "cancel", ""},
+		{"fulfilled", "refund", domain.PurchaseStatusRefunded},
+		{"fulfilled", "partially refund", domain.PurchaseStatusPartiallyRefunded},
+		{"partially_refunded", "partially refund", domain.PurchaseStatusPartiallyRefunded},
+		{"partially_refunded", "refund", domain.PurchaseStatusRefunded},
+		{"partially_refunded", "cancel", ""},
+		{"fulfilled", "cancel", ""},
+		{"cancelled", "mark paid", ""},
+		{"refunded", "refund", ""},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		variant := tc.variant
+		if variant == "" {
+			variant = "pending"
+		}
+
+		t.Run(variant+" "+tc.transition, func(t *testing.T) {
+			var p *domain.Purchase
+			if tc.variant == "" {
+				p = factories.NewPurchase()
+			} else {
+				p = factories.NewPurchase(tc.variant)
+			}
+			status, updatedAt := p.Status, p.UpdatedAt
+
+			as := assert.New(t)
+			err := transitions[tc.transition](p, now)
+			if tc.want == "" {
+				as.ErrorIs(err, domain.ErrPurchaseInvalidTransition)
+				as.Equal(status, p.Status)
+				as.Equal(updatedAt, p.UpdatedAt)
+				as.Empty(p.Events())
+				return
+			}
+
+			as.Nil(err)
+			as.Equal(tc.want, p.Status)
+			as.Equal(now, p.UpdatedAt)
+			as.Equal([]domain.Event{domain.PurchaseStatusChanged{
+				PurchaseID: p.ID,
+				From:       status,
+				To:         tc.want,
+				At:         now,
+			}}, p.Events())
+		})
+	}
+}
+
+func TestPurchaseMarkCreated(t *testing.T) {
+	p := factories.NewPurchase()
+
+	as := assert.New(t)
+	as.Nil(p.MarkCreated(time.Now()))
+
+	events := p.PullEvents()
+	if as.Len(events, 1) {
+		evt, ok := events[0].(domain.PurchaseCreated)
+		as.True(ok)
+		as.Equal(p.ID, evt.PurchaseID)
+		as.Equal(domain.NewMoney(10, "MYR"), evt.Total)
+	}
+	as.Empty(p.Events())
+}
+
+func TestPurchasePreviousStatus(t *testing.T) {
+	p := factories.NewPurchase("paid")
+
+	as := assert.New(t)
+	as.Equal(domain.PurchaseStatusPaid, p.PreviousStatus())
+
+	now := time.Now()
+	as.Nil(p.PartiallyRefund(now))
+	as.Nil(p.Refund(now))
+	as.Equal(domain.PurchaseStatusPaid, p.PreviousStatus())
+
+	p.PullEvents()
+	as.Equal(domain.PurchaseStatusRefunded, p.PreviousStatus())
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefundAmountInvalid = errors.New("refund amount must be positive")
+	ErrRefundExceedsPaid   = errors.New("refund exceeds the amount paid")
+)
+
+// Refund is an entry in the refund ledger of a purchase.
+type Refund struct {
+	ID         uuid.UUID
+	PurchaseID uuid.UUID
+	Amount     Money
+	Reason     string
+	CreatedAt  time.Time
+}
+
+// RefundableAmount returns the amount paid that is not refunded yet.
+func (p *Purchase) RefundableAmount(refunds []Refund) (Money, error) {
+	total, err := p.Total()
+	if err != nil {
+		return total, err
+	}
+
+	for _, r := range refunds {
+		if r.PurchaseID != p.ID {
+			continue
+		}
+
+		total, err = total.Sub(r.Amount)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// IssueRefund refunds the amount, or the remaining refundable amount when
+// the amount is nil, and updates the purchase status.
+func (p *Purchase) IssueRefund(amount *Money, reason string, refunds []Refund, now time.Time) (*Refund, error) {
+	refundable, err := p.RefundableAmount(refunds)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount == nil {
+		amount = &refundable
+	}
+
+	if err := refundable.checkCurrency(*amount); err != nil {
+		return nil, err
+	}
+
+	if amount.Amount <= 0 {
+		return nil, ErrRefundAmountInvalid
+	}
+
+	if amount.Amount > refundable.Amount {
+		return nil, ErrRefundExceedsPaid
+	}
+
+	if amount.Amount == refundable.Amount {
+		err = p.Refund(now)
+	} else {
+		err = p.PartiallyRefund(now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Refund{
+		ID:         uuid.New(),
+		PurchaseID: p.ID,
+		Amount:     *amount,
+		Reason:     reason,
+		CreatedAt:  p.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurchaseRefundableAmount(t *testing.T) {
+	p := factories.NewPurchase("paid")
+	refunds := []domain.Refund{
+		{PurchaseID: p.ID, Amount: domain.NewMoney(3, "MYR")},
+		{PurchaseID: uuid.New(), Amount: domain.NewMoney(3, "MYR")},
+	}
+
+	as := assert.New(t)
+	total, err := p.Total()
+	as.Nil(err)
+	as.Equal(domain.NewMoney(10, "MYR"), total)
+
+	refundable, err := p.RefundableAmount(refunds)
+	as.Nil(err)
+	as.Equal(domain.NewMoney(7, "MYR"), refundable)
+}
+
+func TestPurchaseIssueRefund(t *testing.T) {
+	myr := func(amount int64) *domain.Money {
+		return types.Ptr(domain.NewMoney(amount, "MYR"))
+	}
+
+	t.Run("full refund", func(t *testing.T) {
+		p := factories.NewPurchase("paid")
+
+		as := assert.New(t)
+		r, err := p.IssueRefund(nil, "damaged", nil, time.Now())
+		as.Nil(err)
+		as.Equal(p.ID, r.PurchaseID)
+		as.Equal(*myr(10), r.Amount)
+		as.Equal("damaged", r.Reason)
+		as.Equal(domain.PurchaseStatusRefunded, p.Status)
+	})
+
+	t.Run("partial refunds", func(t *testing.T) {
+		p := factories.NewPurchase("fulfilled")
+
+		as := assert.New(t)
+		r1, err := p.IssueRefund(myr(4), "", nil, time.Now())
+		as.Nil(err)
+		as.Equal(domain.PurchaseStatusPartiallyRefunded, p.Status)
+
+		r2, err := p.IssueRefund(myr(4), "", []domain.Refund{*r1}, time.Now())
+		as.Nil(err)
+		as.Equal(domain.PurchaseStatusPartiallyRefunded, p.Status)
+
+		r3, err := p.IssueRefund(nil, "", []domain.Refund{*r1, *r2}, time.Now())
+		as.Nil(err)
+		as.Equal(*myr(2), r3.Amount)
+		as.Equal(domain.PurchaseStatusRefunded, p.Status)
+	})
+
+	t.Run("exceeds paid", func(t *testing.T) {
+		p := factories.NewPurchase("paid")
+		refunds := []domain.Refund{{PurchaseID: p.ID, Amount: *myr(8)}}
+
+		as := assert.New(t)
+		_, err := p.IssueRefund(myr(3), "", refunds, time.Now())
+		as.ErrorIs(err, domain.ErrRefundExceedsPaid)
+		as.Equal(domain.PurchaseStatusPaid, p.Status)
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		p := factories.NewPurchase("paid")
+
+		as := assert.New(t)
+		_, err := p.IssueRefund(myr(0), "", nil, time.Now())
+		as.ErrorIs(err, domain.ErrRefundAmountInvalid)
+
+		_, err = p.IssueRefund(myr(-1), "", nil, time.Now())
+		as.ErrorIs(err, domain.ErrRefundAmountInvalid)
+
+		_, err = p.IssueRefund(types.Ptr(domain.NewMoney(1, "USD")), "", nil, time.Now())
+		as.ErrorIs(err, domain.ErrCurrencyMismatch)
+	})
+
+	t.Run("not paid", func(t *testing.T) {
+		p := factories.NewPurchase()
+		_, err := p.IssueRefund(nil, "", nil, time.Now())
+		assert.ErrorIs(t, err, domain.ErrPurchaseInvalidTransition)
+	})
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTaxCategoryUnknown = errors.New("unknown tax category")
+
+// TaxCategory groups products that are taxed at the same rate, e.g. food,
+// services.
+type TaxCategory string
+
+type TaxMode string
+
+const (
+	// TaxExclusive adds the tax on top of the price.
+	TaxExclusive TaxMode = "exclusive"
+	// TaxInclusive treats the price as already including the tax.
+	TaxInclusive TaxMode = "inclusive"
+)
+
+type TaxBase string
+
+const (
+	// TaxAfterDiscount computes the tax on the discounted price.
+	TaxAfterDiscount TaxBase = "after_discount"
+	// TaxBeforeDiscount computes the tax on the price before discounts.
+	TaxBeforeDiscount TaxBase = "before_discount"
+)
+
+type Tax struct {
+	Amount    Money
+	Rate      int // In basis points, e.g. 600 for 6%.
+	Inclusive bool
+}
+
+// TaxCalculator computes the tax for all units of the purchase.
+type TaxCalculator interface {
+	CalculateTax(category TaxCategory, purchase Purchase) (Tax, error)
+}
+
+// NoTaxCalculator does not charge any tax.
+type NoTaxCalculator struct{}
+
+func (NoTaxCalculator) CalculateTax(category TaxCategory, purchase Purchase) (Tax, error) {
+	return Tax{
+		Amount: NewMoney(0, purchase.BasePrice.Currency),
+	}, nil
+}
+
+// RateTaxCalculator charges a flat rate for each tax category.
+type RateTaxCalculator struct {
+	Rates    map[TaxCategory]int // In basis points.
+	Mode     TaxMode             // Defaults to TaxExclusive.
+	Base     TaxBase             // Defaults to TaxAfterDiscount.
+	Rounding Rounding            // Defaults to RoundHalfUp.
+}
+
+func (c *RateTaxCalculator) CalculateTax(category TaxCategory, purchase Purchase) (Tax, error) {
+	rate, ok := c.Rates[category]
+	if !ok {
+		return Tax{}, fmt.Errorf("%w: %q", ErrTaxCategoryUnknown, category)
+	}
+
+	price := purchase.BasePrice
+	if c.Base != TaxBeforeDiscount {
+		var err error
+		price, err = price.Add(purchase.Discount)
+		if err != nil {
+			return Tax{}, err
+		}
+	}
+
+	base, err := price.Mul(purchase.Unit)
+	if err != nil {
+		return Tax{}, err
+	}
+
+	amount, err := base.Mul(rate)
+	if err != nil {
+		return Tax{}, err
+	}
+
+	// The tax portion of a tax inclusive price p is p * rate / (1 + rate).
+	inclusive := c.Mode == TaxInclusive
+	divisor := int64(10_000)
+	if inclusive {
+		divisor += int64(rate)
+	}
+
+	return Tax{
+		Amount:    NewMoney(c.Rounding.round(amount.Amount, divisor), base.Currency),
+		Rate:      rate,
+		Inclusive: inclusive,
+	}, nil
+}
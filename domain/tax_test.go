@@ -0,0 +1,86 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateTaxCalculator(t *testing.T) {
+	rates := map[domain.TaxCategory]int{
+		"":        600,
+		"food":    0,
+		"luxury":  1000,
+		"service": 800,
+	}
+
+	// 2 units at 10$ with 5$ off.
+	purchase := factories.NewPurchase()
+	purchase.BasePrice.Amount = 1000
+	purchase.Discount.Amount = -500
+
+	tests := []struct {
+		name      string
+		category  domain.TaxCategory
+		mode      domain.TaxMode
+		base      domain.TaxBase
+		rounding  domain.Rounding
+		wantTax   int64
+		wantTotal int64
+	}{
+		{"exclusive after discount", "", domain.TaxExclusive, domain.TaxAfterDiscount, "", 60, 1060},
+		{"exclusive before discount", "", domain.TaxExclusive, domain.TaxBeforeDiscount, "", 120, 1120},
+		{"inclusive after discount", "", domain.TaxInclusive, domain.TaxAfterDiscount, "", 57, 1000},
+		{"inclusive before discount", "", domain.TaxInclusive, domain.TaxBeforeDiscount, "", 113, 1000},
+		{"inclusive round down", "", domain.TaxInclusive, domain.TaxAfterDiscount, domain.RoundDown, 56, 1000},
+		{"defaults to exclusive after discount", "", "", "", "", 60, 1060},
+		{"zero rated category", "food", domain.TaxExclusive, domain.TaxAfterDiscount, "", 0, 1000},
+		{"luxury category", "luxury", domain.TaxExclusive, domain.TaxAfterDiscount, "", 100, 1100},
+		{"service category", "service", domain.TaxInclusive, domain.TaxAfterDiscount, "", 74, 1000},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			calc := &domain.RateTaxCalculator{
+				Rates:    rates,
+				Mode:     tc.mode,
+				Base:     tc.base,
+				Rounding: tc.rounding,
+			}
+
+			as := assert.New(t)
+			tax, err := calc.CalculateTax(tc.category, *purchase)
+			as.Nil(err)
+			as.Equal(domain.NewMoney(tc.wantTax, "MYR"), tax.Amount)
+			as.Equal(rates[tc.category], tax.Rate)
+			as.Equal(tc.mode == domain.TaxInclusive, tax.Inclusive)
+
+			p := *purchase
+			p.Tax = tax.Amount
+			p.TaxRate = tax.Rate
+			p.TaxInclusive = tax.Inclusive
+
+			total, err := p.Total()
+			as.Nil(err)
+			as.Equal(domain.NewMoney(tc.wantTotal, "MYR"), total)
+		})
+	}
+
+	t.Run("unknown category", func(t *testing.T) {
+		calc := &domain.RateTaxCalculator{Rates: rates}
+		_, err := calc.CalculateTax("unknown", *purchase)
+		assert.ErrorIs(t, err, domain.ErrTaxCategoryUnknown)
+	})
+}
+
+func TestNoTaxCalculator(t *testing.T) {
+	tax, err := domain.NoTaxCalculator{}.CalculateTax("", *factories.NewPurchase())
+
+	as := assert.New(t)
+	as.Nil(err)
+	as.Equal(domain.NewMoney(0, "MYR"), tax.Amount)
+	as.False(tax.Inclusive)
+}
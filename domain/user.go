@@ -1,8 +1,47 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusClosed    UserStatus = "closed"
+)
 
 type User struct {
-	ID   uuid.UUID
-	Name string
+	ID          uuid.UUID
+	Name        string
+	Status      UserStatus
+	BannedUntil *time.Time // Set while the user is banned.
+	BirthDate   *time.Time // Optional, required for the age restricted products.
+}
+
+func (u *User) IsActive() bool {
+	return u.Status == UserStatusActive
+}
+
+func (u *User) IsBannedAt(t time.Time) bool {
+	return u.BannedUntil != nil && t.Before(*u.BannedUntil)
+}
+
+// AgeAt returns the age in full years, and false if the birth date is
+// unknown.
+func (u *User) AgeAt(t time.Time) (int, bool) {
+	if u.BirthDate == nil {
+		return 0, false
+	}
+
+	b := u.BirthDate.In(t.Location())
+	age := t.Year() - b.Year()
+	if t.Month() < b.Month() || (t.Month() == b.Month() && t.Day() < b.Day()) {
+		age--
+	}
+
+	return age, true
 }
@@ -0,0 +1,40 @@
+// Package event contains the event publishers for the usecases.
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+)
+
+// InMemoryPublisher keeps the published events in memory, for tests and
+// local development.
+type InMemoryPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func NewInMemoryPublisher() *InMemoryPublisher {
+	return &InMemoryPublisher{}
+}
+
+func (p *InMemoryPublisher) Publish(ctx context.Context, events ...domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+
+	return nil
+}
+
+// Events returns a copy of the published events.
+func (p *InMemoryPublisher) Events() []domain.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	events := make([]domain.Event, len(p.events))
+	copy(events, p.events)
+
+	return events
+}
@@ -0,0 +1,419 @@
+// Package grpcapi exposes the usecases as gRPC services. The protobuf
+// definitions are in shopv1, and are generated with `make proto`.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/alextanhongpin/errors/causes"
+	"github.com/alextanhongpin/errors/codes"
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/grpcapi/shopv1"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MetadataUserID carries the authenticated user, and is set by the gateway.
+const MetadataUserID = "x-user-id"
+
+// ErrorDomain is the domain of the errdetails.ErrorInfo.
+const ErrorDomain = "go-domain-test"
+
+var (
+	ErrInvalidID       = causes.New(codes.BadRequest, "invalid_id", "The id must be a valid UUID.")
+	ErrUnauthenticated = causes.New(codes.Unauthorized, "unauthenticated", "The user is not authenticated.")
+	ErrInternal        = causes.New(codes.Internal, "internal", "Something went wrong.")
+)
+
+type productUsecase interface {
+	View(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	Create(ctx context.Context, dto usecase.CreateProductDto) (*domain.Product, error)
+	Update(ctx context.Context, dto usecase.UpdateProductDto) (*domain.Product, error)
+	Publish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	SchedulePublish(ctx context.Context, dto usecase.SchedulePublishDto) (*domain.Product, error)
+	Unpublish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	Restore(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	List(ctx context.Context, dto usecase.ListProductsDto) (*usecase.ProductPage, error)
+}
+
+type purchaseUsecase interface {
+	Purchase(ctx context.Context, dto usecase.PurchaseDto) (*domain.Purchase, error)
+}
+
+// Register registers the services on the server.
+func Register(s *grpc.Server, product productUsecase, purchase purchaseUsecase) {
+	shopv1.RegisterProductServiceServer(s, &ProductServer{usecase: product})
+	shopv1.RegisterPurchaseServiceServer(s, &PurchaseServer{usecase: purchase})
+}
+
+type ProductServer struct {
+	shopv1.UnimplementedProductServiceServer
+
+	usecase productUsecase
+}
+
+func (s *ProductServer) ViewProduct(ctx context.Context, req *shopv1.ViewProductRequest) (*shopv1.Product, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.View(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+func (s *ProductServer) ListProducts(ctx context.Context, req *shopv1.ListProductsRequest) (*shopv1.ListProductsResponse, error) {
+	// The user is optional, and only needed to list their unpublished
+	// products.
+	var viewer uuid.UUID
+	if md, _ := metadata.FromIncomingContext(ctx); len(md.Get(MetadataUserID)) > 0 {
+		var err error
+		if viewer, err = userID(ctx); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+
+	dto := usecase.ListProductsDto{
+		UserID: viewer,
+		Sort:   usecase.ProductSort(req.GetSort()),
+		Cursor: req.GetCursor(),
+		Limit:  int(req.GetLimit()),
+		Filter: usecase.ProductFilter{
+			Published:  req.Published,
+			MinPrice:   toMoney(req.GetMinPrice()),
+			MaxPrice:   toMoney(req.GetMaxPrice()),
+			NamePrefix: req.GetNamePrefix(),
+		},
+	}
+
+	if req.GetOwnerId() != "" {
+		ownerID, err := parseID(req.GetOwnerId())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		dto.Filter.OwnerID = &ownerID
+	}
+
+	page, err := s.usecase.List(ctx, dto)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &shopv1.ListProductsResponse{
+		Products:   make([]*shopv1.Product, len(page.Products)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Products {
+		res.Products[i] = newProduct(&page.Products[i])
+	}
+
+	return res, nil
+}
+
+func (s *ProductServer) CreateProduct(ctx context.Context, req *shopv1.CreateProductRequest) (*shopv1.Product, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.Create(ctx, usecase.CreateProductDto{
+		Name:   req.GetName(),
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+func (s *ProductServer) UpdateProduct(ctx context.Context, req *shopv1.UpdateProductRequest) (*shopv1.Product, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.Update(ctx, usecase.UpdateProductDto{
+		ID:      id,
+		UserID:  userID,
+		Name:    req.GetName(),
+		Price:   domain.NewMoney(req.GetPrice().GetAmount(), domain.Currency(req.GetPrice().GetCurrency())),
+		Version: int(req.GetVersion()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+func (s *ProductServer) PublishProduct(ctx context.Context, req *shopv1.PublishProductRequest) (*shopv1.Product, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var p *domain.Product
+	if req.PublishAt == nil && req.UnpublishAt == nil {
+		p, err = s.usecase.Publish(ctx, id, userID)
+	} else {
+		dto := usecase.SchedulePublishDto{
+			ID:     id,
+			UserID: userID,
+		}
+		if req.PublishAt != nil {
+			dto.PublishAt = req.GetPublishAt().AsTime()
+		}
+		if req.UnpublishAt != nil {
+			unpublishAt := req.GetUnpublishAt().AsTime()
+			dto.UnpublishAt = &unpublishAt
+		}
+
+		p, err = s.usecase.SchedulePublish(ctx, dto)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+func (s *ProductServer) UnpublishProduct(ctx context.Context, req *shopv1.UnpublishProductRequest) (*shopv1.Product, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.Unpublish(ctx, id, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+func (s *ProductServer) DeleteProduct(ctx context.Context, req *shopv1.DeleteProductRequest) (*emptypb.Empty, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	if err := s.usecase.Delete(ctx, id, userID); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *ProductServer) RestoreProduct(ctx context.Context, req *shopv1.RestoreProductRequest) (*shopv1.Product, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.Restore(ctx, id, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return newProduct(p), nil
+}
+
+type PurchaseServer struct {
+	shopv1.UnimplementedPurchaseServiceServer
+
+	usecase purchaseUsecase
+}
+
+func (s *PurchaseServer) CreatePurchase(ctx context.Context, req *shopv1.CreatePurchaseRequest) (*shopv1.Purchase, error) {
+	userID, err := userID(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	productID, err := parseID(req.GetProductId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	p, err := s.usecase.Purchase(ctx, usecase.PurchaseDto{
+		ProductID:      productID,
+		UserID:         userID,
+		Unit:           int(req.GetUnit()),
+		CouponCodes:    req.GetCouponCodes(),
+		IdempotencyKey: req.GetIdempotencyKey(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res, err := newPurchase(p)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return res, nil
+}
+
+func parseID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, ErrInvalidID
+	}
+
+	return id, nil
+}
+
+func userID(ctx context.Context) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(MetadataUserID)
+	if len(values) != 1 {
+		return uuid.Nil, ErrUnauthenticated
+	}
+
+	id, err := uuid.Parse(values[0])
+	if err != nil {
+		return uuid.Nil, ErrUnauthenticated
+	}
+
+	return id, nil
+}
+
+// toStatus converts the cause to a status with the kind in the
+// errdetails.ErrorInfo, and the message in the errdetails.LocalizedMessage.
+// Errors without a cause are not exposed, and are reported as ErrInternal
+// instead.
+func toStatus(err error) error {
+	var d causes.Detail
+	if !errors.As(err, &d) {
+		return toStatus(ErrInternal)
+	}
+
+	det := d.Detail()
+	st := status.New(codes.GRPC(det.Code()), det.Message())
+
+	md := map[string]string{
+		"code": det.Code().String(),
+	}
+	if remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(det); ok {
+		md["remaining"] = strconv.Itoa(remaining)
+	}
+
+	withDetails, detailsErr := st.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason:   det.Kind(),
+			Domain:   ErrorDomain,
+			Metadata: md,
+		},
+		&errdetails.LocalizedMessage{
+			Locale:  "en-US",
+			Message: det.Message(),
+		},
+	)
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+func newMoney(m domain.Money) *shopv1.Money {
+	return &shopv1.Money{
+		Amount:   m.Amount,
+		Currency: string(m.Currency),
+	}
+}
+
+// toMoney returns nil if the money is not set.
+func toMoney(m *shopv1.Money) *domain.Money {
+	if m == nil {
+		return nil
+	}
+
+	res := domain.NewMoney(m.GetAmount(), domain.Currency(m.GetCurrency()))
+	return &res
+}
+
+func newProduct(p *domain.Product) *shopv1.Product {
+	res := &shopv1.Product{
+		Id:      p.ID.String(),
+		Name:    string(p.Name),
+		UserId:  p.UserID.String(),
+		Price:   newMoney(p.Price),
+		Version: int32(p.Version),
+	}
+
+	if p.PublishedAt != nil {
+		res.PublishedAt = timestamppb.New(*p.PublishedAt)
+	}
+
+	if p.UnpublishAt != nil {
+		res.UnpublishAt = timestamppb.New(*p.UnpublishAt)
+	}
+
+	if p.DeletedAt != nil {
+		res.DeletedAt = timestamppb.New(*p.DeletedAt)
+	}
+
+	return res
+}
+
+func newPurchase(p *domain.Purchase) (*shopv1.Purchase, error) {
+	total, err := p.Total()
+	if err != nil {
+		return nil, err
+	}
+
+	return &shopv1.Purchase{
+		Id:          p.ID.String(),
+		ProductId:   p.ProductID.String(),
+		UserId:      p.UserID.String(),
+		Unit:        int32(p.Unit),
+		BasePrice:   newMoney(p.BasePrice),
+		Discount:    newMoney(p.Discount),
+		Tax:         newMoney(p.Tax),
+		Total:       newMoney(total),
+		Status:      string(p.Status),
+		CouponCodes: p.CouponCodes,
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+	}, nil
+}
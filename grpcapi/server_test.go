@@ -0,0 +1,388 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/grpcapi"
+	"github.com/alextanhongpin/go-domain-test/grpcapi/shopv1"
+	"github.com/alextanhongpin/go-domain-test/repository/inmemory"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProductService(t *testing.T) {
+	published := factories.NewProduct("published")
+	unpublished := factories.NewProduct("no_published_at")
+	owner := published.UserID
+
+	conn := dial(t, inmemory.WithProducts(published, unpublished))
+	client := shopv1.NewProductServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("view", func(t *testing.T) {
+		p, err := client.ViewProduct(ctx, &shopv1.ViewProductRequest{Id: published.ID.String()})
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(published.ID.String(), p.GetId())
+		as.Equal("colorful socks", p.GetName())
+		as.True(published.PublishedAt.Equal(p.GetPublishedAt().AsTime()))
+		as.Equal(int64(10), p.GetPrice().GetAmount())
+		as.Equal("MYR", p.GetPrice().GetCurrency())
+	})
+
+	t.Run("view unpublished", func(t *testing.T) {
+		_, err := client.ViewProduct(ctx, &shopv1.ViewProductRequest{Id: unpublished.ID.String()})
+		assertStatus(t, err, codes.NotFound, "product_not_found", "Product does not exist or may have been deleted.")
+	})
+
+	t.Run("view invalid id", func(t *testing.T) {
+		_, err := client.ViewProduct(ctx, &shopv1.ViewProductRequest{Id: "1"})
+		assertStatus(t, err, codes.InvalidArgument, "invalid_id", "The id must be a valid UUID.")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		res, err := client.ListProducts(ctx, &shopv1.ListProductsRequest{})
+
+		as := assert.New(t)
+		as.Nil(err)
+		if as.Len(res.GetProducts(), 1) {
+			as.Equal(published.ID.String(), res.GetProducts()[0].GetId())
+		}
+		as.Empty(res.GetNextCursor())
+	})
+
+	t.Run("list unpublished as owner", func(t *testing.T) {
+		published := false
+		res, err := client.ListProducts(asUser(ctx, owner), &shopv1.ListProductsRequest{
+			OwnerId:   owner.String(),
+			Published: &published,
+		})
+
+		as := assert.New(t)
+		as.Nil(err)
+		if as.Len(res.GetProducts(), 1) {
+			as.Equal(unpublished.ID.String(), res.GetProducts()[0].GetId())
+		}
+	})
+
+	t.Run("list invalid sort", func(t *testing.T) {
+		_, err := client.ListProducts(ctx, &shopv1.ListProductsRequest{Sort: "newest"})
+		assertStatus(t, err, codes.InvalidArgument, "product_query_invalid", usecase.ErrProductQueryInvalid.Error())
+	})
+
+	t.Run("create", func(t *testing.T) {
+		p, err := client.CreateProduct(asUser(ctx, owner), &shopv1.CreateProductRequest{Name: "colorful socks"})
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(owner.String(), p.GetUserId())
+		as.Nil(p.GetPublishedAt())
+	})
+
+	t.Run("create bad name", func(t *testing.T) {
+		_, err := client.CreateProduct(asUser(ctx, owner), &shopv1.CreateProductRequest{Name: "!@#"})
+		assertStatus(t, err, codes.InvalidArgument, "product_name_bad_format", usecase.ErrProductNameBadFormat.Error())
+	})
+
+	t.Run("create unauthenticated", func(t *testing.T) {
+		_, err := client.CreateProduct(ctx, &shopv1.CreateProductRequest{Name: "colorful socks"})
+		assertStatus(t, err, codes.Unauthenticated, "unauthenticated", "The user is not authenticated.")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		req := &shopv1.UpdateProductRequest{
+			Id:      published.ID.String(),
+			Name:    "striped socks",
+			Price:   &shopv1.Money{Amount: 12, Currency: "MYR"},
+			Version: 0,
+		}
+		p, err := client.UpdateProduct(asUser(ctx, owner), req)
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal("striped socks", p.GetName())
+		as.Equal(int64(12), p.GetPrice().GetAmount())
+		as.Equal(int32(1), p.GetVersion())
+
+		// The same edit is now based on a stale version.
+		_, err = client.UpdateProduct(asUser(ctx, owner), req)
+		assertStatus(t, err, codes.Aborted, "product_version_conflict", usecase.ErrProductVersionConflict.Error())
+	})
+
+	t.Run("update negative price", func(t *testing.T) {
+		_, err := client.UpdateProduct(asUser(ctx, owner), &shopv1.UpdateProductRequest{
+			Id:      published.ID.String(),
+			Name:    "striped socks",
+			Price:   &shopv1.Money{Amount: -1, Currency: "MYR"},
+			Version: 1,
+		})
+		assertStatus(t, err, codes.InvalidArgument, "product_price_invalid", usecase.ErrProductPriceInvalid.Error())
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		_, err := client.PublishProduct(asUser(ctx, owner), &shopv1.PublishProductRequest{Id: unpublished.ID.String()})
+		assert.Nil(t, err)
+
+		_, err = client.ViewProduct(ctx, &shopv1.ViewProductRequest{Id: unpublished.ID.String()})
+		assert.Nil(t, err)
+	})
+
+	t.Run("unpublish", func(t *testing.T) {
+		p, err := client.UnpublishProduct(asUser(ctx, owner), &shopv1.UnpublishProductRequest{Id: unpublished.ID.String()})
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Nil(p.GetPublishedAt())
+
+		_, err = client.ViewProduct(ctx, &shopv1.ViewProductRequest{Id: unpublished.ID.String()})
+		as.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("schedule publish", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour)
+		p, err := client.PublishProduct(asUser(ctx, owner), &shopv1.PublishProductRequest{
+			Id:          unpublished.ID.String(),
+			PublishAt:   timestamppb.New(publishAt),
+			UnpublishAt: timestamppb.New(publishAt.Add(time.Hour)),
+		})
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.True(publishAt.Equal(p.GetPublishedAt().AsTime()))
+		as.True(publishAt.Add(time.Hour).Equal(p.GetUnpublishAt().AsTime()))
+	})
+
+	t.Run("schedule publish invalid window", func(t *testing.T) {
+		_, err := client.PublishProduct(asUser(ctx, owner), &shopv1.PublishProductRequest{
+			Id:          unpublished.ID.String(),
+			UnpublishAt: timestamppb.New(time.Now().Add(-time.Hour)),
+		})
+		assertStatus(t, err, codes.InvalidArgument, "product_schedule_invalid", usecase.ErrProductScheduleInvalid.Error())
+	})
+
+	t.Run("delete by other user", func(t *testing.T) {
+		_, err := client.DeleteProduct(asUser(ctx, uuid.New()), &shopv1.DeleteProductRequest{Id: published.ID.String()})
+		assertStatus(t, err, codes.Unauthenticated, "product_unauthorized", usecase.ErrProductUnauthorized.Error())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := client.DeleteProduct(asUser(ctx, owner), &shopv1.DeleteProductRequest{Id: unpublished.ID.String()})
+		assert.Nil(t, err)
+
+		_, err = client.DeleteProduct(asUser(ctx, owner), &shopv1.DeleteProductRequest{Id: unpublished.ID.String()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		p, err := client.RestoreProduct(asUser(ctx, owner), &shopv1.RestoreProductRequest{Id: unpublished.ID.String()})
+		assert.Nil(t, err)
+		assert.Nil(t, p.GetDeletedAt())
+
+		_, err = client.RestoreProduct(asUser(ctx, owner), &shopv1.RestoreProductRequest{Id: unpublished.ID.String()})
+		assertStatus(t, err, codes.Aborted, "product_not_deleted", usecase.ErrProductNotDeleted.Error())
+	})
+}
+
+func TestPurchaseService(t *testing.T) {
+	user := factories.NewUser()
+	p := factories.NewProduct("published")
+	d := factories.NewDiscount()
+	d.ProductID = p.ID
+
+	limited := factories.NewProduct("published", "limited")
+
+	conn := dial(t,
+		inmemory.WithUsers(user),
+		inmemory.WithProducts(p, limited),
+		inmemory.WithDiscounts(d),
+		inmemory.WithStock(p.ID, 3),
+		inmemory.WithStock(limited.ID, 3),
+	)
+	client := shopv1.NewPurchaseServiceClient(conn)
+	ctx := asUser(context.Background(), user.ID)
+	req := &shopv1.CreatePurchaseRequest{
+		ProductId: p.ID.String(),
+		Unit:      2,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		res, err := client.CreatePurchase(ctx, req)
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(p.ID.String(), res.GetProductId())
+		as.Equal(user.ID.String(), res.GetUserId())
+		as.Equal(int32(2), res.GetUnit())
+		as.Equal(int64(-5), res.GetDiscount().GetAmount())
+		as.Equal(int64(10), res.GetTotal().GetAmount())
+		as.Equal(string(domain.PurchaseStatusPending), res.GetStatus())
+	})
+
+	t.Run("out of stock", func(t *testing.T) {
+		_, err := client.CreatePurchase(ctx, req)
+		assertStatus(t, err, codes.Aborted, "product_out_of_stock", usecase.ErrProductOutOfStock.Error())
+	})
+
+	t.Run("purchase limit exceeded", func(t *testing.T) {
+		_, err := client.CreatePurchase(ctx, &shopv1.CreatePurchaseRequest{
+			ProductId: limited.ID.String(),
+			Unit:      2,
+		})
+		info := assertStatus(t, err, codes.FailedPrecondition, "purchase_limit_exceeded", "You have reached the purchase limit for this product.")
+		assert.Equal(t, "1", info.GetMetadata()["remaining"])
+	})
+
+	t.Run("ineligible user", func(t *testing.T) {
+		_, err := client.CreatePurchase(asUser(context.Background(), uuid.New()), req)
+		assertStatus(t, err, codes.PermissionDenied, "user_ineligible", usecase.ErrUserIneligible.Error())
+	})
+}
+
+func TestInternalError(t *testing.T) {
+	conn := dialServer(t, func(s *grpc.Server) {
+		grpcapi.Register(s, new(failingProductUsecase), nil)
+	})
+
+	_, err := shopv1.NewProductServiceClient(conn).ViewProduct(context.Background(), &shopv1.ViewProductRequest{
+		Id: uuid.NewString(),
+	})
+
+	// The error is not leaked.
+	assertStatus(t, err, codes.Internal, "internal", "Something went wrong.")
+}
+
+func dial(t *testing.T, opts ...inmemory.Option) *grpc.ClientConn {
+	store := inmemory.NewStore(opts...)
+	product := usecase.NewProduct(inmemory.NewProductRepository(store), event.NewInMemoryPublisher())
+	purchase := usecase.NewPurchaseUsecase(inmemory.NewPurchaseRepository(store), inmemory.NewIdempotencyRepository(store))
+
+	return dialServer(t, func(s *grpc.Server) {
+		grpcapi.Register(s, product, purchase)
+	})
+}
+
+func dialServer(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	register(srv)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func asUser(ctx context.Context, userID uuid.UUID) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, grpcapi.MetadataUserID, userID.String())
+}
+
+// assertStatus returns the ErrorInfo of the status, for the metadata.
+func assertStatus(t *testing.T, err error, code codes.Code, kind, msg string) *errdetails.ErrorInfo {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+
+	as := assert.New(t)
+	if !as.True(ok) {
+		return nil
+	}
+
+	as.Equal(code, st.Code())
+	as.Equal(msg, st.Message())
+
+	var (
+		info      *errdetails.ErrorInfo
+		localized *errdetails.LocalizedMessage
+	)
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.LocalizedMessage:
+			localized = d
+		}
+	}
+
+	if as.NotNil(info) {
+		as.Equal(kind, info.GetReason())
+		as.Equal(grpcapi.ErrorDomain, info.GetDomain())
+	}
+
+	if as.NotNil(localized) {
+		as.Equal(msg, localized.GetMessage())
+	}
+
+	return info
+}
+
+type failingProductUsecase struct{}
+
+func (failingProductUsecase) View(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Create(ctx context.Context, dto usecase.CreateProductDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Update(ctx context.Context, dto usecase.UpdateProductDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Publish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) SchedulePublish(ctx context.Context, dto usecase.SchedulePublishDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Unpublish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	return errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Restore(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) List(ctx context.Context, dto usecase.ListProductsDto) (*usecase.ProductPage, error) {
+	return nil, errors.New("db: connection refused")
+}
@@ -0,0 +1,366 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+type productUsecase interface {
+	View(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	Create(ctx context.Context, dto usecase.CreateProductDto) (*domain.Product, error)
+	Update(ctx context.Context, dto usecase.UpdateProductDto) (*domain.Product, error)
+	Publish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	SchedulePublish(ctx context.Context, dto usecase.SchedulePublishDto) (*domain.Product, error)
+	Unpublish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+	Restore(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error)
+	List(ctx context.Context, dto usecase.ListProductsDto) (*usecase.ProductPage, error)
+}
+
+type money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func newMoney(m domain.Money) money {
+	return money{
+		Amount:   m.Amount,
+		Currency: string(m.Currency),
+	}
+}
+
+type productResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	UserID      uuid.UUID  `json:"user_id"`
+	PublishedAt *time.Time `json:"published_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	Price       money      `json:"price"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at"`
+}
+
+func newProductResponse(p *domain.Product) productResponse {
+	return productResponse{
+		ID:          p.ID,
+		Name:        string(p.Name),
+		UserID:      p.UserID,
+		PublishedAt: p.PublishedAt,
+		UnpublishAt: p.UnpublishAt,
+		Price:       newMoney(p.Price),
+		Version:     p.Version,
+		DeletedAt:   p.DeletedAt,
+	}
+}
+
+type createProductRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *Server) createProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req createProductRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.product.Create(r.Context(), usecase.CreateProductDto{
+		Name:   req.Name,
+		UserID: userID,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newProductResponse(p))
+}
+
+type listProductsResponse struct {
+	Products   []productResponse `json:"products"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// listProducts lists the products with the query parameters:
+//   - owner, published, name_prefix: the filters.
+//   - min_price, max_price: the price range in the currency.
+//   - sort: name, price or -price.
+//   - cursor, limit: the page.
+//
+// The user is optional, and only needed to list their unpublished products.
+func (s *Server) listProducts(w http.ResponseWriter, r *http.Request) {
+	var viewer uuid.UUID
+	if r.Header.Get(HeaderUserID) != "" {
+		var err error
+		if viewer, err = userID(r); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	dto, err := parseListProducts(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	dto.UserID = viewer
+
+	page, err := s.product.List(r.Context(), dto)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	res := listProductsResponse{
+		Products:   make([]productResponse, len(page.Products)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Products {
+		res.Products[i] = newProductResponse(&page.Products[i])
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func parseListProducts(q url.Values) (usecase.ListProductsDto, error) {
+	dto := usecase.ListProductsDto{
+		Sort:   usecase.ProductSort(q.Get("sort")),
+		Cursor: q.Get("cursor"),
+		Filter: usecase.ProductFilter{
+			NamePrefix: q.Get("name_prefix"),
+		},
+	}
+
+	if v := q.Get("owner"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return dto, ErrInvalidQuery
+		}
+		dto.Filter.OwnerID = &id
+	}
+
+	if v := q.Get("published"); v != "" {
+		published, err := strconv.ParseBool(v)
+		if err != nil {
+			return dto, ErrInvalidQuery
+		}
+		dto.Filter.Published = &published
+	}
+
+	price := func(key string) (*domain.Money, error) {
+		v := q.Get(key)
+		if v == "" {
+			return nil, nil
+		}
+
+		amount, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidQuery
+		}
+
+		m := domain.NewMoney(amount, domain.Currency(q.Get("currency")))
+		return &m, nil
+	}
+
+	var err error
+	if dto.Filter.MinPrice, err = price("min_price"); err != nil {
+		return dto, err
+	}
+
+	if dto.Filter.MaxPrice, err = price("max_price"); err != nil {
+		return dto, err
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if dto.Limit, err = strconv.Atoi(v); err != nil {
+			return dto, ErrInvalidQuery
+		}
+	}
+
+	return dto, nil
+}
+
+func (s *Server) viewProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "/products/", "")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.product.View(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(p))
+}
+
+type updateProductRequest struct {
+	Name    string `json:"name"`
+	Price   money  `json:"price"`
+	Version int    `json:"version"`
+}
+
+func (s *Server) updateProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := pathID(r, "/products/", "")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req updateProductRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.product.Update(r.Context(), usecase.UpdateProductDto{
+		ID:      id,
+		UserID:  userID,
+		Name:    req.Name,
+		Price:   domain.NewMoney(req.Price.Amount, domain.Currency(req.Price.Currency)),
+		Version: req.Version,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(p))
+}
+
+func (s *Server) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := pathID(r, "/products/", "")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := s.product.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishProductRequest is optional. Without the times, the product is
+// published immediately.
+type publishProductRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+func (s *Server) publishProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := pathID(r, "/products/", "/publish")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req publishProductRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	var p *domain.Product
+	if req.PublishAt == nil && req.UnpublishAt == nil {
+		p, err = s.product.Publish(r.Context(), id, userID)
+	} else {
+		dto := usecase.SchedulePublishDto{
+			ID:          id,
+			UserID:      userID,
+			UnpublishAt: req.UnpublishAt,
+		}
+		if req.PublishAt != nil {
+			dto.PublishAt = *req.PublishAt
+		}
+
+		p, err = s.product.SchedulePublish(r.Context(), dto)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(p))
+}
+
+func (s *Server) unpublishProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := pathID(r, "/products/", "/unpublish")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.product.Unpublish(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(p))
+}
+
+func (s *Server) restoreProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	id, err := pathID(r, "/products/", "/restore")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.product.Restore(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProductResponse(p))
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+type purchaseUsecase interface {
+	Purchase(ctx context.Context, dto usecase.PurchaseDto) (*domain.Purchase, error)
+}
+
+type purchaseRequest struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	Unit        int       `json:"unit"`
+	CouponCodes []string  `json:"coupon_codes"`
+}
+
+type purchaseResponse struct {
+	ID          uuid.UUID `json:"id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Unit        int       `json:"unit"`
+	BasePrice   money     `json:"base_price"`
+	Discount    money     `json:"discount"`
+	Tax         money     `json:"tax"`
+	Total       money     `json:"total"`
+	Status      string    `json:"status"`
+	CouponCodes []string  `json:"coupon_codes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newPurchaseResponse(p *domain.Purchase) (purchaseResponse, error) {
+	total, err := p.Total()
+	if err != nil {
+		return purchaseResponse{}, err
+	}
+
+	codes := p.CouponCodes
+	if codes == nil {
+		codes = []string{}
+	}
+
+	return purchaseResponse{
+		ID:          p.ID,
+		ProductID:   p.ProductID,
+		UserID:      p.UserID,
+		Unit:        p.Unit,
+		BasePrice:   newMoney(p.BasePrice),
+		Discount:    newMoney(p.Discount),
+		Tax:         newMoney(p.Tax),
+		Total:       newMoney(total),
+		Status:      string(p.Status),
+		CouponCodes: codes,
+		CreatedAt:   p.CreatedAt,
+	}, nil
+}
+
+func (s *Server) purchaseProduct(w http.ResponseWriter, r *http.Request) {
+	userID, err := userID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req purchaseRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	p, err := s.purchase.Purchase(r.Context(), usecase.PurchaseDto{
+		ProductID:      req.ProductID,
+		UserID:         userID,
+		Unit:           req.Unit,
+		CouponCodes:    req.CouponCodes,
+		IdempotencyKey: r.Header.Get(HeaderIdempotencyKey),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	res, err := newPurchaseResponse(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, res)
+}
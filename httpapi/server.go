@@ -0,0 +1,191 @@
+// Package httpapi exposes the usecases as a JSON REST API.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alextanhongpin/errors/causes"
+	"github.com/alextanhongpin/errors/codes"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+// HeaderUserID carries the authenticated user, and is set by the gateway.
+const HeaderUserID = "X-User-ID"
+
+// HeaderIdempotencyKey makes retries of the purchase safe.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+const maxBodyBytes = 1 << 20
+
+var (
+	ErrBadRequest       = causes.New(codes.BadRequest, "bad_request", "The request body is not valid JSON.")
+	ErrInvalidID        = causes.New(codes.BadRequest, "invalid_id", "The id must be a valid UUID.")
+	ErrInvalidQuery     = causes.New(codes.BadRequest, "invalid_query", "The query parameters are not valid.")
+	ErrUnauthenticated  = causes.New(codes.Unauthorized, "unauthenticated", "The user is not authenticated.")
+	ErrMethodNotAllowed = causes.New(codes.BadRequest, "method_not_allowed", "The method is not allowed for the resource.")
+	ErrInternal         = causes.New(codes.Internal, "internal", "Something went wrong.")
+)
+
+type Server struct {
+	product  productUsecase
+	purchase purchaseUsecase
+}
+
+func New(product productUsecase, purchase purchaseUsecase) *Server {
+	return &Server{
+		product:  product,
+		purchase: purchase,
+	}
+}
+
+// Handler returns the routes:
+//
+//	GET    /products
+//	POST   /products
+//	GET    /products/{id}
+//	PUT    /products/{id}
+//	DELETE /products/{id}
+//	POST   /products/{id}/publish
+//	POST   /products/{id}/unpublish
+//	POST   /products/{id}/restore
+//	POST   /purchases
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.listProducts(w, r)
+		case http.MethodPost:
+			s.createProduct(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	})
+	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/publish"):
+			allow(s.publishProduct, http.MethodPost)(w, r)
+			return
+		case strings.HasSuffix(r.URL.Path, "/unpublish"):
+			allow(s.unpublishProduct, http.MethodPost)(w, r)
+			return
+		case strings.HasSuffix(r.URL.Path, "/restore"):
+			allow(s.restoreProduct, http.MethodPost)(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.viewProduct(w, r)
+		case http.MethodPut:
+			s.updateProduct(w, r)
+		case http.MethodDelete:
+			s.deleteProduct(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+		}
+	})
+	mux.HandleFunc("/purchases", allow(s.purchaseProduct, http.MethodPost))
+
+	return mux
+}
+
+func allow(h http.HandlerFunc, method string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			methodNotAllowed(w, method)
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	res, _ := newErrorResponse(ErrMethodNotAllowed)
+	writeJSON(w, http.StatusMethodNotAllowed, res)
+}
+
+// pathID returns the id between the prefix and the suffix, e.g.
+// /products/{id}/publish.
+func pathID(r *http.Request, prefix, suffix string) (uuid.UUID, error) {
+	id, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefix), suffix))
+	if err != nil {
+		return uuid.Nil, ErrInvalidID
+	}
+
+	return id, nil
+}
+
+func userID(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(r.Header.Get(HeaderUserID))
+	if err != nil {
+		return uuid.Nil, ErrUnauthenticated
+	}
+
+	return id, nil
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return ErrBadRequest
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      string `json:"code"`
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	Remaining *int   `json:"remaining,omitempty"` // Only for purchase_limit_exceeded.
+}
+
+// newErrorResponse returns the body and the HTTP status of the cause.
+// Errors without a cause are not exposed, and are reported as ErrInternal
+// instead.
+func newErrorResponse(err error) (errorResponse, int) {
+	var d causes.Detail
+	if !errors.As(err, &d) {
+		return newErrorResponse(ErrInternal)
+	}
+
+	det := d.Detail()
+	body := errorBody{
+		Code:    det.Code().String(),
+		Kind:    det.Kind(),
+		Message: det.Message(),
+	}
+
+	if remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(det); ok {
+		body.Remaining = &remaining
+	}
+
+	return errorResponse{
+		Error: body,
+	}, codes.HTTP(det.Code())
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	res, status := newErrorResponse(err)
+	writeJSON(w, status, res)
+}
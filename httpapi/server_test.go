@@ -0,0 +1,466 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/httpapi"
+	"github.com/alextanhongpin/go-domain-test/repository/inmemory"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProduct(t *testing.T) {
+	published := factories.NewProduct("published")
+	unpublished := factories.NewProduct("no_published_at")
+	owner := published.UserID
+	srv := newServer(inmemory.WithProducts(published, unpublished))
+
+	t.Run("view", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products/"+published.ID.String(), uuid.Nil, "")
+
+		as := assert.New(t)
+		as.Equal(http.StatusOK, res.StatusCode)
+		as.JSONEq(`{
+			"id": "`+published.ID.String()+`",
+			"name": "colorful socks",
+			"user_id": "`+owner.String()+`",
+			"published_at": "`+published.PublishedAt.Format(time.RFC3339Nano)+`",
+			"unpublish_at": null,
+			"price": {"amount": 10, "currency": "MYR"},
+			"version": 0,
+			"deleted_at": null
+		}`, res.body)
+	})
+
+	t.Run("view unpublished", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products/"+unpublished.ID.String(), uuid.Nil, "")
+
+		as := assert.New(t)
+		as.Equal(http.StatusNotFound, res.StatusCode)
+		as.JSONEq(`{
+			"error": {
+				"code": "not_found",
+				"kind": "product_not_found",
+				"message": "Product does not exist or may have been deleted."
+			}
+		}`, res.body)
+	})
+
+	t.Run("view invalid id", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products/1", uuid.Nil, "")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "invalid_id", res.errorKind(t))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products", owner, `{"name": "colorful socks"}`)
+
+		as := assert.New(t)
+		as.Equal(http.StatusCreated, res.StatusCode)
+		as.Equal("application/json", res.Header.Get("Content-Type"))
+
+		var body struct {
+			ID     uuid.UUID `json:"id"`
+			UserID uuid.UUID `json:"user_id"`
+		}
+		as.Nil(json.Unmarshal([]byte(res.body), &body))
+		as.NotEqual(uuid.Nil, body.ID)
+		as.Equal(owner, body.UserID)
+	})
+
+	t.Run("create bad name", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products", owner, `{"name": "!@#"}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "product_name_bad_format", res.errorKind(t))
+	})
+
+	t.Run("create bad json", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products", owner, `{"name":`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "bad_request", res.errorKind(t))
+	})
+
+	t.Run("create unauthenticated", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products", uuid.Nil, `{"name": "colorful socks"}`)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "unauthenticated", res.errorKind(t))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		body := `{"name": "striped socks", "price": {"amount": 12, "currency": "MYR"}, "version": 0}`
+		res := srv.do(http.MethodPut, "/products/"+published.ID.String(), owner, body)
+
+		as := assert.New(t)
+		as.Equal(http.StatusOK, res.StatusCode)
+
+		var got struct {
+			Name    string `json:"name"`
+			Version int    `json:"version"`
+		}
+		as.Nil(json.Unmarshal([]byte(res.body), &got))
+		as.Equal("striped socks", got.Name)
+		as.Equal(1, got.Version)
+
+		// The same edit is now based on a stale version.
+		res = srv.do(http.MethodPut, "/products/"+published.ID.String(), owner, body)
+		as.Equal(http.StatusConflict, res.StatusCode)
+		as.Equal("product_version_conflict", res.errorKind(t))
+	})
+
+	t.Run("update negative price", func(t *testing.T) {
+		body := `{"name": "striped socks", "price": {"amount": -1, "currency": "MYR"}, "version": 1}`
+		res := srv.do(http.MethodPut, "/products/"+published.ID.String(), owner, body)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "product_price_invalid", res.errorKind(t))
+	})
+
+	t.Run("update by other user", func(t *testing.T) {
+		body := `{"name": "striped socks", "price": {"amount": 12, "currency": "MYR"}, "version": 1}`
+		res := srv.do(http.MethodPut, "/products/"+published.ID.String(), uuid.New(), body)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "product_unauthorized", res.errorKind(t))
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/publish", owner, "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = srv.do(http.MethodGet, "/products/"+unpublished.ID.String(), uuid.Nil, "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("unpublish", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/unpublish", owner, "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		res = srv.do(http.MethodGet, "/products/"+unpublished.ID.String(), uuid.Nil, "")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("schedule publish", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		body := `{"publish_at": "` + publishAt.Format(time.RFC3339) + `", "unpublish_at": "` + publishAt.Add(time.Hour).Format(time.RFC3339) + `"}`
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/publish", owner, body)
+
+		as := assert.New(t)
+		as.Equal(http.StatusOK, res.StatusCode)
+
+		var got struct {
+			PublishedAt time.Time `json:"published_at"`
+			UnpublishAt time.Time `json:"unpublish_at"`
+		}
+		as.Nil(json.Unmarshal([]byte(res.body), &got))
+		as.True(publishAt.Equal(got.PublishedAt))
+		as.True(publishAt.Add(time.Hour).Equal(got.UnpublishAt))
+
+		// Not visible until the publish time.
+		res = srv.do(http.MethodGet, "/products/"+unpublished.ID.String(), uuid.Nil, "")
+		as.Equal(http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("schedule publish invalid window", func(t *testing.T) {
+		body := `{"unpublish_at": "2000-01-01T00:00:00Z"}`
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/publish", owner, body)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "product_schedule_invalid", res.errorKind(t))
+	})
+
+	t.Run("publish by other user", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/publish", uuid.New(), "")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "product_unauthorized", res.errorKind(t))
+	})
+
+	t.Run("publish method not allowed", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products/"+unpublished.ID.String()+"/publish", owner, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+		assert.Equal(t, "POST", res.Header.Get("Allow"))
+	})
+
+	t.Run("delete by other user", func(t *testing.T) {
+		res := srv.do(http.MethodDelete, "/products/"+published.ID.String(), uuid.New(), "")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "product_unauthorized", res.errorKind(t))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		res := srv.do(http.MethodDelete, "/products/"+unpublished.ID.String(), owner, "")
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		res = srv.do(http.MethodDelete, "/products/"+unpublished.ID.String(), owner, "")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/restore", owner, "")
+
+		as := assert.New(t)
+		as.Equal(http.StatusOK, res.StatusCode, res.body)
+		as.Contains(res.body, `"deleted_at":null`)
+
+		res = srv.do(http.MethodPost, "/products/"+unpublished.ID.String()+"/restore", owner, "")
+		as.Equal(http.StatusConflict, res.StatusCode)
+		as.Equal("product_not_deleted", res.errorKind(t))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		res := srv.do(http.MethodPatch, "/products/"+published.ID.String(), owner, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+		assert.Equal(t, "GET, PUT, DELETE", res.Header.Get("Allow"))
+		assert.Equal(t, "method_not_allowed", res.errorKind(t))
+	})
+}
+
+func TestProductList(t *testing.T) {
+	published := factories.NewProduct("published")
+	unpublished := factories.NewProduct("no_published_at")
+	owner := unpublished.UserID
+	srv := newServer(inmemory.WithProducts(published, unpublished))
+
+	t.Run("list", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products?limit=1", uuid.Nil, "")
+
+		as := assert.New(t)
+		as.Equal(http.StatusOK, res.StatusCode, res.body)
+
+		var page struct {
+			Products []struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"products"`
+			NextCursor string `json:"next_cursor"`
+		}
+		as.Nil(json.Unmarshal([]byte(res.body), &page))
+		if as.Len(page.Products, 1) {
+			as.Equal(published.ID, page.Products[0].ID)
+		}
+		as.Empty(page.NextCursor)
+	})
+
+	t.Run("list unpublished as owner", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products?published=false&max_price=10&currency=MYR", owner, "")
+		assert.Equal(t, http.StatusOK, res.StatusCode, res.body)
+		assert.Contains(t, res.body, unpublished.ID.String())
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products?published=maybe", uuid.Nil, "")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "invalid_query", res.errorKind(t))
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/products?sort=newest", uuid.Nil, "")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "product_query_invalid", res.errorKind(t))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		res := srv.do(http.MethodDelete, "/products", owner, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+		assert.Equal(t, "GET, POST", res.Header.Get("Allow"))
+	})
+}
+
+func TestPurchase(t *testing.T) {
+	user := factories.NewUser()
+	p := factories.NewProduct("published")
+	d := factories.NewDiscount()
+	d.ProductID = p.ID
+	limited := factories.NewProduct("published", "limited")
+
+	srv := newServer(
+		inmemory.WithUsers(user),
+		inmemory.WithProducts(p, limited),
+		inmemory.WithDiscounts(d),
+		inmemory.WithStock(p.ID, 3),
+		inmemory.WithStock(limited.ID, 3),
+	)
+	body := `{"product_id": "` + p.ID.String() + `", "unit": 2}`
+
+	t.Run("success", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/purchases", user.ID, body)
+
+		as := assert.New(t)
+		as.Equal(http.StatusCreated, res.StatusCode)
+
+		var got struct {
+			ProductID uuid.UUID `json:"product_id"`
+			Unit      int       `json:"unit"`
+			Discount  struct {
+				Amount int64 `json:"amount"`
+			} `json:"discount"`
+			Total struct {
+				Amount int64 `json:"amount"`
+			} `json:"total"`
+			Status string `json:"status"`
+		}
+		as.Nil(json.Unmarshal([]byte(res.body), &got))
+		as.Equal(p.ID, got.ProductID)
+		as.Equal(2, got.Unit)
+		as.Equal(int64(-5), got.Discount.Amount)
+		as.Equal(int64(10), got.Total.Amount)
+		as.Equal(string(domain.PurchaseStatusPending), got.Status)
+	})
+
+	t.Run("out of stock", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/purchases", user.ID, body)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, "product_out_of_stock", res.errorKind(t))
+	})
+
+	t.Run("purchase limit exceeded", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/purchases", user.ID, `{"product_id": "`+limited.ID.String()+`", "unit": 2}`)
+
+		as := assert.New(t)
+		as.Equal(http.StatusBadRequest, res.StatusCode)
+		as.JSONEq(`{
+			"error": {
+				"code": "precondition_failed",
+				"kind": "purchase_limit_exceeded",
+				"message": "You have reached the purchase limit for this product.",
+				"remaining": 1
+			}
+		}`, res.body)
+	})
+
+	t.Run("ineligible user", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/purchases", uuid.New(), body)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.Equal(t, "user_ineligible", res.errorKind(t))
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		res := srv.do(http.MethodPost, "/purchases", user.ID, `{"units": 2}`)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "bad_request", res.errorKind(t))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		res := srv.do(http.MethodGet, "/purchases", user.ID, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+		assert.Equal(t, "POST", res.Header.Get("Allow"))
+	})
+}
+
+func TestInternalError(t *testing.T) {
+	h := httpapi.New(new(failingProductUsecase), nil).Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/products/"+uuid.NewString(), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	as := assert.New(t)
+	as.Equal(http.StatusInternalServerError, rec.Code)
+
+	// The error is not leaked.
+	as.JSONEq(`{
+		"error": {
+			"code": "internal",
+			"kind": "internal",
+			"message": "Something went wrong."
+		}
+	}`, rec.Body.String())
+}
+
+type server struct {
+	handler http.Handler
+}
+
+func newServer(opts ...inmemory.Option) *server {
+	store := inmemory.NewStore(opts...)
+	product := usecase.NewProduct(inmemory.NewProductRepository(store), event.NewInMemoryPublisher())
+	purchase := usecase.NewPurchaseUsecase(inmemory.NewPurchaseRepository(store), inmemory.NewIdempotencyRepository(store))
+
+	return &server{
+		handler: httpapi.New(product, purchase).Handler(),
+	}
+}
+
+type response struct {
+	*http.Response
+	body string
+}
+
+// do sends the request as the user, unless the user is uuid.Nil.
+func (s *server) do(method, target string, userID uuid.UUID, body string) *response {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != uuid.Nil {
+		req.Header.Set(httpapi.HeaderUserID, userID.String())
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	res := rec.Result()
+	b, _ := io.ReadAll(res.Body)
+
+	return &response{
+		Response: res,
+		body:     string(b),
+	}
+}
+
+func (r *response) errorKind(t *testing.T) string {
+	t.Helper()
+
+	var body struct {
+		Error struct {
+			Kind string `json:"kind"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(r.body), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	return body.Error.Kind
+}
+
+type failingProductUsecase struct{}
+
+func (failingProductUsecase) View(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Create(ctx context.Context, dto usecase.CreateProductDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Update(ctx context.Context, dto usecase.UpdateProductDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Publish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) SchedulePublish(ctx context.Context, dto usecase.SchedulePublishDto) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Unpublish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	return errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) Restore(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return nil, errors.New("db: connection refused")
+}
+
+func (failingProductUsecase) List(ctx context.Context, dto usecase.ListProductsDto) (*usecase.ProductPage, error) {
+	return nil, errors.New("db: connection refused")
+}
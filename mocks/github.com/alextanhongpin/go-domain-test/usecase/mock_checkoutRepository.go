@@ -0,0 +1,361 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+	time "time"
+
+	domain "github.com/alextanhongpin/go-domain-test/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockCheckoutRepository is an autogenerated mock type for the checkoutRepository type
+type MockCheckoutRepository struct {
+	mock.Mock
+}
+
+type MockCheckoutRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockCheckoutRepository) EXPECT() *MockCheckoutRepository_Expecter {
+	return &MockCheckoutRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateOrder provides a mock function with given fields: ctx, order
+func (_m *MockCheckoutRepository) CreateOrder(ctx context.Context, order domain.Order) error {
+	ret := _m.Called(ctx, order)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Order) error); ok {
+		r0 = rf(ctx, order)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockCheckoutRepository_CreateOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOrder'
+type MockCheckoutRepository_CreateOrder_Call struct {
+	*mock.Call
+}
+
+// CreateOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - order domain.Order
+func (_e *MockCheckoutRepository_Expecter) CreateOrder(ctx interface{}, order interface{}) *MockCheckoutRepository_CreateOrder_Call {
+	return &MockCheckoutRepository_CreateOrder_Call{Call: _e.mock.On("CreateOrder", ctx, order)}
+}
+
+func (_c *MockCheckoutRepository_CreateOrder_Call) Run(run func(ctx context.Context, order domain.Order)) *MockCheckoutRepository_CreateOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Order))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_CreateOrder_Call) Return(_a0 error) *MockCheckoutRepository_CreateOrder_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_CreateOrder_Call) RunAndReturn(run func(context.Context, domain.Order) error) *MockCheckoutRepository_CreateOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindProduct provides a mock function with given fields: ctx, productID
+func (_m *MockCheckoutRepository) FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error) {
+	ret := _m.Called(ctx, productID)
+
+	var r0 *domain.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Product, error)); ok {
+		return rf(ctx, productID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Product); ok {
+		r0 = rf(ctx, productID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCheckoutRepository_FindProduct_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindProduct'
+type MockCheckoutRepository_FindProduct_Call struct {
+	*mock.Call
+}
+
+// FindProduct is a helper method to define mock.On call
+//   - ctx context.Context
+//   - productID uuid.UUID
+func (_e *MockCheckoutRepository_Expecter) FindProduct(ctx interface{}, productID interface{}) *MockCheckoutRepository_FindProduct_Call {
+	return &MockCheckoutRepository_FindProduct_Call{Call: _e.mock.On("FindProduct", ctx, productID)}
+}
+
+func (_c *MockCheckoutRepository_FindProduct_Call) Run(run func(ctx context.Context, productID uuid.UUID)) *MockCheckoutRepository_FindProduct_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindProduct_Call) Return(_a0 *domain.Product, _a1 error) *MockCheckoutRepository_FindProduct_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindProduct_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.Product, error)) *MockCheckoutRepository_FindProduct_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindProductDiscount provides a mock function with given fields: ctx, productID
+func (_m *MockCheckoutRepository) FindProductDiscount(ctx context.Context, productID uuid.UUID) ([]domain.Discount, error) {
+	ret := _m.Called(ctx, productID)
+
+	var r0 []domain.Discount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]domain.Discount, error)); ok {
+		return rf(ctx, productID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []domain.Discount); ok {
+		r0 = rf(ctx, productID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Discount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, productID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCheckoutRepository_FindProductDiscount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindProductDiscount'
+type MockCheckoutRepository_FindProductDiscount_Call struct {
+	*mock.Call
+}
+
+// FindProductDiscount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - productID uuid.UUID
+func (_e *MockCheckoutRepository_Expecter) FindProductDiscount(ctx interface{}, productID interface{}) *MockCheckoutRepository_FindProductDiscount_Call {
+	return &MockCheckoutRepository_FindProductDiscount_Call{Call: _e.mock.On("FindProductDiscount", ctx, productID)}
+}
+
+func (_c *MockCheckoutRepository_FindProductDiscount_Call) Run(run func(ctx context.Context, productID uuid.UUID)) *MockCheckoutRepository_FindProductDiscount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindProductDiscount_Call) Return(_a0 []domain.Discount, _a1 error) *MockCheckoutRepository_FindProductDiscount_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindProductDiscount_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]domain.Discount, error)) *MockCheckoutRepository_FindProductDiscount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindUser provides a mock function with given fields: ctx, userID
+func (_m *MockCheckoutRepository) FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCheckoutRepository_FindUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUser'
+type MockCheckoutRepository_FindUser_Call struct {
+	*mock.Call
+}
+
+// FindUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *MockCheckoutRepository_Expecter) FindUser(ctx interface{}, userID interface{}) *MockCheckoutRepository_FindUser_Call {
+	return &MockCheckoutRepository_FindUser_Call{Call: _e.mock.On("FindUser", ctx, userID)}
+}
+
+func (_c *MockCheckoutRepository_FindUser_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *MockCheckoutRepository_FindUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUser_Call) Return(_a0 *domain.User, _a1 error) *MockCheckoutRepository_FindUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUser_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.User, error)) *MockCheckoutRepository_FindUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindUserProductPurchases provides a mock function with given fields: ctx, userID, productID, since
+func (_m *MockCheckoutRepository) FindUserProductPurchases(ctx context.Context, userID uuid.UUID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error) {
+	ret := _m.Called(ctx, userID, productID, since)
+
+	var r0 []domain.PurchasedUnits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) ([]domain.PurchasedUnits, error)); ok {
+		return rf(ctx, userID, productID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) []domain.PurchasedUnits); ok {
+		r0 = rf(ctx, userID, productID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PurchasedUnits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, productID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCheckoutRepository_FindUserProductPurchases_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUserProductPurchases'
+type MockCheckoutRepository_FindUserProductPurchases_Call struct {
+	*mock.Call
+}
+
+// FindUserProductPurchases is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - productID uuid.UUID
+//   - since time.Time
+func (_e *MockCheckoutRepository_Expecter) FindUserProductPurchases(ctx interface{}, userID interface{}, productID interface{}, since interface{}) *MockCheckoutRepository_FindUserProductPurchases_Call {
+	return &MockCheckoutRepository_FindUserProductPurchases_Call{Call: _e.mock.On("FindUserProductPurchases", ctx, userID, productID, since)}
+}
+
+func (_c *MockCheckoutRepository_FindUserProductPurchases_Call) Run(run func(ctx context.Context, userID uuid.UUID, productID uuid.UUID, since time.Time)) *MockCheckoutRepository_FindUserProductPurchases_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUserProductPurchases_Call) Return(_a0 []domain.PurchasedUnits, _a1 error) *MockCheckoutRepository_FindUserProductPurchases_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUserProductPurchases_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID, time.Time) ([]domain.PurchasedUnits, error)) *MockCheckoutRepository_FindUserProductPurchases_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindUserPurchaseTimes provides a mock function with given fields: ctx, userID, since
+func (_m *MockCheckoutRepository) FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	var r0 []time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) ([]time.Time, error)); ok {
+		return rf(ctx, userID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) []time.Time); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCheckoutRepository_FindUserPurchaseTimes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUserPurchaseTimes'
+type MockCheckoutRepository_FindUserPurchaseTimes_Call struct {
+	*mock.Call
+}
+
+// FindUserPurchaseTimes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - since time.Time
+func (_e *MockCheckoutRepository_Expecter) FindUserPurchaseTimes(ctx interface{}, userID interface{}, since interface{}) *MockCheckoutRepository_FindUserPurchaseTimes_Call {
+	return &MockCheckoutRepository_FindUserPurchaseTimes_Call{Call: _e.mock.On("FindUserPurchaseTimes", ctx, userID, since)}
+}
+
+func (_c *MockCheckoutRepository_FindUserPurchaseTimes_Call) Run(run func(ctx context.Context, userID uuid.UUID, since time.Time)) *MockCheckoutRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUserPurchaseTimes_Call) Return(_a0 []time.Time, _a1 error) *MockCheckoutRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCheckoutRepository_FindUserPurchaseTimes_Call) RunAndReturn(run func(context.Context, uuid.UUID, time.Time) ([]time.Time, error)) *MockCheckoutRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockCheckoutRepository creates a new instance of MockCheckoutRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockCheckoutRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockCheckoutRepository {
+	mock := &MockCheckoutRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,142 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+
+	domain "github.com/alextanhongpin/go-domain-test/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockIdempotencyRepository is an autogenerated mock type for the idempotencyRepository type
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+type MockIdempotencyRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepository_Expecter {
+	return &MockIdempotencyRepository_Expecter{mock: &_m.Mock}
+}
+
+// DeleteIdempotencyKey provides a mock function with given fields: ctx, key
+func (_m *MockIdempotencyRepository) DeleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockIdempotencyRepository_DeleteIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteIdempotencyKey'
+type MockIdempotencyRepository_DeleteIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// DeleteIdempotencyKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key domain.IdempotencyKey
+func (_e *MockIdempotencyRepository_Expecter) DeleteIdempotencyKey(ctx interface{}, key interface{}) *MockIdempotencyRepository_DeleteIdempotencyKey_Call {
+	return &MockIdempotencyRepository_DeleteIdempotencyKey_Call{Call: _e.mock.On("DeleteIdempotencyKey", ctx, key)}
+}
+
+func (_c *MockIdempotencyRepository_DeleteIdempotencyKey_Call) Run(run func(ctx context.Context, key domain.IdempotencyKey)) *MockIdempotencyRepository_DeleteIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.IdempotencyKey))
+	})
+	return _c
+}
+
+func (_c *MockIdempotencyRepository_DeleteIdempotencyKey_Call) Return(_a0 error) *MockIdempotencyRepository_DeleteIdempotencyKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockIdempotencyRepository_DeleteIdempotencyKey_Call) RunAndReturn(run func(context.Context, domain.IdempotencyKey) error) *MockIdempotencyRepository_DeleteIdempotencyKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockIdempotencyKey provides a mock function with given fields: ctx, key
+func (_m *MockIdempotencyRepository) LockIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *domain.IdempotencyKey
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.IdempotencyKey) *domain.IdempotencyKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.IdempotencyKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.IdempotencyKey) bool); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.IdempotencyKey) error); ok {
+		r2 = rf(ctx, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockIdempotencyRepository_LockIdempotencyKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockIdempotencyKey'
+type MockIdempotencyRepository_LockIdempotencyKey_Call struct {
+	*mock.Call
+}
+
+// LockIdempotencyKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key domain.IdempotencyKey
+func (_e *MockIdempotencyRepository_Expecter) LockIdempotencyKey(ctx interface{}, key interface{}) *MockIdempotencyRepository_LockIdempotencyKey_Call {
+	return &MockIdempotencyRepository_LockIdempotencyKey_Call{Call: _e.mock.On("LockIdempotencyKey", ctx, key)}
+}
+
+func (_c *MockIdempotencyRepository_LockIdempotencyKey_Call) Run(run func(ctx context.Context, key domain.IdempotencyKey)) *MockIdempotencyRepository_LockIdempotencyKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.IdempotencyKey))
+	})
+	return _c
+}
+
+func (_c *MockIdempotencyRepository_LockIdempotencyKey_Call) Return(existing *domain.IdempotencyKey, created bool, err error) *MockIdempotencyRepository_LockIdempotencyKey_Call {
+	_c.Call.Return(existing, created, err)
+	return _c
+}
+
+func (_c *MockIdempotencyRepository_LockIdempotencyKey_Call) RunAndReturn(run func(context.Context, domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error)) *MockIdempotencyRepository_LockIdempotencyKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockIdempotencyRepository creates a new instance of MockIdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockIdempotencyRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,90 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockProductPurgeRepository is an autogenerated mock type for the productPurgeRepository type
+type MockProductPurgeRepository struct {
+	mock.Mock
+}
+
+type MockProductPurgeRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockProductPurgeRepository) EXPECT() *MockProductPurgeRepository_Expecter {
+	return &MockProductPurgeRepository_Expecter{mock: &_m.Mock}
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *MockProductPurgeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockProductPurgeRepository_PurgeDeleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PurgeDeleted'
+type MockProductPurgeRepository_PurgeDeleted_Call struct {
+	*mock.Call
+}
+
+// PurgeDeleted is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *MockProductPurgeRepository_Expecter) PurgeDeleted(ctx interface{}, before interface{}) *MockProductPurgeRepository_PurgeDeleted_Call {
+	return &MockProductPurgeRepository_PurgeDeleted_Call{Call: _e.mock.On("PurgeDeleted", ctx, before)}
+}
+
+func (_c *MockProductPurgeRepository_PurgeDeleted_Call) Run(run func(ctx context.Context, before time.Time)) *MockProductPurgeRepository_PurgeDeleted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockProductPurgeRepository_PurgeDeleted_Call) Return(_a0 int, _a1 error) *MockProductPurgeRepository_PurgeDeleted_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockProductPurgeRepository_PurgeDeleted_Call) RunAndReturn(run func(context.Context, time.Time) (int, error)) *MockProductPurgeRepository_PurgeDeleted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockProductPurgeRepository creates a new instance of MockProductPurgeRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockProductPurgeRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockProductPurgeRepository {
+	mock := &MockProductPurgeRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -6,9 +6,9 @@ import (
 	context "context"
 
 	domain "github.com/alextanhongpin/go-domain-test/domain"
-	mock "github.com/stretchr/testify/mock"
-
+	usecase "github.com/alextanhongpin/go-domain-test/usecase"
 	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
 )
 
 // MockProductRepository is an autogenerated mock type for the productRepository type
@@ -80,68 +80,80 @@ func (_c *MockProductRepository_Create_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *MockProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+// FindByID provides a mock function with given fields: ctx, id
+func (_m *MockProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+	var r0 *domain.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Product, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Product); ok {
 		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockProductRepository_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
-type MockProductRepository_Delete_Call struct {
+// MockProductRepository_FindByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByID'
+type MockProductRepository_FindByID_Call struct {
 	*mock.Call
 }
 
-// Delete is a helper method to define mock.On call
+// FindByID is a helper method to define mock.On call
 //   - ctx context.Context
 //   - id uuid.UUID
-func (_e *MockProductRepository_Expecter) Delete(ctx interface{}, id interface{}) *MockProductRepository_Delete_Call {
-	return &MockProductRepository_Delete_Call{Call: _e.mock.On("Delete", ctx, id)}
+func (_e *MockProductRepository_Expecter) FindByID(ctx interface{}, id interface{}) *MockProductRepository_FindByID_Call {
+	return &MockProductRepository_FindByID_Call{Call: _e.mock.On("FindByID", ctx, id)}
 }
 
-func (_c *MockProductRepository_Delete_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockProductRepository_Delete_Call {
+func (_c *MockProductRepository_FindByID_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockProductRepository_FindByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		run(args[0].(context.Context), args[1].(uuid.UUID))
 	})
 	return _c
 }
 
-func (_c *MockProductRepository_Delete_Call) Return(_a0 error) *MockProductRepository_Delete_Call {
-	_c.Call.Return(_a0)
+func (_c *MockProductRepository_FindByID_Call) Return(_a0 *domain.Product, _a1 error) *MockProductRepository_FindByID_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockProductRepository_Delete_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockProductRepository_Delete_Call {
+func (_c *MockProductRepository_FindByID_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.Product, error)) *MockProductRepository_FindByID_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// FindByID provides a mock function with given fields: ctx, id
-func (_m *MockProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
-	ret := _m.Called(ctx, id)
+// List provides a mock function with given fields: ctx, q
+func (_m *MockProductRepository) List(ctx context.Context, q usecase.ProductQuery) ([]domain.Product, error) {
+	ret := _m.Called(ctx, q)
 
-	var r0 *domain.Product
+	var r0 []domain.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Product, error)); ok {
-		return rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.ProductQuery) ([]domain.Product, error)); ok {
+		return rf(ctx, q)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Product); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, usecase.ProductQuery) []domain.Product); ok {
+		r0 = rf(ctx, q)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.Product)
+			r0 = ret.Get(0).([]domain.Product)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, usecase.ProductQuery) error); ok {
+		r1 = rf(ctx, q)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -149,31 +161,75 @@ func (_m *MockProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*d
 	return r0, r1
 }
 
-// MockProductRepository_FindByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByID'
-type MockProductRepository_FindByID_Call struct {
+// MockProductRepository_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockProductRepository_List_Call struct {
 	*mock.Call
 }
 
-// FindByID is a helper method to define mock.On call
+// List is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id uuid.UUID
-func (_e *MockProductRepository_Expecter) FindByID(ctx interface{}, id interface{}) *MockProductRepository_FindByID_Call {
-	return &MockProductRepository_FindByID_Call{Call: _e.mock.On("FindByID", ctx, id)}
+//   - q usecase.ProductQuery
+func (_e *MockProductRepository_Expecter) List(ctx interface{}, q interface{}) *MockProductRepository_List_Call {
+	return &MockProductRepository_List_Call{Call: _e.mock.On("List", ctx, q)}
 }
 
-func (_c *MockProductRepository_FindByID_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockProductRepository_FindByID_Call {
+func (_c *MockProductRepository_List_Call) Run(run func(ctx context.Context, q usecase.ProductQuery)) *MockProductRepository_List_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(usecase.ProductQuery))
 	})
 	return _c
 }
 
-func (_c *MockProductRepository_FindByID_Call) Return(_a0 *domain.Product, _a1 error) *MockProductRepository_FindByID_Call {
+func (_c *MockProductRepository_List_Call) Return(_a0 []domain.Product, _a1 error) *MockProductRepository_List_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockProductRepository_FindByID_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.Product, error)) *MockProductRepository_FindByID_Call {
+func (_c *MockProductRepository_List_Call) RunAndReturn(run func(context.Context, usecase.ProductQuery) ([]domain.Product, error)) *MockProductRepository_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Update provides a mock function with given fields: ctx, p, version
+func (_m *MockProductRepository) Update(ctx context.Context, p domain.Product, version int) error {
+	ret := _m.Called(ctx, p, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Product, int) error); ok {
+		r0 = rf(ctx, p, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockProductRepository_Update_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Update'
+type MockProductRepository_Update_Call struct {
+	*mock.Call
+}
+
+// Update is a helper method to define mock.On call
+//   - ctx context.Context
+//   - p domain.Product
+//   - version int
+func (_e *MockProductRepository_Expecter) Update(ctx interface{}, p interface{}, version interface{}) *MockProductRepository_Update_Call {
+	return &MockProductRepository_Update_Call{Call: _e.mock.On("Update", ctx, p, version)}
+}
+
+func (_c *MockProductRepository_Update_Call) Run(run func(ctx context.Context, p domain.Product, version int)) *MockProductRepository_Update_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Product), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockProductRepository_Update_Call) Return(_a0 error) *MockProductRepository_Update_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockProductRepository_Update_Call) RunAndReturn(run func(context.Context, domain.Product, int) error) *MockProductRepository_Update_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,196 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+	time "time"
+
+	domain "github.com/alextanhongpin/go-domain-test/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockProductScheduleRepository is an autogenerated mock type for the productScheduleRepository type
+type MockProductScheduleRepository struct {
+	mock.Mock
+}
+
+type MockProductScheduleRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockProductScheduleRepository) EXPECT() *MockProductScheduleRepository_Expecter {
+	return &MockProductScheduleRepository_Expecter{mock: &_m.Mock}
+}
+
+// FindScheduleWatermark provides a mock function with given fields: ctx
+func (_m *MockProductScheduleRepository) FindScheduleWatermark(ctx context.Context) (time.Time, bool, error) {
+	ret := _m.Called(ctx)
+
+	var r0 time.Time
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context) (time.Time, bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) time.Time); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) bool); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) error); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockProductScheduleRepository_FindScheduleWatermark_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindScheduleWatermark'
+type MockProductScheduleRepository_FindScheduleWatermark_Call struct {
+	*mock.Call
+}
+
+// FindScheduleWatermark is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockProductScheduleRepository_Expecter) FindScheduleWatermark(ctx interface{}) *MockProductScheduleRepository_FindScheduleWatermark_Call {
+	return &MockProductScheduleRepository_FindScheduleWatermark_Call{Call: _e.mock.On("FindScheduleWatermark", ctx)}
+}
+
+func (_c *MockProductScheduleRepository_FindScheduleWatermark_Call) Run(run func(ctx context.Context)) *MockProductScheduleRepository_FindScheduleWatermark_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_FindScheduleWatermark_Call) Return(_a0 time.Time, _a1 bool, _a2 error) *MockProductScheduleRepository_FindScheduleWatermark_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_FindScheduleWatermark_Call) RunAndReturn(run func(context.Context) (time.Time, bool, error)) *MockProductScheduleRepository_FindScheduleWatermark_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindScheduled provides a mock function with given fields: ctx, from, to
+func (_m *MockProductScheduleRepository) FindScheduled(ctx context.Context, from time.Time, to time.Time) ([]domain.Product, error) {
+	ret := _m.Called(ctx, from, to)
+
+	var r0 []domain.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) ([]domain.Product, error)); ok {
+		return rf(ctx, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) []domain.Product); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockProductScheduleRepository_FindScheduled_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindScheduled'
+type MockProductScheduleRepository_FindScheduled_Call struct {
+	*mock.Call
+}
+
+// FindScheduled is a helper method to define mock.On call
+//   - ctx context.Context
+//   - from time.Time
+//   - to time.Time
+func (_e *MockProductScheduleRepository_Expecter) FindScheduled(ctx interface{}, from interface{}, to interface{}) *MockProductScheduleRepository_FindScheduled_Call {
+	return &MockProductScheduleRepository_FindScheduled_Call{Call: _e.mock.On("FindScheduled", ctx, from, to)}
+}
+
+func (_c *MockProductScheduleRepository_FindScheduled_Call) Run(run func(ctx context.Context, from time.Time, to time.Time)) *MockProductScheduleRepository_FindScheduled_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_FindScheduled_Call) Return(_a0 []domain.Product, _a1 error) *MockProductScheduleRepository_FindScheduled_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_FindScheduled_Call) RunAndReturn(run func(context.Context, time.Time, time.Time) ([]domain.Product, error)) *MockProductScheduleRepository_FindScheduled_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveScheduleWatermark provides a mock function with given fields: ctx, since
+func (_m *MockProductScheduleRepository) SaveScheduleWatermark(ctx context.Context, since time.Time) error {
+	ret := _m.Called(ctx, since)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, since)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockProductScheduleRepository_SaveScheduleWatermark_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveScheduleWatermark'
+type MockProductScheduleRepository_SaveScheduleWatermark_Call struct {
+	*mock.Call
+}
+
+// SaveScheduleWatermark is a helper method to define mock.On call
+//   - ctx context.Context
+//   - since time.Time
+func (_e *MockProductScheduleRepository_Expecter) SaveScheduleWatermark(ctx interface{}, since interface{}) *MockProductScheduleRepository_SaveScheduleWatermark_Call {
+	return &MockProductScheduleRepository_SaveScheduleWatermark_Call{Call: _e.mock.On("SaveScheduleWatermark", ctx, since)}
+}
+
+func (_c *MockProductScheduleRepository_SaveScheduleWatermark_Call) Run(run func(ctx context.Context, since time.Time)) *MockProductScheduleRepository_SaveScheduleWatermark_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_SaveScheduleWatermark_Call) Return(_a0 error) *MockProductScheduleRepository_SaveScheduleWatermark_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockProductScheduleRepository_SaveScheduleWatermark_Call) RunAndReturn(run func(context.Context, time.Time) error) *MockProductScheduleRepository_SaveScheduleWatermark_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockProductScheduleRepository creates a new instance of MockProductScheduleRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockProductScheduleRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockProductScheduleRepository {
+	mock := &MockProductScheduleRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,322 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+
+	domain "github.com/alextanhongpin/go-domain-test/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockPurchaseLifecycleRepository is an autogenerated mock type for the purchaseLifecycleRepository type
+type MockPurchaseLifecycleRepository struct {
+	mock.Mock
+}
+
+type MockPurchaseLifecycleRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockPurchaseLifecycleRepository) EXPECT() *MockPurchaseLifecycleRepository_Expecter {
+	return &MockPurchaseLifecycleRepository_Expecter{mock: &_m.Mock}
+}
+
+// CancelPurchase provides a mock function with given fields: ctx, purchase
+func (_m *MockPurchaseLifecycleRepository) CancelPurchase(ctx context.Context, purchase domain.Purchase) error {
+	ret := _m.Called(ctx, purchase)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Purchase) error); ok {
+		r0 = rf(ctx, purchase)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockPurchaseLifecycleRepository_CancelPurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelPurchase'
+type MockPurchaseLifecycleRepository_CancelPurchase_Call struct {
+	*mock.Call
+}
+
+// CancelPurchase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - purchase domain.Purchase
+func (_e *MockPurchaseLifecycleRepository_Expecter) CancelPurchase(ctx interface{}, purchase interface{}) *MockPurchaseLifecycleRepository_CancelPurchase_Call {
+	return &MockPurchaseLifecycleRepository_CancelPurchase_Call{Call: _e.mock.On("CancelPurchase", ctx, purchase)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_CancelPurchase_Call) Run(run func(ctx context.Context, purchase domain.Purchase)) *MockPurchaseLifecycleRepository_CancelPurchase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Purchase))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_CancelPurchase_Call) Return(_a0 error) *MockPurchaseLifecycleRepository_CancelPurchase_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_CancelPurchase_Call) RunAndReturn(run func(context.Context, domain.Purchase) error) *MockPurchaseLifecycleRepository_CancelPurchase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRefund provides a mock function with given fields: ctx, refund, purchase
+func (_m *MockPurchaseLifecycleRepository) CreateRefund(ctx context.Context, refund domain.Refund, purchase domain.Purchase) error {
+	ret := _m.Called(ctx, refund, purchase)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Refund, domain.Purchase) error); ok {
+		r0 = rf(ctx, refund, purchase)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockPurchaseLifecycleRepository_CreateRefund_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRefund'
+type MockPurchaseLifecycleRepository_CreateRefund_Call struct {
+	*mock.Call
+}
+
+// CreateRefund is a helper method to define mock.On call
+//   - ctx context.Context
+//   - refund domain.Refund
+//   - purchase domain.Purchase
+func (_e *MockPurchaseLifecycleRepository_Expecter) CreateRefund(ctx interface{}, refund interface{}, purchase interface{}) *MockPurchaseLifecycleRepository_CreateRefund_Call {
+	return &MockPurchaseLifecycleRepository_CreateRefund_Call{Call: _e.mock.On("CreateRefund", ctx, refund, purchase)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_CreateRefund_Call) Run(run func(ctx context.Context, refund domain.Refund, purchase domain.Purchase)) *MockPurchaseLifecycleRepository_CreateRefund_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Refund), args[2].(domain.Purchase))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_CreateRefund_Call) Return(_a0 error) *MockPurchaseLifecycleRepository_CreateRefund_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_CreateRefund_Call) RunAndReturn(run func(context.Context, domain.Refund, domain.Purchase) error) *MockPurchaseLifecycleRepository_CreateRefund_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindPurchase provides a mock function with given fields: ctx, id
+func (_m *MockPurchaseLifecycleRepository) FindPurchase(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Purchase, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Purchase); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseLifecycleRepository_FindPurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindPurchase'
+type MockPurchaseLifecycleRepository_FindPurchase_Call struct {
+	*mock.Call
+}
+
+// FindPurchase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *MockPurchaseLifecycleRepository_Expecter) FindPurchase(ctx interface{}, id interface{}) *MockPurchaseLifecycleRepository_FindPurchase_Call {
+	return &MockPurchaseLifecycleRepository_FindPurchase_Call{Call: _e.mock.On("FindPurchase", ctx, id)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindPurchase_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockPurchaseLifecycleRepository_FindPurchase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindPurchase_Call) Return(_a0 *domain.Purchase, _a1 error) *MockPurchaseLifecycleRepository_FindPurchase_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindPurchase_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.Purchase, error)) *MockPurchaseLifecycleRepository_FindPurchase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindRefunds provides a mock function with given fields: ctx, purchaseID
+func (_m *MockPurchaseLifecycleRepository) FindRefunds(ctx context.Context, purchaseID uuid.UUID) ([]domain.Refund, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	var r0 []domain.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]domain.Refund, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []domain.Refund); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseLifecycleRepository_FindRefunds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindRefunds'
+type MockPurchaseLifecycleRepository_FindRefunds_Call struct {
+	*mock.Call
+}
+
+// FindRefunds is a helper method to define mock.On call
+//   - ctx context.Context
+//   - purchaseID uuid.UUID
+func (_e *MockPurchaseLifecycleRepository_Expecter) FindRefunds(ctx interface{}, purchaseID interface{}) *MockPurchaseLifecycleRepository_FindRefunds_Call {
+	return &MockPurchaseLifecycleRepository_FindRefunds_Call{Call: _e.mock.On("FindRefunds", ctx, purchaseID)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindRefunds_Call) Run(run func(ctx context.Context, purchaseID uuid.UUID)) *MockPurchaseLifecycleRepository_FindRefunds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindRefunds_Call) Return(_a0 []domain.Refund, _a1 error) *MockPurchaseLifecycleRepository_FindRefunds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_FindRefunds_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]domain.Refund, error)) *MockPurchaseLifecycleRepository_FindRefunds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PayPurchase provides a mock function with given fields: ctx, purchase
+func (_m *MockPurchaseLifecycleRepository) PayPurchase(ctx context.Context, purchase domain.Purchase) error {
+	ret := _m.Called(ctx, purchase)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Purchase) error); ok {
+		r0 = rf(ctx, purchase)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockPurchaseLifecycleRepository_PayPurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PayPurchase'
+type MockPurchaseLifecycleRepository_PayPurchase_Call struct {
+	*mock.Call
+}
+
+// PayPurchase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - purchase domain.Purchase
+func (_e *MockPurchaseLifecycleRepository_Expecter) PayPurchase(ctx interface{}, purchase interface{}) *MockPurchaseLifecycleRepository_PayPurchase_Call {
+	return &MockPurchaseLifecycleRepository_PayPurchase_Call{Call: _e.mock.On("PayPurchase", ctx, purchase)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_PayPurchase_Call) Run(run func(ctx context.Context, purchase domain.Purchase)) *MockPurchaseLifecycleRepository_PayPurchase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Purchase))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_PayPurchase_Call) Return(_a0 error) *MockPurchaseLifecycleRepository_PayPurchase_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_PayPurchase_Call) RunAndReturn(run func(context.Context, domain.Purchase) error) *MockPurchaseLifecycleRepository_PayPurchase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePurchase provides a mock function with given fields: ctx, purchase
+func (_m *MockPurchaseLifecycleRepository) UpdatePurchase(ctx context.Context, purchase domain.Purchase) error {
+	ret := _m.Called(ctx, purchase)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Purchase) error); ok {
+		r0 = rf(ctx, purchase)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockPurchaseLifecycleRepository_UpdatePurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePurchase'
+type MockPurchaseLifecycleRepository_UpdatePurchase_Call struct {
+	*mock.Call
+}
+
+// UpdatePurchase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - purchase domain.Purchase
+func (_e *MockPurchaseLifecycleRepository_Expecter) UpdatePurchase(ctx interface{}, purchase interface{}) *MockPurchaseLifecycleRepository_UpdatePurchase_Call {
+	return &MockPurchaseLifecycleRepository_UpdatePurchase_Call{Call: _e.mock.On("UpdatePurchase", ctx, purchase)}
+}
+
+func (_c *MockPurchaseLifecycleRepository_UpdatePurchase_Call) Run(run func(ctx context.Context, purchase domain.Purchase)) *MockPurchaseLifecycleRepository_UpdatePurchase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Purchase))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_UpdatePurchase_Call) Return(_a0 error) *MockPurchaseLifecycleRepository_UpdatePurchase_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPurchaseLifecycleRepository_UpdatePurchase_Call) RunAndReturn(run func(context.Context, domain.Purchase) error) *MockPurchaseLifecycleRepository_UpdatePurchase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockPurchaseLifecycleRepository creates a new instance of MockPurchaseLifecycleRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockPurchaseLifecycleRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockPurchaseLifecycleRepository {
+	mock := &MockPurchaseLifecycleRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,7 @@ package usecase
 
 import (
 	context "context"
+	time "time"
 
 	domain "github.com/alextanhongpin/go-domain-test/domain"
 	mock "github.com/stretchr/testify/mock"
@@ -24,13 +25,69 @@ func (_m *MockPurchaseRepository) EXPECT() *MockPurchaseRepository_Expecter {
 	return &MockPurchaseRepository_Expecter{mock: &_m.Mock}
 }
 
-// CheckUserEligibility provides a mock function with given fields: ctx, userID
-func (_m *MockPurchaseRepository) CheckUserEligibility(ctx context.Context, userID uuid.UUID) error {
-	ret := _m.Called(ctx, userID)
+// CountCouponRedemptions provides a mock function with given fields: ctx, code, userID
+func (_m *MockPurchaseRepository) CountCouponRedemptions(ctx context.Context, code string, userID uuid.UUID) (*domain.CouponUsage, error) {
+	ret := _m.Called(ctx, code, userID)
+
+	var r0 *domain.CouponUsage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) (*domain.CouponUsage, error)); ok {
+		return rf(ctx, code, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, uuid.UUID) *domain.CouponUsage); ok {
+		r0 = rf(ctx, code, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.CouponUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, uuid.UUID) error); ok {
+		r1 = rf(ctx, code, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseRepository_CountCouponRedemptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountCouponRedemptions'
+type MockPurchaseRepository_CountCouponRedemptions_Call struct {
+	*mock.Call
+}
+
+// CountCouponRedemptions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - code string
+//   - userID uuid.UUID
+func (_e *MockPurchaseRepository_Expecter) CountCouponRedemptions(ctx interface{}, code interface{}, userID interface{}) *MockPurchaseRepository_CountCouponRedemptions_Call {
+	return &MockPurchaseRepository_CountCouponRedemptions_Call{Call: _e.mock.On("CountCouponRedemptions", ctx, code, userID)}
+}
+
+func (_c *MockPurchaseRepository_CountCouponRedemptions_Call) Run(run func(ctx context.Context, code string, userID uuid.UUID)) *MockPurchaseRepository_CountCouponRedemptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseRepository_CountCouponRedemptions_Call) Return(_a0 *domain.CouponUsage, _a1 error) *MockPurchaseRepository_CountCouponRedemptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseRepository_CountCouponRedemptions_Call) RunAndReturn(run func(context.Context, string, uuid.UUID) (*domain.CouponUsage, error)) *MockPurchaseRepository_CountCouponRedemptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateIdempotentPurchase provides a mock function with given fields: ctx, purchase, key
+func (_m *MockPurchaseRepository) CreateIdempotentPurchase(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey) error {
+	ret := _m.Called(ctx, purchase, key)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Purchase, domain.IdempotencyKey) error); ok {
+		r0 = rf(ctx, purchase, key)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -38,31 +95,32 @@ func (_m *MockPurchaseRepository) CheckUserEligibility(ctx context.Context, user
 	return r0
 }
 
-// MockPurchaseRepository_CheckUserEligibility_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckUserEligibility'
-type MockPurchaseRepository_CheckUserEligibility_Call struct {
+// MockPurchaseRepository_CreateIdempotentPurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateIdempotentPurchase'
+type MockPurchaseRepository_CreateIdempotentPurchase_Call struct {
 	*mock.Call
 }
 
-// CheckUserEligibility is a helper method to define mock.On call
+// CreateIdempotentPurchase is a helper method to define mock.On call
 //   - ctx context.Context
-//   - userID uuid.UUID
-func (_e *MockPurchaseRepository_Expecter) CheckUserEligibility(ctx interface{}, userID interface{}) *MockPurchaseRepository_CheckUserEligibility_Call {
-	return &MockPurchaseRepository_CheckUserEligibility_Call{Call: _e.mock.On("CheckUserEligibility", ctx, userID)}
+//   - purchase domain.Purchase
+//   - key domain.IdempotencyKey
+func (_e *MockPurchaseRepository_Expecter) CreateIdempotentPurchase(ctx interface{}, purchase interface{}, key interface{}) *MockPurchaseRepository_CreateIdempotentPurchase_Call {
+	return &MockPurchaseRepository_CreateIdempotentPurchase_Call{Call: _e.mock.On("CreateIdempotentPurchase", ctx, purchase, key)}
 }
 
-func (_c *MockPurchaseRepository_CheckUserEligibility_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *MockPurchaseRepository_CheckUserEligibility_Call {
+func (_c *MockPurchaseRepository_CreateIdempotentPurchase_Call) Run(run func(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey)) *MockPurchaseRepository_CreateIdempotentPurchase_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uuid.UUID))
+		run(args[0].(context.Context), args[1].(domain.Purchase), args[2].(domain.IdempotencyKey))
 	})
 	return _c
 }
 
-func (_c *MockPurchaseRepository_CheckUserEligibility_Call) Return(_a0 error) *MockPurchaseRepository_CheckUserEligibility_Call {
+func (_c *MockPurchaseRepository_CreateIdempotentPurchase_Call) Return(_a0 error) *MockPurchaseRepository_CreateIdempotentPurchase_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockPurchaseRepository_CheckUserEligibility_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *MockPurchaseRepository_CheckUserEligibility_Call {
+func (_c *MockPurchaseRepository_CreateIdempotentPurchase_Call) RunAndReturn(run func(context.Context, domain.Purchase, domain.IdempotencyKey) error) *MockPurchaseRepository_CreateIdempotentPurchase_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -110,6 +168,61 @@ func (_c *MockPurchaseRepository_CreatePurchase_Call) RunAndReturn(run func(cont
 	return _c
 }
 
+// FindDiscountByCouponCode provides a mock function with given fields: ctx, code
+func (_m *MockPurchaseRepository) FindDiscountByCouponCode(ctx context.Context, code string) (*domain.Discount, error) {
+	ret := _m.Called(ctx, code)
+
+	var r0 *domain.Discount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Discount, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Discount); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Discount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseRepository_FindDiscountByCouponCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindDiscountByCouponCode'
+type MockPurchaseRepository_FindDiscountByCouponCode_Call struct {
+	*mock.Call
+}
+
+// FindDiscountByCouponCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - code string
+func (_e *MockPurchaseRepository_Expecter) FindDiscountByCouponCode(ctx interface{}, code interface{}) *MockPurchaseRepository_FindDiscountByCouponCode_Call {
+	return &MockPurchaseRepository_FindDiscountByCouponCode_Call{Call: _e.mock.On("FindDiscountByCouponCode", ctx, code)}
+}
+
+func (_c *MockPurchaseRepository_FindDiscountByCouponCode_Call) Run(run func(ctx context.Context, code string)) *MockPurchaseRepository_FindDiscountByCouponCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindDiscountByCouponCode_Call) Return(_a0 *domain.Discount, _a1 error) *MockPurchaseRepository_FindDiscountByCouponCode_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindDiscountByCouponCode_Call) RunAndReturn(run func(context.Context, string) (*domain.Discount, error)) *MockPurchaseRepository_FindDiscountByCouponCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindProduct provides a mock function with given fields: ctx, productID
 func (_m *MockPurchaseRepository) FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error) {
 	ret := _m.Called(ctx, productID)
@@ -220,6 +333,174 @@ func (_c *MockPurchaseRepository_FindProductDiscount_Call) RunAndReturn(run func
 	return _c
 }
 
+// FindUser provides a mock function with given fields: ctx, userID
+func (_m *MockPurchaseRepository) FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseRepository_FindUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUser'
+type MockPurchaseRepository_FindUser_Call struct {
+	*mock.Call
+}
+
+// FindUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *MockPurchaseRepository_Expecter) FindUser(ctx interface{}, userID interface{}) *MockPurchaseRepository_FindUser_Call {
+	return &MockPurchaseRepository_FindUser_Call{Call: _e.mock.On("FindUser", ctx, userID)}
+}
+
+func (_c *MockPurchaseRepository_FindUser_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *MockPurchaseRepository_FindUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUser_Call) Return(_a0 *domain.User, _a1 error) *MockPurchaseRepository_FindUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUser_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.User, error)) *MockPurchaseRepository_FindUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindUserProductPurchases provides a mock function with given fields: ctx, userID, productID, since
+func (_m *MockPurchaseRepository) FindUserProductPurchases(ctx context.Context, userID uuid.UUID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error) {
+	ret := _m.Called(ctx, userID, productID, since)
+
+	var r0 []domain.PurchasedUnits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) ([]domain.PurchasedUnits, error)); ok {
+		return rf(ctx, userID, productID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) []domain.PurchasedUnits); ok {
+		r0 = rf(ctx, userID, productID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PurchasedUnits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, productID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseRepository_FindUserProductPurchases_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUserProductPurchases'
+type MockPurchaseRepository_FindUserProductPurchases_Call struct {
+	*mock.Call
+}
+
+// FindUserProductPurchases is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - productID uuid.UUID
+//   - since time.Time
+func (_e *MockPurchaseRepository_Expecter) FindUserProductPurchases(ctx interface{}, userID interface{}, productID interface{}, since interface{}) *MockPurchaseRepository_FindUserProductPurchases_Call {
+	return &MockPurchaseRepository_FindUserProductPurchases_Call{Call: _e.mock.On("FindUserProductPurchases", ctx, userID, productID, since)}
+}
+
+func (_c *MockPurchaseRepository_FindUserProductPurchases_Call) Run(run func(ctx context.Context, userID uuid.UUID, productID uuid.UUID, since time.Time)) *MockPurchaseRepository_FindUserProductPurchases_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(uuid.UUID), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUserProductPurchases_Call) Return(_a0 []domain.PurchasedUnits, _a1 error) *MockPurchaseRepository_FindUserProductPurchases_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUserProductPurchases_Call) RunAndReturn(run func(context.Context, uuid.UUID, uuid.UUID, time.Time) ([]domain.PurchasedUnits, error)) *MockPurchaseRepository_FindUserProductPurchases_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindUserPurchaseTimes provides a mock function with given fields: ctx, userID, since
+func (_m *MockPurchaseRepository) FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	ret := _m.Called(ctx, userID, since)
+
+	var r0 []time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) ([]time.Time, error)); ok {
+		return rf(ctx, userID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) []time.Time); ok {
+		r0 = rf(ctx, userID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockPurchaseRepository_FindUserPurchaseTimes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindUserPurchaseTimes'
+type MockPurchaseRepository_FindUserPurchaseTimes_Call struct {
+	*mock.Call
+}
+
+// FindUserPurchaseTimes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+//   - since time.Time
+func (_e *MockPurchaseRepository_Expecter) FindUserPurchaseTimes(ctx interface{}, userID interface{}, since interface{}) *MockPurchaseRepository_FindUserPurchaseTimes_Call {
+	return &MockPurchaseRepository_FindUserPurchaseTimes_Call{Call: _e.mock.On("FindUserPurchaseTimes", ctx, userID, since)}
+}
+
+func (_c *MockPurchaseRepository_FindUserPurchaseTimes_Call) Run(run func(ctx context.Context, userID uuid.UUID, since time.Time)) *MockPurchaseRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUserPurchaseTimes_Call) Return(_a0 []time.Time, _a1 error) *MockPurchaseRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockPurchaseRepository_FindUserPurchaseTimes_Call) RunAndReturn(run func(context.Context, uuid.UUID, time.Time) ([]time.Time, error)) *MockPurchaseRepository_FindUserPurchaseTimes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockPurchaseRepository creates a new instance of MockPurchaseRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockPurchaseRepository(t interface {
@@ -0,0 +1,193 @@
+// Code generated by mockery v2.32.0. DO NOT EDIT.
+
+package usecase
+
+import (
+	context "context"
+
+	domain "github.com/alextanhongpin/go-domain-test/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
+)
+
+// MockRefundRepository is an autogenerated mock type for the refundRepository type
+type MockRefundRepository struct {
+	mock.Mock
+}
+
+type MockRefundRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockRefundRepository) EXPECT() *MockRefundRepository_Expecter {
+	return &MockRefundRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateRefund provides a mock function with given fields: ctx, refund, purchase
+func (_m *MockRefundRepository) CreateRefund(ctx context.Context, refund domain.Refund, purchase domain.Purchase) error {
+	ret := _m.Called(ctx, refund, purchase)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Refund, domain.Purchase) error); ok {
+		r0 = rf(ctx, refund, purchase)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRefundRepository_CreateRefund_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRefund'
+type MockRefundRepository_CreateRefund_Call struct {
+	*mock.Call
+}
+
+// CreateRefund is a helper method to define mock.On call
+//   - ctx context.Context
+//   - refund domain.Refund
+//   - purchase domain.Purchase
+func (_e *MockRefundRepository_Expecter) CreateRefund(ctx interface{}, refund interface{}, purchase interface{}) *MockRefundRepository_CreateRefund_Call {
+	return &MockRefundRepository_CreateRefund_Call{Call: _e.mock.On("CreateRefund", ctx, refund, purchase)}
+}
+
+func (_c *MockRefundRepository_CreateRefund_Call) Run(run func(ctx context.Context, refund domain.Refund, purchase domain.Purchase)) *MockRefundRepository_CreateRefund_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.Refund), args[2].(domain.Purchase))
+	})
+	return _c
+}
+
+func (_c *MockRefundRepository_CreateRefund_Call) Return(_a0 error) *MockRefundRepository_CreateRefund_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRefundRepository_CreateRefund_Call) RunAndReturn(run func(context.Context, domain.Refund, domain.Purchase) error) *MockRefundRepository_CreateRefund_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindPurchase provides a mock function with given fields: ctx, id
+func (_m *MockRefundRepository) FindPurchase(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Purchase
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.Purchase, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.Purchase); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Purchase)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRefundRepository_FindPurchase_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindPurchase'
+type MockRefundRepository_FindPurchase_Call struct {
+	*mock.Call
+}
+
+// FindPurchase is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *MockRefundRepository_Expecter) FindPurchase(ctx interface{}, id interface{}) *MockRefundRepository_FindPurchase_Call {
+	return &MockRefundRepository_FindPurchase_Call{Call: _e.mock.On("FindPurchase", ctx, id)}
+}
+
+func (_c *MockRefundRepository_FindPurchase_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockRefundRepository_FindPurchase_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockRefundRepository_FindPurchase_Call) Return(_a0 *domain.Purchase, _a1 error) *MockRefundRepository_FindPurchase_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRefundRepository_FindPurchase_Call) RunAndReturn(run func(context.Context, uuid.UUID) (*domain.Purchase, error)) *MockRefundRepository_FindPurchase_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindRefunds provides a mock function with given fields: ctx, purchaseID
+func (_m *MockRefundRepository) FindRefunds(ctx context.Context, purchaseID uuid.UUID) ([]domain.Refund, error) {
+	ret := _m.Called(ctx, purchaseID)
+
+	var r0 []domain.Refund
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]domain.Refund, error)); ok {
+		return rf(ctx, purchaseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []domain.Refund); ok {
+		r0 = rf(ctx, purchaseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Refund)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, purchaseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRefundRepository_FindRefunds_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindRefunds'
+type MockRefundRepository_FindRefunds_Call struct {
+	*mock.Call
+}
+
+// FindRefunds is a helper method to define mock.On call
+//   - ctx context.Context
+//   - purchaseID uuid.UUID
+func (_e *MockRefundRepository_Expecter) FindRefunds(ctx interface{}, purchaseID interface{}) *MockRefundRepository_FindRefunds_Call {
+	return &MockRefundRepository_FindRefunds_Call{Call: _e.mock.On("FindRefunds", ctx, purchaseID)}
+}
+
+func (_c *MockRefundRepository_FindRefunds_Call) Run(run func(ctx context.Context, purchaseID uuid.UUID)) *MockRefundRepository_FindRefunds_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockRefundRepository_FindRefunds_Call) Return(_a0 []domain.Refund, _a1 error) *MockRefundRepository_FindRefunds_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRefundRepository_FindRefunds_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]domain.Refund, error)) *MockRefundRepository_FindRefunds_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockRefundRepository creates a new instance of MockRefundRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockRefundRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockRefundRepository {
+	mock := &MockRefundRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrMessageNotFound = errors.New("outbox: message not found")
+
+// InMemoryStore keeps the messages in memory, for tests and local
+// development.
+type InMemoryStore struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Add appends the messages. Repositories call it in the same critical section
+// as the aggregate changes.
+func (s *InMemoryStore) Add(ctx context.Context, msgs ...Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msgs = append(s.msgs, msgs...)
+
+	return nil
+}
+
+func (s *InMemoryStore) Undelivered(ctx context.Context, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []Message
+	for _, msg := range s.msgs {
+		if len(res) == limit {
+			break
+		}
+
+		if !msg.IsDelivered() && !msg.IsDead() {
+			res = append(res, msg)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *InMemoryStore) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return s.update(id, func(msg *Message) {
+		msg.DeliveredAt = &at
+	})
+}
+
+func (s *InMemoryStore) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	return s.update(id, func(msg *Message) {
+		msg.Attempts++
+		msg.LastError = reason
+	})
+}
+
+func (s *InMemoryStore) MarkDead(ctx context.Context, id uuid.UUID, reason string, at time.Time) error {
+	return s.update(id, func(msg *Message) {
+		msg.Attempts++
+		msg.LastError = reason
+		msg.DeadAt = &at
+	})
+}
+
+// Messages returns a copy of all messages.
+func (s *InMemoryStore) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msgs := make([]Message, len(s.msgs))
+	copy(msgs, s.msgs)
+
+	return msgs
+}
+
+func (s *InMemoryStore) update(id uuid.UUID, fn func(*Message)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.msgs {
+		if s.msgs[i].ID == id {
+			fn(&s.msgs[i])
+			return nil
+		}
+	}
+
+	return ErrMessageNotFound
+}
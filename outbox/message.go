@@ -0,0 +1,54 @@
+// Package outbox implements the transactional outbox. The repositories store
+// the domain events as messages in the same transaction as the aggregate, and
+// the Relay delivers them to the publisher afterwards.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	ID          uuid.UUID
+	Name        string          // The event name, e.g. purchase.created.
+	Payload     json.RawMessage // The event, encoded as JSON.
+	OccurredAt  time.Time
+	Attempts    int        // The number of failed deliveries.
+	LastError   string     // The error of the last failed delivery.
+	DeliveredAt *time.Time // Nil until the message is delivered.
+	DeadAt      *time.Time // Set when the message is given up on.
+}
+
+func (m Message) IsDelivered() bool {
+	return m.DeliveredAt != nil
+}
+
+// IsDead returns true if the message failed too many times, and is no longer
+// relayed.
+func (m Message) IsDead() bool {
+	return m.DeadAt != nil
+}
+
+// NewMessages encodes the events as messages, in the same order.
+func NewMessages(events ...domain.Event) ([]Message, error) {
+	msgs := make([]Message, len(events))
+	for i, evt := range events {
+		b, err := json.Marshal(evt)
+		if err != nil {
+			return nil, fmt.Errorf("outbox: encode %s: %w", evt.EventName(), err)
+		}
+
+		msgs[i] = Message{
+			ID:         uuid.New(),
+			Name:       evt.EventName(),
+			Payload:    b,
+			OccurredAt: evt.OccurredAt(),
+		}
+	}
+
+	return msgs, nil
+}
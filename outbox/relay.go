@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store reads and updates the messages written by the repositories.
+type Store interface {
+	// Undelivered returns up to limit messages that are neither delivered
+	// nor dead, oldest first.
+	Undelivered(ctx context.Context, limit int) ([]Message, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error
+	// MarkFailed increments the attempts of the message, and records the
+	// reason.
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+	// MarkDead increments the attempts of the message, records the reason,
+	// and stops the message from being relayed. The message is kept for
+	// inspection.
+	MarkDead(ctx context.Context, id uuid.UUID, reason string, at time.Time) error
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Relay delivers the undelivered messages to the publisher. Delivery is at
+// least once, so the consumers must handle duplicates.
+type Relay struct {
+	store       Store
+	publisher   Publisher
+	batchSize   int
+	maxAttempts int
+	maxFailures int
+	backoff     func(attempt int) time.Duration
+	onError     func(error)
+}
+
+type RelayOption func(*Relay)
+
+// WithBatchSize sets the number of messages read on each run. Defaults to 100.
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+// WithMaxAttempts sets the number of attempts to publish a message on each
+// run, before giving up until the next run. Defaults to 3.
+func WithMaxAttempts(n int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = n
+	}
+}
+
+// WithMaxFailures sets the number of runs that a message can fail in, before
+// it is dead-lettered and skipped, so that it does not hold back the later
+// messages forever. Defaults to 10.
+func WithMaxFailures(n int) RelayOption {
+	return func(r *Relay) {
+		r.maxFailures = n
+	}
+}
+
+// WithBackoff sets the wait before each retry, by attempt starting from 1.
+// Defaults to ExponentialBackoff(100*time.Millisecond, 5*time.Second).
+func WithBackoff(backoff func(attempt int) time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.backoff = backoff
+	}
+}
+
+// WithErrorHandler sets the handler for the errors that Run retries on the
+// next tick.
+func WithErrorHandler(fn func(error)) RelayOption {
+	return func(r *Relay) {
+		r.onError = fn
+	}
+}
+
+func NewRelay(store Store, publisher Publisher, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:       store,
+		publisher:   publisher,
+		batchSize:   100,
+		maxAttempts: 3,
+		maxFailures: 10,
+		backoff:     ExponentialBackoff(100*time.Millisecond, 5*time.Second),
+		onError:     func(error) {},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// ExponentialBackoff doubles the wait on every attempt, up to maxWait.
+func ExponentialBackoff(base, maxWait time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < maxWait; i++ {
+			d *= 2
+		}
+
+		if d > maxWait {
+			return maxWait
+		}
+
+		return d
+	}
+}
+
+// Run relays the messages on every interval, until the context is done.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil {
+			r.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// RelayOnce delivers one batch of messages, and returns the number of
+// delivered messages. It stops at the first message that cannot be
+// delivered, so that the messages are published in order. A message that
+// fails in as many runs as the max failures is dead-lettered instead, and
+// the later messages are still delivered.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	msgs, err := r.store.Undelivered(ctx, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("store.Undelivered: %w", err)
+	}
+
+	var (
+		n    int
+		dead []error
+	)
+	for _, msg := range msgs {
+		if err := r.publish(ctx, msg); err != nil {
+			if msg.Attempts+1 >= r.maxFailures {
+				if markErr := r.store.MarkDead(ctx, msg.ID, err.Error(), time.Now()); markErr != nil {
+					return n, fmt.Errorf("store.MarkDead: %w", markErr)
+				}
+
+				dead = append(dead, fmt.Errorf("outbox: dead-letter %s: %w", msg.ID, err))
+				continue
+			}
+
+			if markErr := r.store.MarkFailed(ctx, msg.ID, err.Error()); markErr != nil {
+				return n, fmt.Errorf("store.MarkFailed: %w", markErr)
+			}
+
+			return n, errors.Join(append(dead, fmt.Errorf("outbox: publish %s: %w", msg.ID, err))...)
+		}
+
+		if err := r.store.MarkDelivered(ctx, msg.ID, time.Now()); err != nil {
+			return n, fmt.Errorf("store.MarkDelivered: %w", err)
+		}
+		n++
+	}
+
+	return n, errors.Join(dead...)
+}
+
+func (r *Relay) publish(ctx context.Context, msg Message) error {
+	var err error
+	for attempt := 1; attempt <= r.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.backoff(attempt - 1)):
+			}
+		}
+
+		if err = r.publisher.Publish(ctx, msg); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,246 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var errPublish = errors.New("publish error")
+
+func TestNewMessages(t *testing.T) {
+	evt := domain.PurchaseStatusChanged{
+		PurchaseID: uuid.New(),
+		From:       domain.PurchaseStatusPending,
+		To:         domain.PurchaseStatusPaid,
+		At:         time.Now(),
+	}
+
+	msgs, err := outbox.NewMessages(evt)
+
+	as := assert.New(t)
+	as.Nil(err)
+	if as.Len(msgs, 1) {
+		as.Equal("purchase.status_changed", msgs[0].Name)
+		as.Equal(evt.At, msgs[0].OccurredAt)
+		as.Contains(string(msgs[0].Payload), `"To":"paid"`)
+		as.False(msgs[0].IsDelivered())
+	}
+}
+
+func TestRelay(t *testing.T) {
+	t.Run("delivers in order", func(t *testing.T) {
+		f := newRelayFlow(3)
+		n, err := f.relay().RelayOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(3, n)
+		as.Equal(f.ids(), f.publisher.published())
+		for _, msg := range f.store.Messages() {
+			as.True(msg.IsDelivered())
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		f := newRelayFlow(1)
+		f.publisher.failures = 2
+		n, err := f.relay().RelayOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(1, n)
+		as.Equal(3, f.publisher.calls)
+		as.Equal([]time.Duration{1, 2}, f.waits)
+		as.True(f.store.Messages()[0].IsDelivered())
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		f := newRelayFlow(2)
+		f.publisher.failures = 5
+		n, err := f.relay().RelayOnce(context.Background())
+
+		as := assert.New(t)
+		as.ErrorIs(err, errPublish)
+		as.Equal(0, n)
+		as.Equal(3, f.publisher.calls)
+
+		// The next message is not published, to keep the order.
+		msgs := f.store.Messages()
+		as.False(msgs[0].IsDelivered())
+		as.Equal(1, msgs[0].Attempts)
+		as.Equal(errPublish.Error(), msgs[0].LastError)
+		as.False(msgs[1].IsDelivered())
+		as.Equal(0, msgs[1].Attempts)
+
+		// Delivered on the next run.
+		n, err = f.relay().RelayOnce(context.Background())
+		as.Nil(err)
+		as.Equal(2, n)
+		as.Equal(f.ids(), f.publisher.published())
+	})
+
+	t.Run("dead-letters after max failures", func(t *testing.T) {
+		f := newRelayFlow(2)
+		f.publisher.failures = 2
+		relay := f.relay(outbox.WithMaxAttempts(1), outbox.WithMaxFailures(2))
+
+		as := assert.New(t)
+		n, err := relay.RelayOnce(context.Background())
+		as.ErrorIs(err, errPublish)
+		as.Equal(0, n)
+
+		// The message is given up on, and the next message is delivered.
+		n, err = relay.RelayOnce(context.Background())
+		as.ErrorIs(err, errPublish)
+		as.Equal(1, n)
+		as.Equal(f.ids()[1:], f.publisher.published())
+
+		msgs := f.store.Messages()
+		as.True(msgs[0].IsDead())
+		as.Equal(2, msgs[0].Attempts)
+		as.True(msgs[1].IsDelivered())
+
+		n, err = relay.RelayOnce(context.Background())
+		as.Nil(err)
+		as.Equal(0, n)
+	})
+
+	t.Run("skips delivered", func(t *testing.T) {
+		f := newRelayFlow(2)
+		ctx := context.Background()
+		as := assert.New(t)
+		as.Nil(f.store.MarkDelivered(ctx, f.ids()[0], time.Now()))
+
+		n, err := f.relay().RelayOnce(ctx)
+		as.Nil(err)
+		as.Equal(1, n)
+		as.Equal(f.ids()[1:], f.publisher.published())
+	})
+
+	t.Run("batch size", func(t *testing.T) {
+		f := newRelayFlow(3)
+		n, err := f.relay(outbox.WithBatchSize(2)).RelayOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(2, n)
+		as.Equal(f.ids()[:2], f.publisher.published())
+	})
+
+	t.Run("run until cancelled", func(t *testing.T) {
+		f := newRelayFlow(2)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error)
+		go func() {
+			done <- f.relay().Run(ctx, time.Millisecond)
+		}()
+
+		as := assert.New(t)
+		as.Eventually(func() bool {
+			return len(f.publisher.published()) == 2
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		as.ErrorIs(<-done, context.Canceled)
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := outbox.ExponentialBackoff(time.Second, 5*time.Second)
+
+	as := assert.New(t)
+	as.Equal(time.Second, backoff(1))
+	as.Equal(2*time.Second, backoff(2))
+	as.Equal(4*time.Second, backoff(3))
+	as.Equal(5*time.Second, backoff(4))
+	as.Equal(5*time.Second, backoff(100))
+}
+
+type relayFlow struct {
+	store     *outbox.InMemoryStore
+	publisher *fakePublisher
+	msgs      []outbox.Message
+	waits     []time.Duration
+}
+
+func newRelayFlow(n int) *relayFlow {
+	f := new(relayFlow)
+	f.store = outbox.NewInMemoryStore()
+	f.publisher = new(fakePublisher)
+
+	for i := 0; i < n; i++ {
+		msgs, err := outbox.NewMessages(domain.PurchaseCreated{
+			PurchaseID: uuid.New(),
+			At:         time.Now(),
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		f.msgs = append(f.msgs, msgs...)
+	}
+
+	if err := f.store.Add(context.Background(), f.msgs...); err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+func (f *relayFlow) relay(opts ...outbox.RelayOption) *outbox.Relay {
+	opts = append([]outbox.RelayOption{
+		outbox.WithBackoff(func(attempt int) time.Duration {
+			f.waits = append(f.waits, time.Duration(attempt))
+			return time.Duration(attempt)
+		}),
+	}, opts...)
+
+	return outbox.NewRelay(f.store, f.publisher, opts...)
+}
+
+func (f *relayFlow) ids() []uuid.UUID {
+	ids := make([]uuid.UUID, len(f.msgs))
+	for i, msg := range f.msgs {
+		ids[i] = msg.ID
+	}
+
+	return ids
+}
+
+// fakePublisher fails the first failures calls.
+type fakePublisher struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	ids      []uuid.UUID
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, msg outbox.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if p.calls <= p.failures {
+		return errPublish
+	}
+
+	p.ids = append(p.ids, msg.ID)
+
+	return nil
+}
+
+func (p *fakePublisher) published() []uuid.UUID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]uuid.UUID(nil), p.ids...)
+}
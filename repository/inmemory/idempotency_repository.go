@@ -0,0 +1,73 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyID scopes the key to the user.
+type idempotencyKeyID struct {
+	userID uuid.UUID
+	key    string
+}
+
+func newIdempotencyKeyID(key domain.IdempotencyKey) idempotencyKeyID {
+	return idempotencyKeyID{
+		userID: key.UserID,
+		key:    key.Key,
+	}
+}
+
+type IdempotencyRepository struct {
+	store *Store
+}
+
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		store: store,
+	}
+}
+
+// LockIdempotencyKey claims the key of the user. A key that is expired at
+// the creation time of the given key is claimed again.
+func (r *IdempotencyRepository) LockIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := newIdempotencyKeyID(key)
+	if existing, ok := s.keys[id]; ok && !existing.IsExpired(key.CreatedAt) {
+		if existing.Purchase != nil {
+			p := copyPurchase(*existing.Purchase)
+			existing.Purchase = &p
+		}
+
+		return &existing, false, nil
+	}
+
+	s.keys[id] = key
+
+	return &key, true, nil
+}
+
+// DeleteIdempotencyKey releases the key, unless the claim has expired and
+// the key has been claimed again since.
+func (r *IdempotencyRepository) DeleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := newIdempotencyKeyID(key)
+	if k, ok := s.keys[id]; ok && isClaim(k, key) {
+		delete(s.keys, id)
+	}
+
+	return nil
+}
+
+// isClaim returns true if the stored key is still in progress for the claim.
+func isClaim(stored, claim domain.IdempotencyKey) bool {
+	return !stored.IsCompleted() && stored.CreatedAt.Equal(claim.CreatedAt)
+}
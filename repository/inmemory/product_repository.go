@@ -0,0 +1,246 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+type ProductRepository struct {
+	store *Store
+}
+
+func NewProductRepository(store *Store) *ProductRepository {
+	return &ProductRepository{
+		store: store,
+	}
+}
+
+// FindByID returns usecase.ErrProductNotFound if the product does not exist.
+// Soft deleted products are returned.
+func (r *ProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	return r.store.findProduct(id)
+}
+
+// Create creates an unpublished product without a price.
+func (r *ProductRepository) Create(ctx context.Context, name string, userID uuid.UUID) (*domain.Product, error) {
+	p := domain.Product{
+		ID:     uuid.New(),
+		Name:   domain.ProductName(name),
+		UserID: userID,
+	}
+
+	s := r.store
+	s.mu.Lock()
+	s.products[p.ID] = p
+	s.mu.Unlock()
+
+	return &p, nil
+}
+
+// Update returns usecase.ErrProductVersionConflict if the stored version does
+// not match, and usecase.ErrProductNotFound if the product does not exist.
+func (r *ProductRepository) Update(ctx context.Context, p domain.Product, version int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.products[p.ID]
+	if !ok {
+		return usecase.ErrProductNotFound
+	}
+
+	if stored.Version != version {
+		return usecase.ErrProductVersionConflict
+	}
+
+	s.products[p.ID] = copyProduct(p)
+
+	return nil
+}
+
+// FindScheduled returns the products that are published or expire within
+// (from, to], excluding the deleted products.
+func (r *ProductRepository) FindScheduled(ctx context.Context, from, to time.Time) ([]domain.Product, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	within := func(t *time.Time) bool {
+		return t != nil && t.After(from) && !t.After(to)
+	}
+
+	var products []domain.Product
+	for _, p := range s.products {
+		if p.IsDeleted() {
+			continue
+		}
+
+		if within(p.PublishedAt) || within(p.UnpublishAt) {
+			products = append(products, copyProduct(p))
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID.String() < products[j].ID.String()
+	})
+
+	return products, nil
+}
+
+// FindScheduleWatermark returns the time up to which the transitions were
+// published, and false when none is stored.
+func (r *ProductRepository) FindScheduleWatermark(ctx context.Context) (time.Time, bool, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.scheduleWatermark, !s.scheduleWatermark.IsZero(), nil
+}
+
+func (r *ProductRepository) SaveScheduleWatermark(ctx context.Context, since time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scheduleWatermark = since
+
+	return nil
+}
+
+// PurgeDeleted hard deletes the products that were deleted before the time,
+// together with their discounts and inventories.
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := make(map[uuid.UUID]bool)
+	for id, p := range s.products {
+		if p.IsDeleted() && p.DeletedAt.Before(before) {
+			delete(s.products, id)
+			delete(s.inventories, id)
+			purged[id] = true
+		}
+	}
+
+	discounts := s.discounts[:0]
+	for _, d := range s.discounts {
+		if !purged[d.ProductID] {
+			discounts = append(discounts, d)
+		}
+	}
+	s.discounts = discounts
+
+	return len(purged), nil
+}
+
+// List returns the products matching the query.
+func (r *ProductRepository) List(ctx context.Context, q usecase.ProductQuery) ([]domain.Product, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var products []domain.Product
+	for _, p := range s.products {
+		if matchProduct(p, q) {
+			products = append(products, copyProduct(p))
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return lessProduct(q.Sort, products[i], products[j])
+	})
+
+	if len(products) > q.Limit {
+		products = products[:q.Limit]
+	}
+
+	return products, nil
+}
+
+func matchProduct(p domain.Product, q usecase.ProductQuery) bool {
+	if p.IsDeleted() {
+		return false
+	}
+
+	if !p.IsPublishedAt(q.At) && p.UserID != q.VisibleTo {
+		return false
+	}
+
+	if q.OwnerID != nil && p.UserID != *q.OwnerID {
+		return false
+	}
+
+	if q.Published != nil && p.IsPublishedAt(q.At) != *q.Published {
+		return false
+	}
+
+	if m := q.MinPrice; m != nil && (p.Price.Currency != m.Currency || p.Price.Amount < m.Amount) {
+		return false
+	}
+
+	if m := q.MaxPrice; m != nil && (p.Price.Currency != m.Currency || p.Price.Amount > m.Amount) {
+		return false
+	}
+
+	if !strings.HasPrefix(string(p.Name), q.NamePrefix) {
+		return false
+	}
+
+	if a := q.After; a != nil {
+		after := domain.Product{
+			ID:    a.ID,
+			Name:  domain.ProductName(a.Name),
+			Price: domain.NewMoney(a.Price, a.Currency),
+		}
+
+		return lessProduct(q.Sort, after, p)
+	}
+
+	return true
+}
+
+// lessProduct returns true if a is sorted before b.
+func lessProduct(sort usecase.ProductSort, a, b domain.Product) bool {
+	isPrice := sort == usecase.ProductSortPriceAsc || sort == usecase.ProductSortPriceDesc
+	if isPrice && a.Price.Currency != b.Price.Currency {
+		return a.Price.Currency < b.Price.Currency
+	}
+
+	switch sort {
+	case usecase.ProductSortPriceAsc:
+		if a.Price.Amount != b.Price.Amount {
+			return a.Price.Amount < b.Price.Amount
+		}
+	case usecase.ProductSortPriceDesc:
+		if a.Price.Amount != b.Price.Amount {
+			return a.Price.Amount > b.Price.Amount
+		}
+	default:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	}
+
+	return a.ID.String() < b.ID.String()
+}
+
+func (s *Store) findProduct(id uuid.UUID) (*domain.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.products[id]
+	if !ok {
+		return nil, usecase.ErrProductNotFound
+	}
+
+	p = copyProduct(p)
+
+	return &p, nil
+}
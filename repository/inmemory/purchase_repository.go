@@ -0,0 +1,383 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+type PurchaseRepository struct {
+	store *Store
+}
+
+func NewPurchaseRepository(store *Store) *PurchaseRepository {
+	return &PurchaseRepository{
+		store: store,
+	}
+}
+
+// FindUser returns usecase.ErrUserIneligible if the user is not one of the
+// configured users.
+func (r *PurchaseRepository) FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, usecase.ErrUserIneligible
+	}
+
+	return &u, nil
+}
+
+// FindUserPurchaseTimes returns the creation times of the purchases by the
+// user after the time, excluding the cancelled purchases.
+func (r *PurchaseRepository) FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var times []time.Time
+	for _, p := range s.purchases {
+		if p.UserID != userID || p.Status == domain.PurchaseStatusCancelled || !p.CreatedAt.After(since) {
+			continue
+		}
+
+		times = append(times, p.CreatedAt)
+	}
+
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	return times, nil
+}
+
+// FindUserProductPurchases returns the units of the product bought by the
+// user after the time, excluding the cancelled purchases.
+func (r *PurchaseRepository) FindUserProductPurchases(ctx context.Context, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.userProductPurchases(userID, productID, since), nil
+}
+
+// FindProduct returns usecase.ErrProductNotFound if the product does not
+// exist.
+func (r *PurchaseRepository) FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error) {
+	return r.store.findProduct(productID)
+}
+
+func (r *PurchaseRepository) FindProductDiscount(ctx context.Context, productID uuid.UUID) ([]domain.Discount, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ds []domain.Discount
+	for _, d := range s.discounts {
+		if d.ProductID == productID {
+			ds = append(ds, copyDiscount(d))
+		}
+	}
+
+	return ds, nil
+}
+
+// FindDiscountByCouponCode returns usecase.ErrCouponUnknown if the code does
+// not exist.
+func (r *PurchaseRepository) FindDiscountByCouponCode(ctx context.Context, code string) (*domain.Discount, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, d := range s.discounts {
+		if d.Coupon != nil && d.Coupon.Code == code {
+			d = copyDiscount(d)
+			return &d, nil
+		}
+	}
+
+	return nil, usecase.ErrCouponUnknown
+}
+
+func (r *PurchaseRepository) CountCouponRedemptions(ctx context.Context, code string, userID uuid.UUID) (*domain.CouponUsage, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := s.couponUsage(code, userID)
+
+	return &usage, nil
+}
+
+// FindPurchase returns usecase.ErrPurchaseNotFound if the purchase does not
+// exist.
+func (r *PurchaseRepository) FindPurchase(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.purchases[id]
+	if !ok {
+		return nil, usecase.ErrPurchaseNotFound
+	}
+
+	p = copyPurchase(p)
+
+	return &p, nil
+}
+
+// UpdatePurchase stores the purchase status and the purchase events at once.
+// It returns usecase.ErrPurchaseStatusInvalid if the stored status is no
+// longer the status before the transition.
+func (r *PurchaseRepository) UpdatePurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.purchases[purchase.ID]
+	if !ok {
+		return usecase.ErrPurchaseNotFound
+	}
+
+	if stored.Status != purchase.PreviousStatus() {
+		return usecase.ErrPurchaseStatusInvalid
+	}
+
+	s.purchases[purchase.ID] = copyPurchase(purchase)
+
+	return s.outbox.Add(ctx, msgs...)
+}
+
+func (r *PurchaseRepository) FindRefunds(ctx context.Context, purchaseID uuid.UUID) ([]domain.Refund, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]domain.Refund(nil), s.refunds[purchaseID]...), nil
+}
+
+// CreateRefund stores the refund, the purchase status and the purchase events
+// at once. It returns usecase.ErrRefundExceedsPaid if the refunds total would
+// exceed the purchase total, which is checked again under the lock, so
+// concurrent refunds cannot over-refund. It returns
+// usecase.ErrPurchaseStatusInvalid if the stored status is no longer the
+// status before the refund, or if the status does not match the refunds
+// total.
+func (r *PurchaseRepository) CreateRefund(ctx context.Context, refund domain.Refund, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.purchases[purchase.ID]
+	if !ok {
+		return usecase.ErrPurchaseNotFound
+	}
+
+	refundable, err := stored.RefundableAmount(s.refunds[purchase.ID])
+	if err != nil {
+		return err
+	}
+
+	if refund.Amount.Amount > refundable.Amount {
+		return usecase.ErrRefundExceedsPaid
+	}
+
+	// The status must follow the stored status and match the refunds total,
+	// so that a concurrent refund cannot be overwritten.
+	complete := refund.Amount.Amount == refundable.Amount
+	if stored.Status != purchase.PreviousStatus() || complete != (purchase.Status == domain.PurchaseStatusRefunded) {
+		return usecase.ErrPurchaseStatusInvalid
+	}
+
+	s.refunds[purchase.ID] = append(s.refunds[purchase.ID], refund)
+	s.purchases[purchase.ID] = copyPurchase(purchase)
+
+	return s.outbox.Add(ctx, msgs...)
+}
+
+// PayPurchase stores the paid purchase, removes its reserved units from the
+// stock and stores the purchase events at once. It returns
+// usecase.ErrPurchaseStatusInvalid if the stored purchase is not pending.
+func (r *PurchaseRepository) PayPurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return r.updatePending(ctx, purchase, (*domain.Inventory).Commit, msgs...)
+}
+
+// CancelPurchase stores the cancelled purchase, releases its reserved units
+// and stores the purchase events at once. It returns
+// usecase.ErrPurchaseStatusInvalid if the stored purchase is not pending.
+func (r *PurchaseRepository) CancelPurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return r.updatePending(ctx, purchase, (*domain.Inventory).Release, msgs...)
+}
+
+// updatePending stores the purchase only if the stored purchase is still
+// pending, after applying the change to the inventory of the product.
+func (r *PurchaseRepository) updatePending(ctx context.Context, purchase domain.Purchase, change func(*domain.Inventory, int) error, msgs ...outbox.Message) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.purchases[purchase.ID]
+	if !ok {
+		return usecase.ErrPurchaseNotFound
+	}
+
+	if stored.Status != domain.PurchaseStatusPending {
+		return usecase.ErrPurchaseStatusInvalid
+	}
+
+	inv := s.inventories[purchase.ProductID]
+	if err := change(&inv, purchase.Unit); err != nil {
+		return err
+	}
+
+	s.inventories[purchase.ProductID] = inv
+	s.purchases[purchase.ID] = copyPurchase(purchase)
+
+	return s.outbox.Add(ctx, msgs...)
+}
+
+// CreatePurchase reserves the stock, and persists the purchase, the coupon
+// redemptions and the purchase events at once. It returns
+// usecase.ErrProductOutOfStock if there is not enough stock left,
+// usecase.ErrCouponExhausted if a coupon has been redeemed up to its caps
+// since it was checked, and domain.ErrPurchaseLimitExceeded if the purchase
+// limits of the product are exceeded.
+func (r *PurchaseRepository) CreatePurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, err := s.checkPurchase(purchase)
+	if err != nil {
+		return err
+	}
+
+	return s.createPurchase(ctx, purchase, inv, msgs)
+}
+
+// CreateIdempotentPurchase creates the purchase like CreatePurchase, and
+// stores it on the claimed key at once. It returns
+// usecase.ErrIdempotencyKeyExpired if the claim has expired and the key has
+// been claimed again since.
+func (r *PurchaseRepository) CreateIdempotentPurchase(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := newIdempotencyKeyID(key)
+	k, ok := s.keys[id]
+	if !ok || !isClaim(k, key) {
+		return usecase.ErrIdempotencyKeyExpired
+	}
+
+	inv, err := s.checkPurchase(purchase)
+	if err != nil {
+		return err
+	}
+
+	p := copyPurchase(purchase)
+	k.Purchase = &p
+	s.keys[id] = k
+
+	return s.createPurchase(ctx, purchase, inv, msgs)
+}
+
+// CreateOrder persists the order lines, reserves the stock for every line,
+// and stores the coupon redemptions and the purchase events at once. Nothing
+// is persisted when any line fails, e.g. with usecase.ErrProductOutOfStock or
+// domain.ErrPurchaseLimitExceeded.
+func (r *PurchaseRepository) CreateOrder(ctx context.Context, order domain.Order) error {
+	var events []domain.Event
+	for _, line := range order.Lines {
+		events = append(events, line.Events()...)
+	}
+
+	msgs, err := outbox.NewMessages(events...)
+	if err != nil {
+		return err
+	}
+
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The changes are staged, and only stored once every line succeeds.
+	inventories := make(map[uuid.UUID]domain.Inventory)
+	redemptions := append([]couponRedemption(nil), s.redemptions...)
+	for _, line := range order.Lines {
+		if _, ok := s.purchases[line.ID]; ok {
+			return ErrPurchaseExists
+		}
+
+		if err := s.checkPurchaseLimits(line); err != nil {
+			return err
+		}
+
+		inv, err := s.reserveStock(line.ProductID, line.Unit)
+		if err != nil {
+			return err
+		}
+		inventories[line.ProductID] = inv
+
+		for _, code := range line.CouponCodes {
+			c, ok := s.findCoupon(code)
+			if !ok || !c.CanRedeem(couponUsage(redemptions, code, line.UserID)) {
+				return usecase.ErrCouponExhausted
+			}
+
+			redemptions = append(redemptions, couponRedemption{
+				code:   code,
+				userID: line.UserID,
+			})
+		}
+	}
+
+	for productID, inv := range inventories {
+		s.inventories[productID] = inv
+	}
+
+	for _, line := range order.Lines {
+		s.purchases[line.ID] = copyPurchase(line)
+	}
+	s.redemptions = redemptions
+
+	return s.outbox.Add(ctx, msgs...)
+}
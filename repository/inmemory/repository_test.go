@@ -0,0 +1,819 @@
+package inmemory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/errors/causes"
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/repository/inmemory"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductUsecase(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewStore()
+	uc := usecase.NewProduct(inmemory.NewProductRepository(store), event.NewInMemoryPublisher())
+	userID := uuid.New()
+
+	as := assert.New(t)
+	p, err := uc.Create(ctx, usecase.CreateProductDto{
+		Name:   "colorful socks",
+		UserID: userID,
+	})
+	as.Nil(err)
+
+	// Products are created unpublished.
+	_, err = uc.View(ctx, p.ID)
+	as.ErrorIs(err, usecase.ErrProductNotFound)
+
+	dto := usecase.UpdateProductDto{
+		ID:      p.ID,
+		UserID:  userID,
+		Name:    "striped socks",
+		Price:   domain.NewMoney(12, "MYR"),
+		Version: p.Version,
+	}
+	updated, err := uc.Update(ctx, dto)
+	as.Nil(err)
+	as.Equal(1, updated.Version)
+
+	// The second edit is based on a stale version.
+	_, err = uc.Update(ctx, dto)
+	as.ErrorIs(err, usecase.ErrProductVersionConflict)
+
+	as.ErrorIs(uc.Delete(ctx, p.ID, uuid.New()), usecase.ErrProductUnauthorized)
+	as.Nil(uc.Delete(ctx, p.ID, userID))
+	as.ErrorIs(uc.Delete(ctx, p.ID, userID), usecase.ErrProductNotFound)
+}
+
+func TestProductUsecaseList(t *testing.T) {
+	ctx := context.Background()
+	socks := factories.NewProduct("published")
+	shirt := factories.NewProduct("published")
+	shirt.Name = "shirt"
+	shirt.Price = domain.NewMoney(5, "MYR")
+	draft := factories.NewProduct("no_published_at")
+	draft.UserID = uuid.New()
+	deleted := factories.NewProduct("published", "deleted")
+	hat := factories.NewProduct("published")
+	hat.Name = "usb hat"
+	hat.Price = domain.NewMoney(1, "USD")
+
+	store := inmemory.NewStore(inmemory.WithProducts(socks, shirt, draft, deleted, hat))
+	uc := usecase.NewProduct(inmemory.NewProductRepository(store), event.NewInMemoryPublisher())
+
+	list := func(dto usecase.ListProductsDto) []uuid.UUID {
+		t.Helper()
+
+		// Collects all the pages.
+		var ids []uuid.UUID
+		for {
+			page, err := uc.List(ctx, dto)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range page.Products {
+				ids = append(ids, p.ID)
+			}
+
+			if page.NextCursor == "" {
+				return ids
+			}
+
+			dto.Cursor = page.NextCursor
+		}
+	}
+
+	as := assert.New(t)
+	as.Equal([]uuid.UUID{socks.ID, shirt.ID, hat.ID}, list(usecase.ListProductsDto{Limit: 1}))
+
+	// The prices are sorted by currency first.
+	as.Equal([]uuid.UUID{shirt.ID, socks.ID, hat.ID}, list(usecase.ListProductsDto{Sort: usecase.ProductSortPriceAsc, Limit: 1}))
+	as.Equal([]uuid.UUID{socks.ID, shirt.ID, hat.ID}, list(usecase.ListProductsDto{Sort: usecase.ProductSortPriceDesc, Limit: 1}))
+
+	// The unpublished products are only listed for the owner.
+	as.Len(list(usecase.ListProductsDto{UserID: draft.UserID}), 4)
+	as.Equal([]uuid.UUID{draft.ID}, list(usecase.ListProductsDto{
+		UserID: draft.UserID,
+		Filter: usecase.ProductFilter{OwnerID: &draft.UserID},
+	}))
+}
+
+func TestProductPurger(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	repo := inmemory.NewProductRepository(inmemory.NewStore())
+	uc := usecase.NewProduct(repo, event.NewInMemoryPublisher(), usecase.WithRestoreGracePeriod(time.Hour), usecase.WithProductClock(clock))
+	purger := usecase.NewProductPurger(repo, usecase.WithRetention(2*time.Hour), usecase.WithPurgerClock(clock))
+	userID := uuid.New()
+
+	as := assert.New(t)
+	p, err := uc.Create(ctx, usecase.CreateProductDto{
+		Name:   "colorful socks",
+		UserID: userID,
+	})
+	as.Nil(err)
+
+	as.Nil(uc.Delete(ctx, p.ID, userID))
+	_, err = uc.Restore(ctx, p.ID, userID)
+	as.Nil(err)
+
+	_, err = uc.Restore(ctx, p.ID, userID)
+	as.ErrorIs(err, usecase.ErrProductNotDeleted)
+
+	as.Nil(uc.Delete(ctx, p.ID, userID))
+
+	now = now.Add(time.Hour + time.Second)
+	_, err = uc.Restore(ctx, p.ID, userID)
+	as.ErrorIs(err, usecase.ErrProductRestoreExpired)
+
+	n, err := purger.PurgeOnce(ctx)
+	as.Nil(err)
+	as.Equal(0, n)
+
+	now = now.Add(time.Hour)
+	n, err = purger.PurgeOnce(ctx)
+	as.Nil(err)
+	as.Equal(1, n)
+
+	_, err = uc.Restore(ctx, p.ID, userID)
+	as.ErrorIs(err, usecase.ErrProductNotFound)
+}
+
+func TestProductRepositoryPurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+
+	deleted := factories.NewProduct("deleted")
+	kept := factories.NewProduct()
+	discounts := make([]*domain.Discount, 2)
+	for i, p := range []*domain.Product{deleted, kept} {
+		discounts[i] = factories.NewDiscount()
+		discounts[i].ProductID = p.ID
+	}
+
+	store := inmemory.NewStore(
+		inmemory.WithProducts(deleted, kept),
+		inmemory.WithDiscounts(discounts...),
+		inmemory.WithStock(deleted.ID, 5),
+		inmemory.WithStock(kept.ID, 5),
+	)
+	purchases := inmemory.NewPurchaseRepository(store)
+
+	as := assert.New(t)
+	n, err := inmemory.NewProductRepository(store).PurgeDeleted(ctx, time.Now())
+	as.Nil(err)
+	as.Equal(1, n)
+
+	// The discounts and inventories of the product are purged too.
+	ds, err := purchases.FindProductDiscount(ctx, deleted.ID)
+	as.Nil(err)
+	as.Empty(ds)
+
+	_, ok := store.Inventory(deleted.ID)
+	as.False(ok)
+
+	ds, err = purchases.FindProductDiscount(ctx, kept.ID)
+	as.Nil(err)
+	as.Len(ds, 1)
+
+	_, ok = store.Inventory(kept.ID)
+	as.True(ok)
+}
+
+func TestProductScheduler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	store := inmemory.NewStore()
+	repo := inmemory.NewProductRepository(store)
+	uc := usecase.NewProduct(repo, event.NewInMemoryPublisher(), usecase.WithProductClock(clock))
+	userID := uuid.New()
+
+	as := assert.New(t)
+	p, err := uc.Create(ctx, usecase.CreateProductDto{
+		Name:   "colorful socks",
+		UserID: userID,
+	})
+	as.Nil(err)
+
+	unpublishAt := now.Add(2 * time.Hour)
+	_, err = uc.SchedulePublish(ctx, usecase.SchedulePublishDto{
+		ID:          p.ID,
+		UserID:      userID,
+		PublishAt:   now.Add(time.Hour),
+		UnpublishAt: &unpublishAt,
+	})
+	as.Nil(err)
+
+	publisher := event.NewInMemoryPublisher()
+	scheduler := usecase.NewProductScheduler(repo, publisher, usecase.WithSchedulerClock(clock))
+
+	tick := func(d time.Duration) []string {
+		now = now.Add(d)
+
+		before := len(publisher.Events())
+		_, err := scheduler.RunOnce(ctx)
+		as.Nil(err)
+
+		var names []string
+		for _, evt := range publisher.Events()[before:] {
+			names = append(names, evt.EventName())
+		}
+
+		return names
+	}
+
+	as.Empty(tick(30 * time.Minute))
+	as.Equal([]string{"product.published"}, tick(time.Hour))
+
+	_, err = uc.View(ctx, p.ID)
+	as.Nil(err)
+
+	as.Equal([]string{"product.expired"}, tick(time.Hour))
+	as.Empty(tick(time.Hour))
+
+	_, err = uc.View(ctx, p.ID)
+	as.ErrorIs(err, usecase.ErrProductNotFound)
+
+	// A PublishAt before the previous run is published from now.
+	now = now.Add(time.Minute)
+	_, err = uc.SchedulePublish(ctx, usecase.SchedulePublishDto{
+		ID:        p.ID,
+		UserID:    userID,
+		PublishAt: now.Add(-time.Hour),
+	})
+	as.Nil(err)
+
+	// The restarted scheduler resumes from the stored watermark.
+	scheduler = usecase.NewProductScheduler(repo, publisher, usecase.WithSchedulerClock(clock))
+	as.Equal([]string{"product.published"}, tick(time.Minute))
+}
+
+func TestProductRepositoryCopies(t *testing.T) {
+	ctx := context.Background()
+	p := factories.NewProduct("tiered")
+	repo := inmemory.NewProductRepository(inmemory.NewStore(inmemory.WithProducts(p)))
+
+	got, err := repo.FindByID(ctx, p.ID)
+
+	as := assert.New(t)
+	as.Nil(err)
+	as.Equal(p, got)
+
+	// Changes are not visible until they are saved.
+	got.PriceTiers[0].Price.Amount = 1
+	again, err := repo.FindByID(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(p.PriceTiers, again.PriceTiers)
+}
+
+func TestPurchaseRepositoryDiscountCopies(t *testing.T) {
+	ctx := context.Background()
+	d := factories.NewDiscount("coupon", "expired")
+	d.Cap = types.Ptr(domain.NewMoney(5, "MYR"))
+	want := *d
+	want.Cap = types.Ptr(*d.Cap)
+	want.StartsAt = types.Ptr(*d.StartsAt)
+	want.EndsAt = types.Ptr(*d.EndsAt)
+	want.Coupon = types.Ptr(*d.Coupon)
+
+	repo := inmemory.NewPurchaseRepository(inmemory.NewStore(inmemory.WithDiscounts(d)))
+
+	// Changes to the seeded discount are not visible.
+	d.Cap.Amount = 1
+	*d.EndsAt = time.Now().Add(time.Hour)
+	d.Coupon.MaxPerUser = 0
+
+	got, err := repo.FindDiscountByCouponCode(ctx, want.Coupon.Code)
+
+	as := assert.New(t)
+	as.Nil(err)
+	as.Equal(&want, got)
+
+	// Changes to the found discount are not visible.
+	got.Cap.Amount = 1
+	*got.StartsAt = time.Now()
+
+	ds, err := repo.FindProductDiscount(ctx, want.ProductID)
+	as.Nil(err)
+	as.Equal([]domain.Discount{want}, ds)
+}
+
+func TestPurchaseUsecase(t *testing.T) {
+	ctx := context.Background()
+	user := factories.NewUser()
+
+	newUsecase := func(opts ...inmemory.Option) (*usecase.PurchaseUsecase, *inmemory.Store) {
+		store := inmemory.NewStore(opts...)
+		uc := usecase.NewPurchaseUsecase(inmemory.NewPurchaseRepository(store), inmemory.NewIdempotencyRepository(store))
+
+		return uc, store
+	}
+
+	t.Run("success", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		d := factories.NewDiscount()
+		d.ProductID = p.ID
+
+		uc, store := newUsecase(
+			inmemory.WithUsers(user),
+			inmemory.WithProducts(p),
+			inmemory.WithDiscounts(d),
+			inmemory.WithStock(p.ID, 5),
+		)
+
+		as := assert.New(t)
+		purchase, err := uc.Purchase(ctx, usecase.PurchaseDto{
+			ProductID: p.ID,
+			UserID:    user.ID,
+			Unit:      2,
+		})
+		as.Nil(err)
+		as.Equal(domain.NewMoney(-5, "MYR"), purchase.Discount)
+		as.Len(store.Purchases(user.ID), 1)
+
+		inv, ok := store.Inventory(p.ID)
+		as.True(ok)
+		as.Equal(3, inv.Available())
+
+		msgs := store.Outbox().Messages()
+		if as.Len(msgs, 1) {
+			as.Equal("purchase.created", msgs[0].Name)
+		}
+	})
+
+	t.Run("ineligible user", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		uc, _ := newUsecase(inmemory.WithProducts(p), inmemory.WithStock(p.ID, 5))
+
+		_, err := uc.Purchase(ctx, usecase.PurchaseDto{
+			ProductID: p.ID,
+			UserID:    user.ID,
+			Unit:      1,
+		})
+		assert.ErrorIs(t, err, usecase.ErrUserIneligible)
+	})
+
+	t.Run("underage user", func(t *testing.T) {
+		minor := factories.NewUser("minor")
+		p := factories.NewProduct("published", "age_restricted")
+		uc, _ := newUsecase(inmemory.WithUsers(minor), inmemory.WithProducts(p), inmemory.WithStock(p.ID, 5))
+
+		_, err := uc.Purchase(ctx, usecase.PurchaseDto{
+			ProductID: p.ID,
+			UserID:    minor.ID,
+			Unit:      1,
+		})
+		assert.ErrorIs(t, err, usecase.ErrUserUnderage)
+	})
+
+	t.Run("purchase limit", func(t *testing.T) {
+		p := factories.NewProduct("published", "limited")
+		uc, _ := newUsecase(inmemory.WithUsers(user), inmemory.WithProducts(p), inmemory.WithStock(p.ID, 5))
+
+		dto := usecase.PurchaseDto{
+			ProductID: p.ID,
+			UserID:    user.ID,
+			Unit:      1,
+		}
+
+		as := assert.New(t)
+		_, err := uc.Purchase(ctx, dto)
+		as.Nil(err)
+
+		// Only 1 unit is allowed every 24 hours.
+		_, err = uc.Purchase(ctx, dto)
+		as.True(usecase.ErrPurchaseLimitExceeded.Is(err))
+
+		var d causes.Detail
+		if as.ErrorAs(err, &d) {
+			remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(d.Detail())
+			as.True(ok)
+			as.Equal(0, remaining)
+		}
+	})
+
+	t.Run("coupon", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		d := factories.NewDiscount("coupon")
+		d.ProductID = p.ID
+
+		uc, _ := newUsecase(
+			inmemory.WithUsers(user),
+			inmemory.WithProducts(p),
+			inmemory.WithDiscounts(d),
+			inmemory.WithStock(p.ID, 5),
+		)
+
+		dto := usecase.PurchaseDto{
+			ProductID:   p.ID,
+			UserID:      user.ID,
+			Unit:        2,
+			CouponCodes: []string{"SAVE5"},
+		}
+
+		as := assert.New(t)
+		purchase, err := uc.Purchase(ctx, dto)
+		as.Nil(err)
+		as.Equal([]string{"SAVE5"}, purchase.CouponCodes)
+
+		// Only once per user.
+		_, err = uc.Purchase(ctx, dto)
+		as.ErrorIs(err, usecase.ErrCouponExhausted)
+
+		dto.CouponCodes = []string{"UNKNOWN"}
+		_, err = uc.Purchase(ctx, dto)
+		as.ErrorIs(err, usecase.ErrCouponUnknown)
+	})
+
+	t.Run("coupon concurrency", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		d := factories.NewDiscount("coupon")
+		d.ProductID = p.ID
+		d.Coupon.MaxRedemptions = 3
+
+		n := 20
+		users := make([]*domain.User, n)
+		for i := range users {
+			users[i] = factories.NewUser()
+		}
+
+		uc, store := newUsecase(
+			inmemory.WithUsers(users...),
+			inmemory.WithProducts(p),
+			inmemory.WithDiscounts(d),
+			inmemory.WithStock(p.ID, 2*n),
+		)
+
+		errs := make(chan error, n)
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for _, u := range users {
+			u := u
+			go func() {
+				defer wg.Done()
+
+				_, err := uc.Purchase(ctx, usecase.PurchaseDto{
+					ProductID:   p.ID,
+					UserID:      u.ID,
+					Unit:        2,
+					CouponCodes: []string{d.Coupon.Code},
+				})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var exhausted int
+		for err := range errs {
+			if err != nil {
+				assert.ErrorIs(t, err, usecase.ErrCouponExhausted)
+				exhausted++
+			}
+		}
+
+		as := assert.New(t)
+		as.Equal(n-3, exhausted)
+
+		// The stock of the rejected purchases is released.
+		inv, _ := store.Inventory(p.ID)
+		as.Equal(2*(n-3), inv.Available())
+	})
+
+	t.Run("idempotency", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		uc, store := newUsecase(
+			inmemory.WithUsers(user),
+			inmemory.WithProducts(p),
+			inmemory.WithStock(p.ID, 5),
+		)
+
+		dto := usecase.PurchaseDto{
+			ProductID:      p.ID,
+			UserID:         user.ID,
+			Unit:           1,
+			IdempotencyKey: "key",
+		}
+
+		as := assert.New(t)
+		p1, err := uc.Purchase(ctx, dto)
+		as.Nil(err)
+
+		p2, err := uc.Purchase(ctx, dto)
+		as.Nil(err)
+		as.Equal(p1.ID, p2.ID)
+		as.Len(store.Purchases(user.ID), 1)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		p := factories.NewProduct("published")
+		uc, store := newUsecase(
+			inmemory.WithUsers(user),
+			inmemory.WithProducts(p),
+			inmemory.WithStock(p.ID, 10),
+		)
+
+		n := 50
+		errs := make(chan error, n)
+
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func() {
+				defer wg.Done()
+
+				_, err := uc.Purchase(ctx, usecase.PurchaseDto{
+					ProductID: p.ID,
+					UserID:    user.ID,
+					Unit:      1,
+				})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var outOfStock int
+		for err := range errs {
+			if err != nil {
+				assert.ErrorIs(t, err, usecase.ErrProductOutOfStock)
+				outOfStock++
+			}
+		}
+
+		as := assert.New(t)
+		as.Equal(n-10, outOfStock)
+		as.Len(store.Purchases(user.ID), 10)
+
+		inv, _ := store.Inventory(p.ID)
+		as.Equal(0, inv.Available())
+	})
+}
+
+func TestPurchaseRepositoryCreatePurchase(t *testing.T) {
+	ctx := context.Background()
+
+	p := factories.NewPurchase()
+	store := inmemory.NewStore(inmemory.WithStock(p.ProductID, 3))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	as := assert.New(t)
+	as.Nil(p.MarkCreated(time.Now()))
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	inv, _ := store.Inventory(p.ProductID)
+	as.Equal(domain.Inventory{ProductID: p.ProductID, Stock: 3, Reserved: 2}, inv)
+	as.Len(store.Outbox().Messages(), 1)
+
+	// Nothing is persisted when the stock cannot be reserved.
+	other := factories.NewPurchase()
+	other.UserID = p.UserID
+	other.ProductID = p.ProductID
+	as.Nil(other.MarkCreated(time.Now()))
+	as.ErrorIs(repo.CreatePurchase(ctx, *other), usecase.ErrProductOutOfStock)
+	as.Len(store.Purchases(p.UserID), 1)
+	as.Len(store.Outbox().Messages(), 1)
+
+	inv, _ = store.Inventory(p.ProductID)
+	as.Equal(2, inv.Reserved)
+}
+
+func TestPurchaseRepositoryCreatePurchaseLimits(t *testing.T) {
+	ctx := context.Background()
+
+	p := factories.NewProduct("limited")
+	store := inmemory.NewStore(inmemory.WithProducts(p), inmemory.WithStock(p.ID, 5))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	now := time.Now()
+	first, second := factories.NewPurchase(), factories.NewPurchase()
+	for _, purchase := range []*domain.Purchase{first, second} {
+		purchase.ProductID = p.ID
+		purchase.Unit = 1
+		purchase.CreatedAt = now
+	}
+
+	// Both purchases passed the check before either was stored, so only the
+	// check under the lock stops the second one.
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *first))
+
+	var limitErr *domain.PurchaseLimitExceededError
+	if as.ErrorAs(repo.CreatePurchase(ctx, *second), &limitErr) {
+		as.Equal(0, limitErr.Remaining)
+	}
+
+	order, err := domain.NewOrder(second.UserID, []domain.Purchase{*second}, now)
+	as.Nil(err)
+	as.ErrorIs(repo.CreateOrder(ctx, *order), domain.ErrPurchaseLimitExceeded)
+	as.Len(store.Purchases(first.UserID), 1)
+}
+
+func TestPurchaseRepositoryPayPurchase(t *testing.T) {
+	ctx := context.Background()
+
+	p := factories.NewPurchase()
+	store := inmemory.NewStore(inmemory.WithStock(p.ProductID, 5))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	as.Nil(p.MarkPaid(time.Now()))
+	as.Nil(repo.PayPurchase(ctx, *p))
+
+	// The reserved units are removed from the stock.
+	inv, _ := store.Inventory(p.ProductID)
+	as.Equal(domain.Inventory{ProductID: p.ProductID, Stock: 3}, inv)
+
+	purchases := store.Purchases(p.UserID)
+	if as.Len(purchases, 1) {
+		as.Equal(domain.PurchaseStatusPaid, purchases[0].Status)
+	}
+
+	msgs := store.Outbox().Messages()
+	if as.Len(msgs, 1) {
+		as.Equal("purchase.status_changed", msgs[0].Name)
+	}
+
+	// The stock is committed only once.
+	as.ErrorIs(repo.PayPurchase(ctx, *p), usecase.ErrPurchaseStatusInvalid)
+	as.ErrorIs(repo.PayPurchase(ctx, *factories.NewPurchase("paid")), usecase.ErrPurchaseNotFound)
+}
+
+func TestPurchaseRepositoryCreateOrder(t *testing.T) {
+	ctx := context.Background()
+
+	socks, shirt := factories.NewPurchase(), factories.NewPurchase()
+	store := inmemory.NewStore(inmemory.WithStock(socks.ProductID, 5), inmemory.WithStock(shirt.ProductID, 1))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	order, err := domain.NewOrder(socks.UserID, []domain.Purchase{*socks, *shirt}, time.Now())
+
+	as := assert.New(t)
+	as.Nil(err)
+
+	// Nothing is persisted when any line is out of stock.
+	as.ErrorIs(repo.CreateOrder(ctx, *order), usecase.ErrProductOutOfStock)
+	as.Empty(store.Purchases(socks.UserID))
+	as.Empty(store.Outbox().Messages())
+
+	inv, _ := store.Inventory(socks.ProductID)
+	as.Equal(5, inv.Available())
+
+	order.Lines[1].Unit = 1
+	as.Nil(repo.CreateOrder(ctx, *order))
+	as.Len(store.Purchases(socks.UserID), 2)
+	as.Len(store.Outbox().Messages(), 2)
+
+	inv, _ = store.Inventory(socks.ProductID)
+	as.Equal(3, inv.Available())
+
+	inv, _ = store.Inventory(shirt.ProductID)
+	as.Equal(0, inv.Available())
+}
+
+func TestPurchaseRepositoryCancelPurchase(t *testing.T) {
+	ctx := context.Background()
+
+	p := factories.NewPurchase()
+	store := inmemory.NewStore(inmemory.WithStock(p.ProductID, 5))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	as.Nil(p.Cancel(time.Now()))
+	as.Nil(repo.CancelPurchase(ctx, *p))
+
+	// The reserved units are returned.
+	inv, _ := store.Inventory(p.ProductID)
+	as.Equal(5, inv.Available())
+
+	purchases := store.Purchases(p.UserID)
+	if as.Len(purchases, 1) {
+		as.Equal(domain.PurchaseStatusCancelled, purchases[0].Status)
+	}
+
+	as.Len(store.Outbox().Messages(), 1)
+	as.ErrorIs(repo.CancelPurchase(ctx, *p), usecase.ErrPurchaseStatusInvalid)
+}
+
+func TestPurchaseRepositoryCreateRefund(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	p := factories.NewPurchase("paid")
+	store := inmemory.NewStore(inmemory.WithStock(p.ProductID, p.Unit))
+	repo := inmemory.NewPurchaseRepository(store)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	got, err := repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+
+	paid, err := repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+
+	refund, err := got.IssueRefund(types.Ptr(domain.NewMoney(4, "MYR")), "damaged", nil, now)
+	as.Nil(err)
+	as.Nil(repo.CreateRefund(ctx, *refund, *got))
+
+	refunds, err := repo.FindRefunds(ctx, p.ID)
+	as.Nil(err)
+	as.Equal([]domain.Refund{*refund}, refunds)
+
+	// The refund is checked against the stored refunds, not the stale ones.
+	stale, err := p.IssueRefund(types.Ptr(domain.NewMoney(8, "MYR")), "", nil, now)
+	as.Nil(err)
+	as.ErrorIs(repo.CreateRefund(ctx, *stale, *p), usecase.ErrRefundExceedsPaid)
+
+	got, err = repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(domain.PurchaseStatusPartiallyRefunded, got.Status)
+	as.Len(store.Outbox().Messages(), 1)
+
+	// The stored purchase is no longer paid, so the stale transition conflicts.
+	as.Nil(paid.Fulfill(now))
+	as.ErrorIs(repo.UpdatePurchase(ctx, *paid), usecase.ErrPurchaseStatusInvalid)
+
+	_, err = repo.FindPurchase(ctx, uuid.New())
+	as.ErrorIs(err, usecase.ErrPurchaseNotFound)
+	as.ErrorIs(repo.UpdatePurchase(ctx, *factories.NewPurchase()), usecase.ErrPurchaseNotFound)
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	ctx := context.Background()
+	p := factories.NewPurchase()
+	store := inmemory.NewStore(inmemory.WithStock(p.ProductID, p.Unit))
+	repo := inmemory.NewIdempotencyRepository(store)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	key := domain.IdempotencyKey{
+		UserID:      uuid.New(),
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   now,
+	}
+
+	as := assert.New(t)
+	_, created, err := repo.LockIdempotencyKey(ctx, key)
+	as.Nil(err)
+	as.True(created)
+
+	// Keys are scoped to the user.
+	other := key
+	other.UserID = uuid.New()
+	_, created, err = repo.LockIdempotencyKey(ctx, other)
+	as.Nil(err)
+	as.True(created)
+
+	retry := key
+	retry.CreatedAt = now.Add(time.Second)
+	existing, created, err := repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.False(created)
+	as.False(existing.IsCompleted())
+
+	// The key is claimed again when it is stuck in progress.
+	retry.CreatedAt = now.Add(domain.IdempotencyKeyLockTimeout)
+	_, created, err = repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.True(created)
+
+	// The expired claim can no longer release or complete the key, and the
+	// purchase is not created.
+	purchaseRepo := inmemory.NewPurchaseRepository(store)
+	as.Nil(repo.DeleteIdempotencyKey(ctx, key))
+	as.ErrorIs(purchaseRepo.CreateIdempotentPurchase(ctx, *p, key), usecase.ErrIdempotencyKeyExpired)
+
+	_, err = purchaseRepo.FindPurchase(ctx, p.ID)
+	as.ErrorIs(err, usecase.ErrPurchaseNotFound)
+
+	existing, created, err = repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.False(created)
+	as.False(existing.IsCompleted())
+
+	as.Nil(purchaseRepo.CreateIdempotentPurchase(ctx, *p, retry))
+	existing, created, err = repo.LockIdempotencyKey(ctx, key)
+	as.Nil(err)
+	as.False(created)
+	if as.True(existing.IsCompleted()) {
+		as.Equal(p.ID, existing.Purchase.ID)
+	}
+}
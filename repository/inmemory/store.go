@@ -0,0 +1,307 @@
+// Package inmemory implements the usecase repositories in memory, for local
+// development and tests. All repositories created from the same Store share
+// the same data, and are safe for concurrent use.
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+var ErrPurchaseExists = errors.New("inmemory: purchase already exists")
+
+type couponRedemption struct {
+	code   string
+	userID uuid.UUID
+}
+
+// Store holds the data of the repositories.
+type Store struct {
+	mu          sync.RWMutex
+	users       map[uuid.UUID]domain.User
+	products    map[uuid.UUID]domain.Product
+	discounts   []domain.Discount
+	inventories map[uuid.UUID]domain.Inventory
+	purchases   map[uuid.UUID]domain.Purchase
+	redemptions []couponRedemption
+	refunds     map[uuid.UUID][]domain.Refund
+	keys        map[idempotencyKeyID]domain.IdempotencyKey
+	outbox      *outbox.InMemoryStore
+
+	// scheduleWatermark is the time up to which the ProductScheduler
+	// published the transitions.
+	scheduleWatermark time.Time
+}
+
+type Option func(*Store)
+
+// WithUsers seeds the users, e.g. from factories.NewUser. Other users are
+// not eligible to purchase.
+func WithUsers(users ...*domain.User) Option {
+	return func(s *Store) {
+		for _, u := range users {
+			s.users[u.ID] = *u
+		}
+	}
+}
+
+// WithProducts seeds the products, e.g. from factories.NewProduct.
+func WithProducts(products ...*domain.Product) Option {
+	return func(s *Store) {
+		for _, p := range products {
+			s.products[p.ID] = copyProduct(*p)
+		}
+	}
+}
+
+// WithDiscounts seeds the discounts, e.g. from factories.NewDiscount.
+func WithDiscounts(discounts ...*domain.Discount) Option {
+	return func(s *Store) {
+		for _, d := range discounts {
+			s.discounts = append(s.discounts, copyDiscount(*d))
+		}
+	}
+}
+
+// WithStock sets the units on hand for the product.
+func WithStock(productID uuid.UUID, stock int) Option {
+	return func(s *Store) {
+		s.inventories[productID] = domain.Inventory{
+			ProductID: productID,
+			Stock:     stock,
+		}
+	}
+}
+
+func NewStore(opts ...Option) *Store {
+	s := &Store{
+		users:       make(map[uuid.UUID]domain.User),
+		products:    make(map[uuid.UUID]domain.Product),
+		inventories: make(map[uuid.UUID]domain.Inventory),
+		purchases:   make(map[uuid.UUID]domain.Purchase),
+		refunds:     make(map[uuid.UUID][]domain.Refund),
+		keys:        make(map[idempotencyKeyID]domain.IdempotencyKey),
+		outbox:      outbox.NewInMemoryStore(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Outbox returns the outbox that the purchase events are stored in.
+func (s *Store) Outbox() *outbox.InMemoryStore {
+	return s.outbox
+}
+
+// Inventory returns the inventory of the product.
+func (s *Store) Inventory(productID uuid.UUID) (domain.Inventory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inv, ok := s.inventories[productID]
+	return inv, ok
+}
+
+// Purchases returns the purchases made by the user.
+func (s *Store) Purchases(userID uuid.UUID) []domain.Purchase {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var res []domain.Purchase
+	for _, p := range s.purchases {
+		if p.UserID == userID {
+			res = append(res, copyPurchase(p))
+		}
+	}
+
+	return res
+}
+
+// findCoupon returns the coupon of the code. The caller must hold the lock.
+func (s *Store) findCoupon(code string) (*domain.Coupon, bool) {
+	for _, d := range s.discounts {
+		if d.Coupon != nil && d.Coupon.Code == code {
+			return d.Coupon, true
+		}
+	}
+
+	return nil, false
+}
+
+// couponUsage counts the redemptions of the code. The caller must hold the
+// lock.
+func (s *Store) couponUsage(code string, userID uuid.UUID) domain.CouponUsage {
+	return couponUsage(s.redemptions, code, userID)
+}
+
+func couponUsage(redemptions []couponRedemption, code string, userID uuid.UUID) domain.CouponUsage {
+	var usage domain.CouponUsage
+	for _, red := range redemptions {
+		if red.code != code {
+			continue
+		}
+
+		usage.Redemptions++
+		if red.userID == userID {
+			usage.UserRedemptions++
+		}
+	}
+
+	return usage
+}
+
+// reserveStock returns the inventory of the product with the units reserved,
+// without storing it. It returns usecase.ErrProductOutOfStock if the product
+// does not have enough available units, or has no inventory. The caller must
+// checkPurchase reserves the stock, and checks the purchase limits and the
+// coupon caps. It returns the inventory with the units reserved, which is
+// stored by createPurchase. The caller must hold the lock.
+func (s *Store) checkPurchase(purchase domain.Purchase) (domain.Inventory, error) {
+	if _, ok := s.purchases[purchase.ID]; ok {
+		return domain.Inventory{}, ErrPurchaseExists
+	}
+
+	inv, err := s.reserveStock(purchase.ProductID, purchase.Unit)
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	if err := s.checkPurchaseLimits(purchase); err != nil {
+		return domain.Inventory{}, err
+	}
+
+	// The caps are checked again under the lock, so concurrent purchases
+	// cannot over-redeem the coupon.
+	for _, code := range purchase.CouponCodes {
+		c, ok := s.findCoupon(code)
+		if !ok || !c.CanRedeem(s.couponUsage(code, purchase.UserID)) {
+			return domain.Inventory{}, usecase.ErrCouponExhausted
+		}
+	}
+
+	return inv, nil
+}
+
+// createPurchase stores the purchase checked by checkPurchase, its coupon
+// redemptions and its messages. The caller must hold the lock.
+func (s *Store) createPurchase(ctx context.Context, purchase domain.Purchase, inv domain.Inventory, msgs []outbox.Message) error {
+	s.inventories[purchase.ProductID] = inv
+	s.purchases[purchase.ID] = copyPurchase(purchase)
+	for _, code := range purchase.CouponCodes {
+		s.redemptions = append(s.redemptions, couponRedemption{
+			code:   code,
+			userID: purchase.UserID,
+		})
+	}
+
+	return s.outbox.Add(ctx, msgs...)
+}
+
+// hold the lock.
+func (s *Store) reserveStock(productID uuid.UUID, unit int) (domain.Inventory, error) {
+	inv, ok := s.inventories[productID]
+	if !ok {
+		return inv, usecase.ErrProductOutOfStock
+	}
+
+	if err := inv.Reserve(unit); err != nil {
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			return inv, usecase.ErrProductOutOfStock
+		}
+
+		return inv, err
+	}
+
+	return inv, nil
+}
+
+// userProductPurchases returns the units of the product bought by the user
+// after the time, excluding the cancelled purchases. The caller must hold
+// the lock.
+func (s *Store) userProductPurchases(userID, productID uuid.UUID, since time.Time) []domain.PurchasedUnits {
+	var purchased []domain.PurchasedUnits
+	for _, p := range s.purchases {
+		if p.UserID != userID || p.ProductID != productID || p.Status == domain.PurchaseStatusCancelled || !p.CreatedAt.After(since) {
+			continue
+		}
+
+		purchased = append(purchased, domain.PurchasedUnits{Unit: p.Unit, At: p.CreatedAt})
+	}
+
+	sort.Slice(purchased, func(i, j int) bool {
+		return purchased[i].At.Before(purchased[j].At)
+	})
+
+	return purchased
+}
+
+// checkPurchaseLimits checks the purchase limits of the product again, so
+// that purchases stored since the limits were checked are counted. A missing
+// product has no limits to check. The caller must hold the lock.
+func (s *Store) checkPurchaseLimits(purchase domain.Purchase) error {
+	limits := s.products[purchase.ProductID].PurchaseLimits
+
+	since, ok := limits.Since(purchase.CreatedAt)
+	if !ok {
+		return nil
+	}
+
+	purchased := s.userProductPurchases(purchase.UserID, purchase.ProductID, since)
+
+	return limits.Check(purchase.Unit, purchased, purchase.CreatedAt)
+}
+
+// copyProduct returns a copy that does not share the slices, and does not
+// carry the recorded events.
+func copyProduct(p domain.Product) domain.Product {
+	p.EventRecorder = domain.EventRecorder{}
+	p.PriceTiers = append(domain.PriceTiers(nil), p.PriceTiers...)
+	p.PurchaseLimits = append(domain.PurchaseLimits(nil), p.PurchaseLimits...)
+
+	return p
+}
+
+func copyPurchase(p domain.Purchase) domain.Purchase {
+	p.EventRecorder = domain.EventRecorder{}
+	p.AppliedDiscountIDs = append([]int64(nil), p.AppliedDiscountIDs...)
+	p.RejectedDiscounts = append([]domain.DiscountRejection(nil), p.RejectedDiscounts...)
+	p.CouponCodes = append([]string(nil), p.CouponCodes...)
+
+	return p
+}
+
+// copyDiscount returns a copy that does not share the pointers.
+func copyDiscount(d domain.Discount) domain.Discount {
+	if d.Cap != nil {
+		c := *d.Cap
+		d.Cap = &c
+	}
+
+	if d.StartsAt != nil {
+		t := *d.StartsAt
+		d.StartsAt = &t
+	}
+
+	if d.EndsAt != nil {
+		t := *d.EndsAt
+		d.EndsAt = &t
+	}
+
+	if d.Coupon != nil {
+		c := *d.Coupon
+		d.Coupon = &c
+	}
+
+	return d
+}
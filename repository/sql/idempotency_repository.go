@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// LockIdempotencyKey claims the key of the user. A key that is expired at
+// the creation time of the given key is claimed again.
+func (r *IdempotencyRepository) LockIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+	var (
+		existing *domain.IdempotencyKey
+		created  bool
+	)
+
+	expiredAt := key.CreatedAt.Add(-domain.IdempotencyKeyLockTimeout)
+
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (user_id, key) DO UPDATE
+			SET request_hash = excluded.request_hash, created_at = excluded.created_at
+			WHERE idempotency_keys.purchase_id IS NULL AND idempotency_keys.created_at <= ?`,
+			key.UserID.String(), key.Key, key.RequestHash, key.CreatedAt.UTC(), expiredAt.UTC())
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if n == 1 {
+			existing, created = &key, true
+			return nil
+		}
+
+		existing, err = findIdempotencyKey(ctx, tx, key.UserID, key.Key)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return existing, created, nil
+}
+
+// DeleteIdempotencyKey releases the key, unless the claim has expired and the
+// key has been claimed again since.
+func (r *IdempotencyRepository) DeleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND key = ? AND created_at = ? AND purchase_id IS NULL`,
+		key.UserID.String(), key.Key, key.CreatedAt.UTC())
+	return err
+}
+
+// completeIdempotencyKey stores the purchase id, which is loaded from the
+// purchases table when the key is claimed again. It returns
+// usecase.ErrIdempotencyKeyExpired if the claim has expired and the key has
+// been claimed again since.
+func completeIdempotencyKey(ctx context.Context, q querier, key domain.IdempotencyKey, purchaseID uuid.UUID) error {
+	res, err := q.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET purchase_id = ?
+		WHERE user_id = ? AND key = ? AND created_at = ? AND purchase_id IS NULL`,
+		purchaseID.String(), key.UserID.String(), key.Key, key.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, usecase.ErrIdempotencyKeyExpired)
+}
+
+func findIdempotencyKey(ctx context.Context, q querier, userID uuid.UUID, key string) (*domain.IdempotencyKey, error) {
+	var (
+		k          domain.IdempotencyKey
+		purchaseID sql.NullString
+	)
+
+	if err := q.QueryRowContext(ctx, `
+		SELECT key, request_hash, purchase_id, created_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND key = ?`, userID.String(), key).Scan(&k.Key, &k.RequestHash, &purchaseID, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	k.UserID = userID
+
+	id, err := uuidPtr(purchaseID)
+	if err != nil || id == nil {
+		return &k, err
+	}
+
+	k.Purchase, err = findPurchase(ctx, q, *id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
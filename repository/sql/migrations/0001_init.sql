@@ -0,0 +1,90 @@
+CREATE TABLE users (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	eligible BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE products (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	published_at TIMESTAMP,
+	price_amount INTEGER NOT NULL DEFAULT 0,
+	price_currency TEXT NOT NULL DEFAULT '',
+	price_tiers TEXT NOT NULL DEFAULT '[]',
+	tax_category TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE inventories (
+	product_id TEXT PRIMARY KEY,
+	stock INTEGER NOT NULL CHECK (stock >= 0),
+	reserved INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= stock)
+);
+
+CREATE TABLE discounts (
+	id INTEGER PRIMARY KEY,
+	product_id TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	kind TEXT NOT NULL,
+	amount INTEGER NOT NULL DEFAULT 0,
+	currency TEXT NOT NULL DEFAULT '',
+	percent INTEGER NOT NULL DEFAULT 0,
+	rounding TEXT NOT NULL DEFAULT '',
+	cap_amount INTEGER,
+	cap_currency TEXT,
+	min_purchase_qty INTEGER NOT NULL,
+	stacking TEXT NOT NULL,
+	group_name TEXT NOT NULL DEFAULT '',
+	priority INTEGER NOT NULL DEFAULT 0,
+	starts_at TIMESTAMP,
+	ends_at TIMESTAMP,
+	coupon_code TEXT UNIQUE,
+	coupon_single_use BOOLEAN NOT NULL DEFAULT FALSE,
+	coupon_max_per_user INTEGER NOT NULL DEFAULT 0,
+	coupon_max_redemptions INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX discounts_product_id_idx ON discounts (product_id);
+
+CREATE TABLE purchases (
+	id TEXT PRIMARY KEY,
+	order_id TEXT,
+	user_id TEXT NOT NULL,
+	product_id TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	base_price INTEGER NOT NULL,
+	discount INTEGER NOT NULL,
+	unit INTEGER NOT NULL,
+	tax INTEGER NOT NULL,
+	tax_rate INTEGER NOT NULL,
+	tax_inclusive BOOLEAN NOT NULL,
+	status TEXT NOT NULL,
+	applied_discount_ids TEXT NOT NULL DEFAULT '[]',
+	coupon_codes TEXT NOT NULL DEFAULT '[]',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX purchases_user_id_idx ON purchases (user_id);
+
+CREATE TABLE coupon_redemptions (
+	code TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	purchase_id TEXT NOT NULL,
+	PRIMARY KEY (code, purchase_id)
+);
+
+CREATE INDEX coupon_redemptions_user_id_idx ON coupon_redemptions (code, user_id);
+
+CREATE TABLE outbox (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	id TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	occurred_at TIMESTAMP NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	delivered_at TIMESTAMP
+);
+
+CREATE INDEX outbox_undelivered_idx ON outbox (seq) WHERE delivered_at IS NULL;
@@ -0,0 +1,10 @@
+-- Keys are scoped to the user, so that users cannot replay or probe the keys
+-- of each other.
+CREATE TABLE idempotency_keys (
+	user_id TEXT NOT NULL,
+	key TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	purchase_id TEXT,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, key)
+);
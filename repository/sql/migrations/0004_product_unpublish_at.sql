@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN unpublish_at TIMESTAMP;
+CREATE INDEX products_published_at_idx ON products (published_at);
+CREATE INDEX products_unpublish_at_idx ON products (unpublish_at);
@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX products_deleted_at_idx ON products (deleted_at);
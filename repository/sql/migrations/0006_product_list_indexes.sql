@@ -0,0 +1,2 @@
+CREATE INDEX products_name_idx ON products (name, id);
+CREATE INDEX products_price_idx ON products (price_amount, id);
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN banned_until TIMESTAMP;
+ALTER TABLE users ADD COLUMN birth_date TIMESTAMP;
+UPDATE users SET status = 'suspended' WHERE NOT eligible;
+ALTER TABLE users DROP COLUMN eligible;
+
+ALTER TABLE products ADD COLUMN min_age INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX purchases_user_id_created_at_idx ON purchases (user_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN purchase_limits TEXT NOT NULL DEFAULT '[]';
+
+CREATE INDEX purchases_user_id_product_id_created_at_idx ON purchases (user_id, product_id, created_at);
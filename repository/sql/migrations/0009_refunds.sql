@@ -0,0 +1,10 @@
+CREATE TABLE refunds (
+	id TEXT PRIMARY KEY,
+	purchase_id TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	currency TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX refunds_purchase_id_idx ON refunds (purchase_id, created_at);
@@ -0,0 +1,7 @@
+CREATE TABLE orders (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX purchases_order_id_idx ON purchases (order_id);
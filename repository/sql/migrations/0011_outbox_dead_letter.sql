@@ -0,0 +1,5 @@
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMP;
+
+DROP INDEX outbox_undelivered_idx;
+
+CREATE INDEX outbox_undelivered_idx ON outbox (seq) WHERE delivered_at IS NULL AND dead_at IS NULL;
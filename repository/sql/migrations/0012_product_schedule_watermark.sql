@@ -0,0 +1,6 @@
+-- The time up to which the ProductScheduler published the transitions. There
+-- is at most one row.
+CREATE TABLE product_schedule_watermark (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	since TIMESTAMP NOT NULL
+);
@@ -0,0 +1,4 @@
+-- The price sorts group the products by currency first.
+DROP INDEX products_price_idx;
+
+CREATE INDEX products_price_idx ON products (price_currency, price_amount, id);
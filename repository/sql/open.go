@@ -0,0 +1,25 @@
+package sql
+
+import (
+	"database/sql"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database at the dsn. The transactions take the write
+// lock when they begin (_txlock=immediate), overriding the dsn, so that the
+// checks that read before writing, e.g. the purchase limits, see the writes of
+// the concurrent transactions.
+func Open(dsn string) (*sql.DB, error) {
+	name, query, _ := strings.Cut(dsn, "?")
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("_txlock", "immediate")
+
+	return sql.Open("sqlite", name+"?"+params.Encode())
+}
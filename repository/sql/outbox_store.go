@@ -0,0 +1,99 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	"github.com/google/uuid"
+)
+
+// OutboxStore implements outbox.Store on the outbox table, which is written
+// by the repositories.
+type OutboxStore struct {
+	db *sql.DB
+}
+
+func NewOutboxStore(db *sql.DB) *OutboxStore {
+	return &OutboxStore{
+		db: db,
+	}
+}
+
+func (s *OutboxStore) Undelivered(ctx context.Context, limit int) ([]outbox.Message, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, payload, occurred_at, attempts, last_error, delivered_at, dead_at
+		FROM outbox
+		WHERE delivered_at IS NULL AND dead_at IS NULL
+		ORDER BY seq
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []outbox.Message
+	for rows.Next() {
+		var (
+			msg         outbox.Message
+			id, payload string
+			deliveredAt sql.NullTime
+			deadAt      sql.NullTime
+		)
+
+		if err := rows.Scan(&id, &msg.Name, &payload, &msg.OccurredAt, &msg.Attempts, &msg.LastError, &deliveredAt, &deadAt); err != nil {
+			return nil, err
+		}
+
+		if msg.ID, err = uuid.Parse(id); err != nil {
+			return nil, err
+		}
+
+		msg.Payload = []byte(payload)
+		msg.DeliveredAt = timePtr(deliveredAt)
+		msg.DeadAt = timePtr(deadAt)
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, rows.Err()
+}
+
+func (s *OutboxStore) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE outbox SET delivered_at = ? WHERE id = ?`, at.UTC(), id.String())
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, outbox.ErrMessageNotFound)
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?`, reason, id.String())
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, outbox.ErrMessageNotFound)
+}
+
+func (s *OutboxStore) MarkDead(ctx context.Context, id uuid.UUID, reason string, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = ?, dead_at = ? WHERE id = ?`, reason, at.UTC(), id.String())
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, outbox.ErrMessageNotFound)
+}
+
+func insertMessages(ctx context.Context, q querier, msgs ...outbox.Message) error {
+	for _, msg := range msgs {
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO outbox (id, name, payload, occurred_at)
+			VALUES (?, ?, ?, ?)`, msg.ID.String(), msg.Name, string(msg.Payload), msg.OccurredAt.UTC()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
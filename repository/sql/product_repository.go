@@ -0,0 +1,363 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+const productColumns = `id, name, user_id, published_at, price_amount, price_currency, price_tiers, tax_category, version, unpublish_at, deleted_at, min_age, purchase_limits`
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{
+		db: db,
+	}
+}
+
+// FindByID returns usecase.ErrProductNotFound if the product does not exist.
+// Soft deleted products are returned.
+func (r *ProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	return findProduct(ctx, r.db, id)
+}
+
+// Create creates an unpublished product without a price.
+func (r *ProductRepository) Create(ctx context.Context, name string, userID uuid.UUID) (*domain.Product, error) {
+	p := &domain.Product{
+		ID:     uuid.New(),
+		Name:   domain.ProductName(name),
+		UserID: userID,
+	}
+
+	if err := insertProduct(ctx, r.db, *p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Update returns usecase.ErrProductVersionConflict if the stored version does
+// not match, and usecase.ErrProductNotFound if the product does not exist.
+func (r *ProductRepository) Update(ctx context.Context, p domain.Product, version int) error {
+	tiers, err := jsonText(p.PriceTiers)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE products
+		SET name = ?, price_amount = ?, price_currency = ?, price_tiers = ?,
+			published_at = ?, unpublish_at = ?, deleted_at = ?, version = ?
+		WHERE id = ? AND version = ?`,
+		string(p.Name),
+		p.Price.Amount,
+		string(p.Price.Currency),
+		tiers,
+		nullTime(p.PublishedAt),
+		nullTime(p.UnpublishAt),
+		nullTime(p.DeletedAt),
+		p.Version,
+		p.ID.String(),
+		version,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := mustAffect(res, usecase.ErrProductVersionConflict); err != nil {
+		if _, findErr := findProduct(ctx, r.db, p.ID); findErr != nil {
+			return findErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// FindScheduled returns the products that are published or expire within
+// (from, to], excluding the deleted products.
+func (r *ProductRepository) FindScheduled(ctx context.Context, from, to time.Time) ([]domain.Product, error) {
+	from, to = from.UTC(), to.UTC()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE deleted_at IS NULL
+		AND ((published_at > ? AND published_at <= ?)
+		OR (unpublish_at > ? AND unpublish_at <= ?))
+		ORDER BY id`, from, to, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *p)
+	}
+
+	return products, rows.Err()
+}
+
+// FindScheduleWatermark returns the time up to which the transitions were
+// published, and false when none is stored.
+func (r *ProductRepository) FindScheduleWatermark(ctx context.Context) (time.Time, bool, error) {
+	var since time.Time
+	err := r.db.QueryRowContext(ctx, `SELECT since FROM product_schedule_watermark WHERE id = 1`).Scan(&since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return since, true, nil
+}
+
+func (r *ProductRepository) SaveScheduleWatermark(ctx context.Context, since time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO product_schedule_watermark (id, since)
+		VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET since = excluded.since`, since.UTC())
+	return err
+}
+
+// PurgeDeleted hard deletes the products that were deleted before the time,
+// together with their discounts and inventories, in a single transaction.
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	before = before.UTC()
+
+	var n int64
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for _, table := range []string{"discounts", "inventories"} {
+			if _, err := tx.ExecContext(ctx, `
+				DELETE FROM `+table+`
+				WHERE product_id IN (SELECT id FROM products WHERE deleted_at < ?)`, before); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM products WHERE deleted_at < ?`, before)
+		if err != nil {
+			return err
+		}
+
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// List returns the products matching the query. The published state follows
+// domain.Product.IsPublishedAt the query time.
+func (r *ProductRepository) List(ctx context.Context, q usecase.ProductQuery) ([]domain.Product, error) {
+	const published = `(published_at IS NOT NULL AND published_at < ? AND (unpublish_at IS NULL OR unpublish_at > ?))`
+
+	now := q.At.UTC()
+	where := []string{`deleted_at IS NULL`, `(` + published + ` OR user_id = ?)`}
+	args := []any{now, now, q.VisibleTo.String()}
+
+	if q.OwnerID != nil {
+		where = append(where, `user_id = ?`)
+		args = append(args, q.OwnerID.String())
+	}
+
+	if q.Published != nil {
+		if *q.Published {
+			where = append(where, published)
+		} else {
+			where = append(where, `NOT `+published)
+		}
+		args = append(args, now, now)
+	}
+
+	if q.MinPrice != nil {
+		where = append(where, `price_currency = ? AND price_amount >= ?`)
+		args = append(args, string(q.MinPrice.Currency), q.MinPrice.Amount)
+	}
+
+	if q.MaxPrice != nil {
+		where = append(where, `price_currency = ? AND price_amount <= ?`)
+		args = append(args, string(q.MaxPrice.Currency), q.MaxPrice.Amount)
+	}
+
+	if q.NamePrefix != "" {
+		where = append(where, `substr(name, 1, length(?)) = ?`)
+		args = append(args, q.NamePrefix, q.NamePrefix)
+	}
+
+	var orderBy string
+	switch q.Sort {
+	case usecase.ProductSortPriceAsc:
+		orderBy = `price_currency, price_amount, id`
+		if a := q.After; a != nil {
+			where = append(where, `(price_currency > ? OR (price_currency = ? AND (price_amount > ? OR (price_amount = ? AND id > ?))))`)
+			args = append(args, string(a.Currency), string(a.Currency), a.Price, a.Price, a.ID.String())
+		}
+	case usecase.ProductSortPriceDesc:
+		orderBy = `price_currency, price_amount DESC, id`
+		if a := q.After; a != nil {
+			where = append(where, `(price_currency > ? OR (price_currency = ? AND (price_amount < ? OR (price_amount = ? AND id > ?))))`)
+			args = append(args, string(a.Currency), string(a.Currency), a.Price, a.Price, a.ID.String())
+		}
+	default:
+		orderBy = `name, id`
+		if a := q.After; a != nil {
+			where = append(where, `(name > ? OR (name = ? AND id > ?))`)
+			args = append(args, a.Name, a.Name, a.ID.String())
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE `+strings.Join(where, ` AND `)+`
+		ORDER BY `+orderBy+`
+		LIMIT ?`, append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, *p)
+	}
+
+	return products, rows.Err()
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func findProduct(ctx context.Context, q querier, id uuid.UUID) (*domain.Product, error) {
+	row := q.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE id = ?`, id.String())
+
+	p, err := scanProduct(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, usecase.ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func insertProduct(ctx context.Context, q querier, p domain.Product) error {
+	tiers, err := jsonText(p.PriceTiers)
+	if err != nil {
+		return err
+	}
+
+	limits, err := jsonText(p.PurchaseLimits)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO products (`+productColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID.String(),
+		string(p.Name),
+		p.UserID.String(),
+		nullTime(p.PublishedAt),
+		p.Price.Amount,
+		string(p.Price.Currency),
+		tiers,
+		string(p.TaxCategory),
+		p.Version,
+		nullTime(p.UnpublishAt),
+		nullTime(p.DeletedAt),
+		p.MinAge,
+		limits,
+	)
+
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(s scanner) (*domain.Product, error) {
+	var (
+		p           domain.Product
+		id, userID  string
+		publishedAt sql.NullTime
+		unpublishAt sql.NullTime
+		deletedAt   sql.NullTime
+		tiers       string
+		limits      string
+	)
+
+	if err := s.Scan(
+		&id,
+		&p.Name,
+		&userID,
+		&publishedAt,
+		&p.Price.Amount,
+		&p.Price.Currency,
+		&tiers,
+		&p.TaxCategory,
+		&p.Version,
+		&unpublishAt,
+		&deletedAt,
+		&p.MinAge,
+		&limits,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+
+	if p.UserID, err = uuid.Parse(userID); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tiers), &p.PriceTiers); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(limits), &p.PurchaseLimits); err != nil {
+		return nil, err
+	}
+
+	p.PublishedAt = timePtr(publishedAt)
+	p.UnpublishAt = timePtr(unpublishAt)
+	p.DeletedAt = timePtr(deletedAt)
+
+	return &p, nil
+}
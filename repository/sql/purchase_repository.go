@@ -0,0 +1,765 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+)
+
+const discountColumns = `id, product_id, name, kind, amount, currency, percent, rounding,
+	cap_amount, cap_currency, min_purchase_qty, stacking, group_name, priority,
+	starts_at, ends_at, coupon_code, coupon_single_use, coupon_max_per_user,
+	coupon_max_redemptions`
+
+const purchaseColumns = `id, order_id, user_id, product_id, currency, base_price, discount,
+	unit, tax, tax_rate, tax_inclusive, status, applied_discount_ids,
+	coupon_codes, created_at, updated_at`
+
+type PurchaseRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseRepository(db *sql.DB) *PurchaseRepository {
+	return &PurchaseRepository{
+		db: db,
+	}
+}
+
+// FindUser returns usecase.ErrUserIneligible if the user does not exist.
+func (r *PurchaseRepository) FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	var (
+		u           domain.User
+		id          string
+		bannedUntil sql.NullTime
+		birthDate   sql.NullTime
+	)
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, status, banned_until, birth_date
+		FROM users
+		WHERE id = ?`, userID.String()).Scan(&id, &u.Name, &u.Status, &bannedUntil, &birthDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, usecase.ErrUserIneligible
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if u.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+
+	u.BannedUntil = timePtr(bannedUntil)
+	u.BirthDate = timePtr(birthDate)
+
+	return &u, nil
+}
+
+// FindUserPurchaseTimes returns the creation times of the purchases by the
+// user after the time, excluding the cancelled purchases.
+func (r *PurchaseRepository) FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT created_at
+		FROM purchases
+		WHERE user_id = ? AND created_at > ? AND status <> ?
+		ORDER BY created_at`, userID.String(), since.UTC(), string(domain.PurchaseStatusCancelled))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+
+		times = append(times, t)
+	}
+
+	return times, rows.Err()
+}
+
+// FindUserProductPurchases returns the units of the product bought by the
+// user after the time, excluding the cancelled purchases.
+func (r *PurchaseRepository) FindUserProductPurchases(ctx context.Context, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error) {
+	return findUserProductPurchases(ctx, r.db, userID, productID, since)
+}
+
+// FindProduct returns usecase.ErrProductNotFound if the product does not
+// exist.
+func (r *PurchaseRepository) FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error) {
+	return findProduct(ctx, r.db, productID)
+}
+
+func (r *PurchaseRepository) FindProductDiscount(ctx context.Context, productID uuid.UUID) ([]domain.Discount, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+discountColumns+` FROM discounts WHERE product_id = ? ORDER BY id`, productID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ds []domain.Discount
+	for rows.Next() {
+		d, err := scanDiscount(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		ds = append(ds, *d)
+	}
+
+	return ds, rows.Err()
+}
+
+// FindDiscountByCouponCode returns usecase.ErrCouponUnknown if the code does
+// not exist.
+func (r *PurchaseRepository) FindDiscountByCouponCode(ctx context.Context, code string) (*domain.Discount, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+discountColumns+` FROM discounts WHERE coupon_code = ?`, code)
+
+	d, err := scanDiscount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, usecase.ErrCouponUnknown
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (r *PurchaseRepository) CountCouponRedemptions(ctx context.Context, code string, userID uuid.UUID) (*domain.CouponUsage, error) {
+	var usage domain.CouponUsage
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(CASE WHEN user_id = ? THEN 1 END)
+		FROM coupon_redemptions
+		WHERE code = ?`, userID.String(), code).Scan(&usage.Redemptions, &usage.UserRedemptions); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// reserveStock returns usecase.ErrProductOutOfStock if the product does not
+// have enough available units, or has no inventory.
+func reserveStock(ctx context.Context, q querier, productID uuid.UUID, unit int) error {
+	if unit <= 0 {
+		return domain.ErrNonPositiveUnit
+	}
+
+	// The condition is checked in the same statement, so concurrent
+	// reservations cannot oversell.
+	res, err := q.ExecContext(ctx, `
+		UPDATE inventories
+		SET reserved = reserved + ?
+		WHERE product_id = ? AND stock - reserved >= ?`, unit, productID.String(), unit)
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, usecase.ErrProductOutOfStock)
+}
+
+func releaseStock(ctx context.Context, q querier, productID uuid.UUID, unit int) error {
+	if unit <= 0 {
+		return domain.ErrNonPositiveUnit
+	}
+
+	res, err := q.ExecContext(ctx, `
+		UPDATE inventories
+		SET reserved = reserved - ?
+		WHERE product_id = ? AND reserved >= ?`, unit, productID.String(), unit)
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, domain.ErrInsufficientReserved)
+}
+
+// FindPurchase returns usecase.ErrPurchaseNotFound if the purchase does not
+// exist.
+func (r *PurchaseRepository) FindPurchase(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	return findPurchase(ctx, r.db, id)
+}
+
+// UpdatePurchase stores the purchase status and the purchase events in a
+// single transaction. It returns usecase.ErrPurchaseStatusInvalid if the
+// stored status is no longer the status before the transition.
+func (r *PurchaseRepository) UpdatePurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := updatePurchaseStatus(ctx, tx, purchase, purchase.PreviousStatus()); err != nil {
+			return err
+		}
+
+		return insertMessages(ctx, tx, msgs...)
+	})
+}
+
+func (r *PurchaseRepository) FindRefunds(ctx context.Context, purchaseID uuid.UUID) ([]domain.Refund, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, purchase_id, amount, currency, reason, created_at
+		FROM refunds
+		WHERE purchase_id = ?
+		ORDER BY created_at, id`, purchaseID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []domain.Refund
+	for rows.Next() {
+		ref, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		refunds = append(refunds, *ref)
+	}
+
+	return refunds, rows.Err()
+}
+
+// CreateRefund stores the refund, the purchase status and the purchase events
+// in a single transaction. It returns usecase.ErrRefundExceedsPaid if the
+// refunds total would exceed the purchase total, which is checked in the same
+// statement as the insert, so concurrent refunds cannot over-refund. It
+// returns usecase.ErrPurchaseStatusInvalid if the stored status is no longer
+// the status before the refund, or if the status does not match the refunds
+// total, e.g. when a concurrent partial refund completes the refund.
+func (r *PurchaseRepository) CreateRefund(ctx context.Context, refund domain.Refund, purchase domain.Purchase) error {
+	total, err := purchase.Total()
+	if err != nil {
+		return err
+	}
+
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO refunds (id, purchase_id, amount, currency, reason, created_at)
+			SELECT ?, ?, ?, ?, ?, ?
+			WHERE (
+				SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE purchase_id = ?
+			) + ? <= ?`,
+			refund.ID.String(),
+			refund.PurchaseID.String(),
+			refund.Amount.Amount,
+			string(refund.Amount.Currency),
+			refund.Reason,
+			refund.CreatedAt.UTC(),
+			refund.PurchaseID.String(),
+			refund.Amount.Amount,
+			total.Amount,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := mustAffect(res, usecase.ErrRefundExceedsPaid); err != nil {
+			return err
+		}
+
+		if err := updatePurchaseStatus(ctx, tx, purchase, purchase.PreviousStatus()); err != nil {
+			return err
+		}
+
+		var refunded int64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(SUM(amount), 0)
+			FROM refunds
+			WHERE purchase_id = ?`, refund.PurchaseID.String()).Scan(&refunded); err != nil {
+			return err
+		}
+
+		if (refunded == total.Amount) != (purchase.Status == domain.PurchaseStatusRefunded) {
+			return usecase.ErrPurchaseStatusInvalid
+		}
+
+		return insertMessages(ctx, tx, msgs...)
+	})
+}
+
+// PayPurchase stores the paid purchase, removes its reserved units from the
+// stock and stores the purchase events in a single transaction. It returns
+// usecase.ErrPurchaseStatusInvalid if the stored purchase is not pending.
+func (r *PurchaseRepository) PayPurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := updatePurchaseStatus(ctx, tx, purchase, domain.PurchaseStatusPending); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE inventories
+			SET stock = stock - ?, reserved = reserved - ?
+			WHERE product_id = ? AND reserved >= ?`, purchase.Unit, purchase.Unit, purchase.ProductID.String(), purchase.Unit)
+		if err != nil {
+			return err
+		}
+
+		if err := mustAffect(res, domain.ErrInsufficientReserved); err != nil {
+			return err
+		}
+
+		return insertMessages(ctx, tx, msgs...)
+	})
+}
+
+// CancelPurchase stores the cancelled purchase, releases its reserved units
+// and stores the purchase events in a single transaction. It returns
+// usecase.ErrPurchaseStatusInvalid if the stored purchase is not pending.
+func (r *PurchaseRepository) CancelPurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := updatePurchaseStatus(ctx, tx, purchase, domain.PurchaseStatusPending); err != nil {
+			return err
+		}
+
+		if err := releaseStock(ctx, tx, purchase.ProductID, purchase.Unit); err != nil {
+			return err
+		}
+
+		return insertMessages(ctx, tx, msgs...)
+	})
+}
+
+// CreatePurchase reserves the stock, and persists the purchase, the coupon
+// redemptions and the purchase events in a single transaction. It returns
+// usecase.ErrProductOutOfStock if there is not enough stock left,
+// usecase.ErrCouponExhausted if a coupon has been redeemed up to its caps
+// since it was checked, and domain.ErrPurchaseLimitExceeded if the purchase
+// limits of the product are exceeded.
+func (r *PurchaseRepository) CreatePurchase(ctx context.Context, purchase domain.Purchase) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		return createPurchase(ctx, tx, purchase, msgs)
+	})
+}
+
+// CreateIdempotentPurchase creates the purchase like CreatePurchase, and
+// stores its id on the claimed key in the same transaction. It returns
+// usecase.ErrIdempotencyKeyExpired if the claim has expired and the key has
+// been claimed again since.
+func (r *PurchaseRepository) CreateIdempotentPurchase(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey) error {
+	msgs, err := outbox.NewMessages(purchase.Events()...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := createPurchase(ctx, tx, purchase, msgs); err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, key, purchase.ID)
+	})
+}
+
+// CreateOrder persists the order and its purchases, reserves the stock for
+// every line, and stores the coupon redemptions and the purchase events in a
+// single transaction. Nothing is persisted when any line fails, e.g. with
+// usecase.ErrProductOutOfStock or domain.ErrPurchaseLimitExceeded.
+func (r *PurchaseRepository) CreateOrder(ctx context.Context, order domain.Order) error {
+	var events []domain.Event
+	for _, line := range order.Lines {
+		events = append(events, line.Events()...)
+	}
+
+	msgs, err := outbox.NewMessages(events...)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO orders (id, user_id, created_at)
+			VALUES (?, ?, ?)`, order.ID.String(), order.UserID.String(), order.CreatedAt.UTC()); err != nil {
+			return err
+		}
+
+		for _, line := range order.Lines {
+			if err := reserveStock(ctx, tx, line.ProductID, line.Unit); err != nil {
+				return err
+			}
+
+			if err := checkPurchaseLimits(ctx, tx, line); err != nil {
+				return err
+			}
+
+			if err := insertPurchase(ctx, tx, line); err != nil {
+				return err
+			}
+
+			for _, code := range line.CouponCodes {
+				if err := redeemCoupon(ctx, tx, code, line); err != nil {
+					return err
+				}
+			}
+		}
+
+		return insertMessages(ctx, tx, msgs...)
+	})
+}
+
+// redeemCoupon records the redemption only if the coupon caps are not
+// reached. The caps are checked in the same statement, so concurrent
+// purchases cannot over-redeem the coupon.
+func redeemCoupon(ctx context.Context, tx *sql.Tx, code string, purchase domain.Purchase) error {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO coupon_redemptions (code, user_id, purchase_id)
+		SELECT d.coupon_code, ?, ?
+		FROM discounts d
+		WHERE d.coupon_code = ?
+		AND (NOT d.coupon_single_use OR NOT EXISTS (
+			SELECT 1 FROM coupon_redemptions WHERE code = d.coupon_code
+		))
+		AND (d.coupon_max_redemptions = 0 OR (
+			SELECT COUNT(*) FROM coupon_redemptions WHERE code = d.coupon_code
+		) < d.coupon_max_redemptions)
+		AND (d.coupon_max_per_user = 0 OR (
+			SELECT COUNT(*) FROM coupon_redemptions WHERE code = d.coupon_code AND user_id = ?
+		) < d.coupon_max_per_user)`,
+		purchase.UserID.String(), purchase.ID.String(), code, purchase.UserID.String())
+	if err != nil {
+		return err
+	}
+
+	return mustAffect(res, usecase.ErrCouponExhausted)
+}
+
+func findUserProductPurchases(ctx context.Context, q querier, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT unit, created_at
+		FROM purchases
+		WHERE user_id = ? AND product_id = ? AND created_at > ? AND status <> ?
+		ORDER BY created_at`, userID.String(), productID.String(), since.UTC(), string(domain.PurchaseStatusCancelled))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchased []domain.PurchasedUnits
+	for rows.Next() {
+		var p domain.PurchasedUnits
+		if err := rows.Scan(&p.Unit, &p.At); err != nil {
+			return nil, err
+		}
+
+		purchased = append(purchased, p)
+	}
+
+	return purchased, rows.Err()
+}
+
+// checkPurchaseLimits checks the purchase limits of the product again in the
+// transaction, so that purchases stored since the limits were checked are
+// counted. A missing product has no limits to check.
+func checkPurchaseLimits(ctx context.Context, q querier, purchase domain.Purchase) error {
+	var text string
+	err := q.QueryRowContext(ctx, `
+		SELECT purchase_limits
+		FROM products
+		WHERE id = ?`, purchase.ProductID.String()).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var limits domain.PurchaseLimits
+	if err := json.Unmarshal([]byte(text), &limits); err != nil {
+		return err
+	}
+
+	since, ok := limits.Since(purchase.CreatedAt)
+	if !ok {
+		return nil
+	}
+
+	purchased, err := findUserProductPurchases(ctx, q, purchase.UserID, purchase.ProductID, since)
+	if err != nil {
+		return err
+	}
+
+	return limits.Check(purchase.Unit, purchased, purchase.CreatedAt)
+}
+
+func insertPurchase(ctx context.Context, q querier, p domain.Purchase) error {
+	applied, err := jsonText(p.AppliedDiscountIDs)
+	if err != nil {
+		return err
+	}
+
+	coupons, err := jsonText(p.CouponCodes)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO purchases (`+purchaseColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID.String(),
+		nullUUID(p.OrderID),
+		p.UserID.String(),
+		p.ProductID.String(),
+		string(p.BasePrice.Currency),
+		p.BasePrice.Amount,
+		p.Discount.Amount,
+		p.Unit,
+		p.Tax.Amount,
+		p.TaxRate,
+		p.TaxInclusive,
+		string(p.Status),
+		applied,
+		coupons,
+		p.CreatedAt.UTC(),
+		p.UpdatedAt.UTC(),
+	)
+
+	return err
+}
+
+// savePurchaseStatus returns usecase.ErrPurchaseNotFound if the purchase
+// does not exist.
+// updatePurchaseStatus saves the status of the purchase only if the stored
+// status is still the given status, so that concurrent transitions cannot
+// both succeed.
+func updatePurchaseStatus(ctx context.Context, q querier, p domain.Purchase, from domain.PurchaseStatus) error {
+	res, err := q.ExecContext(ctx, `
+		UPDATE purchases
+		SET status = ?, updated_at = ?
+		WHERE id = ? AND status = ?`, string(p.Status), p.UpdatedAt.UTC(), p.ID.String(), string(from))
+	if err != nil {
+		return err
+	}
+
+	if err := mustAffect(res, usecase.ErrPurchaseStatusInvalid); err != nil {
+		if _, findErr := findPurchase(ctx, q, p.ID); findErr != nil {
+			return findErr
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// createPurchase reserves the stock, and inserts the purchase, the coupon
+// redemptions and the messages.
+func createPurchase(ctx context.Context, tx *sql.Tx, purchase domain.Purchase, msgs []outbox.Message) error {
+	if err := reserveStock(ctx, tx, purchase.ProductID, purchase.Unit); err != nil {
+		return err
+	}
+
+	if err := checkPurchaseLimits(ctx, tx, purchase); err != nil {
+		return err
+	}
+
+	if err := insertPurchase(ctx, tx, purchase); err != nil {
+		return err
+	}
+
+	for _, code := range purchase.CouponCodes {
+		if err := redeemCoupon(ctx, tx, code, purchase); err != nil {
+			return err
+		}
+	}
+
+	return insertMessages(ctx, tx, msgs...)
+}
+
+// findPurchase returns usecase.ErrPurchaseNotFound if the purchase does not
+// exist.
+func findPurchase(ctx context.Context, q querier, id uuid.UUID) (*domain.Purchase, error) {
+	row := q.QueryRowContext(ctx, `SELECT `+purchaseColumns+` FROM purchases WHERE id = ?`, id.String())
+
+	p, err := scanPurchase(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, usecase.ErrPurchaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func scanPurchase(s scanner) (*domain.Purchase, error) {
+	var (
+		p                     domain.Purchase
+		id, userID, productID string
+		orderID               sql.NullString
+		currency              domain.Currency
+		base, discount, tax   int64
+		applied, coupons      string
+		createdAt, updatedAt  sql.NullTime
+	)
+
+	if err := s.Scan(
+		&id,
+		&orderID,
+		&userID,
+		&productID,
+		&currency,
+		&base,
+		&discount,
+		&p.Unit,
+		&tax,
+		&p.TaxRate,
+		&p.TaxInclusive,
+		&p.Status,
+		&applied,
+		&coupons,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+
+	if p.UserID, err = uuid.Parse(userID); err != nil {
+		return nil, err
+	}
+
+	if p.ProductID, err = uuid.Parse(productID); err != nil {
+		return nil, err
+	}
+
+	if p.OrderID, err = uuidPtr(orderID); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(applied), &p.AppliedDiscountIDs); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(coupons), &p.CouponCodes); err != nil {
+		return nil, err
+	}
+
+	p.BasePrice = domain.NewMoney(base, currency)
+	p.Discount = domain.NewMoney(discount, currency)
+	p.Tax = domain.NewMoney(tax, currency)
+	p.CreatedAt = createdAt.Time
+	p.UpdatedAt = updatedAt.Time
+
+	return &p, nil
+}
+
+func scanRefund(s scanner) (*domain.Refund, error) {
+	var (
+		r              domain.Refund
+		id, purchaseID string
+		amount         int64
+		currency       domain.Currency
+	)
+
+	if err := s.Scan(&id, &purchaseID, &amount, &currency, &r.Reason, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if r.ID, err = uuid.Parse(id); err != nil {
+		return nil, err
+	}
+
+	if r.PurchaseID, err = uuid.Parse(purchaseID); err != nil {
+		return nil, err
+	}
+
+	r.Amount = domain.NewMoney(amount, currency)
+
+	return &r, nil
+}
+
+func scanDiscount(s scanner) (*domain.Discount, error) {
+	var (
+		d                domain.Discount
+		productID        string
+		capAmount        sql.NullInt64
+		capCurrency      sql.NullString
+		startsAt, endsAt sql.NullTime
+		code             sql.NullString
+		coupon           domain.Coupon
+	)
+
+	if err := s.Scan(
+		&d.ID,
+		&productID,
+		&d.Name,
+		&d.Kind,
+		&d.Amount.Amount,
+		&d.Amount.Currency,
+		&d.Percent,
+		&d.Rounding,
+		&capAmount,
+		&capCurrency,
+		&d.MinPurchaseQty,
+		&d.Stacking,
+		&d.Group,
+		&d.Priority,
+		&startsAt,
+		&endsAt,
+		&code,
+		&coupon.SingleUse,
+		&coupon.MaxPerUser,
+		&coupon.MaxRedemptions,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if d.ProductID, err = uuid.Parse(productID); err != nil {
+		return nil, err
+	}
+
+	d.StartsAt = timePtr(startsAt)
+	d.EndsAt = timePtr(endsAt)
+
+	if capAmount.Valid {
+		d.Cap = &domain.Money{
+			Amount:   capAmount.Int64,
+			Currency: domain.Currency(capCurrency.String),
+		}
+	}
+
+	if code.Valid {
+		coupon.Code = code.String
+		d.Coupon = &coupon
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,1189 @@
+package sql_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/outbox"
+	sqlrepo "github.com/alextanhongpin/go-domain-test/repository/sql"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestMigrate(t *testing.T) {
+	db := newDB(t)
+
+	// Applied migrations are skipped.
+	assert.Nil(t, sqlrepo.Migrate(context.Background(), db))
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	// The deferred lock in the dsn is overridden.
+	db, err := sqlrepo.Open("file:" + filepath.Join(t.TempDir(), "test.db") + "?_txlock=deferred")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// The write lock is held by the first transaction since it began.
+	_, err = db.BeginTx(ctx, nil)
+	assert.NotNil(t, err)
+}
+
+func TestProductRepository(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewProductRepository(db)
+	userID := uuid.New()
+
+	as := assert.New(t)
+	p, err := repo.Create(ctx, "colorful socks", userID)
+	as.Nil(err)
+	as.Equal(domain.ProductName("colorful socks"), p.Name)
+	as.Equal(userID, p.UserID)
+
+	got, err := repo.FindByID(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(p, got)
+
+	as.Nil(p.Update("striped socks", domain.NewMoney(12, "MYR"), now))
+	as.Nil(p.SetPriceTiers(domain.PriceTiers{{MinQty: 10, Price: domain.NewMoney(10, "MYR")}}, now))
+	p.PullEvents()
+	as.Nil(repo.Update(ctx, *p, 0))
+	as.ErrorIs(repo.Update(ctx, *p, 0), usecase.ErrProductVersionConflict)
+
+	got, err = repo.FindByID(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(p, got)
+
+	// Soft deleted products are still found.
+	p.Delete(now)
+	p.PullEvents()
+	as.Nil(repo.Update(ctx, *p, 2))
+
+	got, err = repo.FindByID(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(p, got)
+
+	d := factories.NewDiscount()
+	d.ProductID = p.ID
+	seedDiscount(t, db, d)
+	seedStock(t, db, p.ID, 5)
+
+	n, err := repo.PurgeDeleted(ctx, now)
+	as.Nil(err)
+	as.Equal(0, n)
+
+	n, err = repo.PurgeDeleted(ctx, now.Add(time.Nanosecond))
+	as.Nil(err)
+	as.Equal(1, n)
+
+	// The discounts and inventories of the product are purged too.
+	var rows int
+	as.Nil(db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM discounts WHERE product_id = ?)
+		+ (SELECT COUNT(*) FROM inventories WHERE product_id = ?)`, p.ID.String(), p.ID.String()).Scan(&rows))
+	as.Equal(0, rows)
+
+	_, err = repo.FindByID(ctx, p.ID)
+	as.ErrorIs(err, usecase.ErrProductNotFound)
+	as.ErrorIs(repo.Update(ctx, *p, 3), usecase.ErrProductNotFound)
+}
+
+func TestProductRepositoryFindScheduled(t *testing.T) {
+	ctx := context.Background()
+	repo := sqlrepo.NewProductRepository(newDB(t))
+	from := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Minute)
+
+	schedule := func(publishAt time.Time, unpublishAt *time.Time) uuid.UUID {
+		t.Helper()
+
+		p, err := repo.Create(ctx, "colorful socks", uuid.New())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Schedule(publishAt, unpublishAt, from); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repo.Update(ctx, *p, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		return p.ID
+	}
+
+	published := schedule(from.Add(500*time.Millisecond), nil)
+	expired := schedule(from.Add(-time.Hour), types.Ptr(to))
+	_ = schedule(from, nil)                                          // At the start of the window.
+	_ = schedule(to.Add(time.Nanosecond), nil)                       // After the window.
+	_ = schedule(from.Add(-time.Hour), types.Ptr(to.Add(time.Hour))) // Published throughout.
+
+	deleted, err := repo.Create(ctx, "colorful socks", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deleted.Schedule(to, nil, from); err != nil {
+		t.Fatal(err)
+	}
+	deleted.Delete(from)
+	if err := repo.Update(ctx, *deleted, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	products, err := repo.FindScheduled(ctx, from, to)
+
+	as := assert.New(t)
+	as.Nil(err)
+
+	var ids []uuid.UUID
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	as.ElementsMatch([]uuid.UUID{published, expired}, ids)
+}
+
+func TestProductRepositoryScheduleWatermark(t *testing.T) {
+	ctx := context.Background()
+	repo := sqlrepo.NewProductRepository(newDB(t))
+	since := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	as := assert.New(t)
+	_, ok, err := repo.FindScheduleWatermark(ctx)
+	as.Nil(err)
+	as.False(ok)
+
+	for _, at := range []time.Time{since, since.Add(time.Hour)} {
+		as.Nil(repo.SaveScheduleWatermark(ctx, at))
+
+		got, ok, err := repo.FindScheduleWatermark(ctx)
+		as.Nil(err)
+		as.True(ok)
+		as.True(at.Equal(got))
+	}
+}
+
+func TestProductRepositoryList(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	repo := sqlrepo.NewProductRepository(newDB(t))
+	owner := uuid.New()
+
+	save := func(name string, price int64, fn func(p *domain.Product)) uuid.UUID {
+		t.Helper()
+
+		p, err := repo.Create(ctx, name, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Update(domain.ProductName(name), domain.NewMoney(price, "MYR"), now); err != nil {
+			t.Fatal(err)
+		}
+		fn(p)
+		if err := repo.Update(ctx, *p, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		return p.ID
+	}
+
+	publish := func(p *domain.Product) {
+		_ = p.Schedule(now.Add(-time.Hour), nil, now)
+	}
+
+	socks := save("socks", 10, publish)
+	shirt := save("shirt", 30, publish)
+	shoes := save("shoes", 20, publish)
+	draft := save("scarf", 40, func(*domain.Product) {})
+	_ = save("sandals", 50, func(p *domain.Product) { // Expired.
+		_ = p.Schedule(now.Add(-time.Hour), types.Ptr(now), now)
+	})
+	_ = save("slippers", 60, func(p *domain.Product) {
+		publish(p)
+		p.Delete(now)
+	})
+
+	list := func(q usecase.ProductQuery) []uuid.UUID {
+		t.Helper()
+
+		if q.Limit == 0 {
+			q.Limit = 10
+		}
+		q.At = now
+
+		products, err := repo.List(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := []uuid.UUID{}
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+
+		return ids
+	}
+
+	as := assert.New(t)
+	as.Equal([]uuid.UUID{shirt, shoes, socks}, list(usecase.ProductQuery{}))
+	as.Equal([]uuid.UUID{draft}, list(usecase.ProductQuery{
+		VisibleTo:     owner,
+		ProductFilter: usecase.ProductFilter{Published: types.Ptr(false), NamePrefix: "sc"},
+	}))
+	as.Equal([]uuid.UUID{socks, shoes}, list(usecase.ProductQuery{
+		Sort:          usecase.ProductSortPriceAsc,
+		ProductFilter: usecase.ProductFilter{MaxPrice: types.Ptr(domain.NewMoney(20, "MYR"))},
+	}))
+	as.Equal([]uuid.UUID{shoes, socks}, list(usecase.ProductQuery{
+		Sort:          usecase.ProductSortPriceDesc,
+		ProductFilter: usecase.ProductFilter{MinPrice: types.Ptr(domain.NewMoney(10, "MYR")), MaxPrice: types.Ptr(domain.NewMoney(20, "MYR"))},
+	}))
+	as.Empty(list(usecase.ProductQuery{
+		ProductFilter: usecase.ProductFilter{MinPrice: types.Ptr(domain.NewMoney(0, "SGD"))},
+	}))
+	as.Empty(list(usecase.ProductQuery{
+		ProductFilter: usecase.ProductFilter{OwnerID: types.Ptr(uuid.New())},
+	}))
+
+	// Pages continue after the cursor.
+	as.Equal([]uuid.UUID{shirt}, list(usecase.ProductQuery{Limit: 1}))
+	as.Equal([]uuid.UUID{shoes, socks}, list(usecase.ProductQuery{
+		After: &usecase.ProductCursor{Sort: usecase.ProductSortName, Name: "shirt", ID: shirt},
+	}))
+	as.Equal([]uuid.UUID{socks}, list(usecase.ProductQuery{
+		Sort:  usecase.ProductSortPriceDesc,
+		After: &usecase.ProductCursor{Sort: usecase.ProductSortPriceDesc, Currency: "MYR", Price: 20, ID: shoes},
+	}))
+
+	// The prices are sorted by currency first.
+	hat := save("hat", 1, func(p *domain.Product) {
+		p.Price = domain.NewMoney(1, "USD")
+		publish(p)
+	})
+	as.Equal([]uuid.UUID{socks, shoes, shirt, hat}, list(usecase.ProductQuery{Sort: usecase.ProductSortPriceAsc}))
+	as.Equal([]uuid.UUID{shirt, shoes, socks, hat}, list(usecase.ProductQuery{Sort: usecase.ProductSortPriceDesc}))
+	as.Equal([]uuid.UUID{hat}, list(usecase.ProductQuery{
+		Sort:  usecase.ProductSortPriceAsc,
+		After: &usecase.ProductCursor{Sort: usecase.ProductSortPriceAsc, Currency: "MYR", Price: 30, ID: shirt},
+	}))
+}
+
+func TestPurchaseRepositoryFindUser(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	user := factories.NewUser("banned", "adult")
+	user.BannedUntil = types.Ptr(user.BannedUntil.UTC().Truncate(time.Second))
+	user.BirthDate = types.Ptr(user.BirthDate.UTC().Truncate(time.Second))
+	seedUser(t, db, user)
+
+	as := assert.New(t)
+	got, err := repo.FindUser(ctx, user.ID)
+	as.Nil(err)
+	as.Equal(user, got)
+
+	_, err = repo.FindUser(ctx, uuid.New())
+	as.ErrorIs(err, usecase.ErrUserIneligible)
+}
+
+func TestPurchaseRepositoryFindUserPurchaseTimes(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	recent := factories.NewPurchase()
+	recent.CreatedAt = now.Add(-time.Hour)
+
+	old := factories.NewPurchase("paid")
+	old.CreatedAt = now.Add(-48 * time.Hour)
+
+	cancelled := factories.NewPurchase("cancelled")
+	cancelled.CreatedAt = now.Add(-time.Hour)
+
+	other := factories.NewPurchase()
+	other.UserID = uuid.New()
+	other.CreatedAt = now.Add(-time.Hour)
+
+	as := assert.New(t)
+	for _, p := range []*domain.Purchase{recent, old, cancelled, other} {
+		seedStock(t, db, p.ProductID, p.Unit)
+		as.Nil(repo.CreatePurchase(ctx, *p))
+	}
+
+	times, err := repo.FindUserPurchaseTimes(ctx, recent.UserID, now.Add(-24*time.Hour))
+	as.Nil(err)
+	if as.Len(times, 1) {
+		as.True(recent.CreatedAt.Equal(times[0]))
+	}
+}
+
+func TestPurchaseRepositoryFindUserProductPurchases(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	recent := factories.NewPurchase()
+	recent.CreatedAt = now.Add(-time.Hour)
+
+	old := factories.NewPurchase("paid")
+	old.ProductID = recent.ProductID
+	old.CreatedAt = now.Add(-48 * time.Hour)
+
+	cancelled := factories.NewPurchase("cancelled")
+	cancelled.ProductID = recent.ProductID
+	cancelled.CreatedAt = now.Add(-time.Hour)
+
+	other := factories.NewPurchase()
+	other.CreatedAt = now.Add(-time.Hour)
+
+	as := assert.New(t)
+	for _, p := range []*domain.Purchase{recent, old, cancelled, other} {
+		seedStock(t, db, p.ProductID, p.Unit)
+		as.Nil(repo.CreatePurchase(ctx, *p))
+	}
+
+	purchased, err := repo.FindUserProductPurchases(ctx, recent.UserID, recent.ProductID, now.Add(-24*time.Hour))
+	as.Nil(err)
+	if as.Len(purchased, 1) {
+		as.Equal(recent.Unit, purchased[0].Unit)
+		as.True(recent.CreatedAt.Equal(purchased[0].At))
+	}
+
+	// The zero time counts all the purchases.
+	purchased, err = repo.FindUserProductPurchases(ctx, recent.UserID, recent.ProductID, time.Time{})
+	as.Nil(err)
+	as.Len(purchased, 2)
+}
+
+func TestPurchaseRepositoryFindProduct(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewProduct("tiered", "limited")
+	p.PublishedAt = types.Ptr(p.PublishedAt.UTC().Truncate(time.Second))
+	p.TaxCategory = "standard"
+	seedProduct(t, db, p)
+
+	as := assert.New(t)
+	got, err := repo.FindProduct(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(p, got)
+
+	_, err = repo.FindProduct(ctx, uuid.New())
+	as.ErrorIs(err, usecase.ErrProductNotFound)
+}
+
+func TestPurchaseRepositoryDiscount(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewProduct()
+
+	fixed := factories.NewDiscount()
+	fixed.ProductID = p.ID
+
+	capped := factories.NewDiscount("percentage", "capped", "expired")
+	capped.ID = 2
+	capped.ProductID = p.ID
+	capped.StartsAt = types.Ptr(capped.StartsAt.UTC().Truncate(time.Second))
+	capped.EndsAt = types.Ptr(capped.EndsAt.UTC().Truncate(time.Second))
+
+	coupon := factories.NewDiscount("coupon")
+	coupon.ID = 3
+	coupon.ProductID = p.ID
+
+	for _, d := range []*domain.Discount{fixed, capped, coupon} {
+		seedDiscount(t, db, d)
+	}
+
+	as := assert.New(t)
+	ds, err := repo.FindProductDiscount(ctx, p.ID)
+	as.Nil(err)
+	as.Equal([]domain.Discount{*fixed, *capped, *coupon}, ds)
+
+	d, err := repo.FindDiscountByCouponCode(ctx, coupon.Coupon.Code)
+	as.Nil(err)
+	as.Equal(coupon, d)
+
+	_, err = repo.FindDiscountByCouponCode(ctx, "UNKNOWN")
+	as.ErrorIs(err, usecase.ErrCouponUnknown)
+}
+
+func TestPurchaseRepositoryCreatePurchaseStock(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewPurchase()
+	seedStock(t, db, p.ProductID, 3)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	var reserved int
+	as.Nil(db.QueryRow(`SELECT reserved FROM inventories WHERE product_id = ?`, p.ProductID.String()).Scan(&reserved))
+	as.Equal(2, reserved)
+
+	// Nothing is persisted when the stock cannot be reserved.
+	other := factories.NewPurchase()
+	other.ProductID = p.ProductID
+	as.ErrorIs(repo.CreatePurchase(ctx, *other), usecase.ErrProductOutOfStock)
+
+	_, err := repo.FindPurchase(ctx, other.ID)
+	as.ErrorIs(err, usecase.ErrPurchaseNotFound)
+
+	as.ErrorIs(repo.CreatePurchase(ctx, *factories.NewPurchase()), usecase.ErrProductOutOfStock)
+
+	other.Unit = 0
+	as.ErrorIs(repo.CreatePurchase(ctx, *other), domain.ErrNonPositiveUnit)
+}
+
+func TestPurchaseRepositoryCreatePurchaseStockConcurrency(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	productID := uuid.New()
+	seedStock(t, db, productID, 10)
+
+	n := 20
+	errs := make(chan error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			p := factories.NewPurchase()
+			p.ProductID = productID
+			p.Unit = 1
+			errs <- repo.CreatePurchase(ctx, *p)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var reserved, outOfStock int
+	for err := range errs {
+		switch err {
+		case nil:
+			reserved++
+		case usecase.ErrProductOutOfStock:
+			outOfStock++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	as := assert.New(t)
+	as.Equal(10, reserved)
+	as.Equal(10, outOfStock)
+}
+
+func TestPurchaseRepositoryCreatePurchase(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewPurchase()
+	p.CouponCodes = []string{"SAVE5"}
+
+	d := factories.NewDiscount("coupon")
+	d.ProductID = p.ProductID
+	seedDiscount(t, db, d)
+	seedStock(t, db, p.ProductID, 5)
+
+	as := assert.New(t)
+	as.Nil(p.MarkCreated(time.Now()))
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	usage, err := repo.CountCouponRedemptions(ctx, "SAVE5", p.UserID)
+	as.Nil(err)
+	as.Equal(&domain.CouponUsage{Redemptions: 1, UserRedemptions: 1}, usage)
+
+	usage, err = repo.CountCouponRedemptions(ctx, "SAVE5", uuid.New())
+	as.Nil(err)
+	as.Equal(&domain.CouponUsage{Redemptions: 1}, usage)
+
+	// The events are stored in the outbox.
+	msgs, err := sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	if as.Len(msgs, 1) {
+		as.Equal("purchase.created", msgs[0].Name)
+
+		var evt domain.PurchaseCreated
+		as.Nil(json.Unmarshal(msgs[0].Payload, &evt))
+		as.Equal(p.ID, evt.PurchaseID)
+	}
+
+	// Nothing is persisted when the transaction fails.
+	as.NotNil(repo.CreatePurchase(ctx, *p))
+	msgs, err = sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Len(msgs, 1)
+}
+
+func TestPurchaseRepositoryCreatePurchaseLimits(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewProduct("limited")
+	seedProduct(t, db, p)
+	seedStock(t, db, p.ID, 5)
+
+	now := time.Now()
+	first, second := factories.NewPurchase(), factories.NewPurchase()
+	for _, purchase := range []*domain.Purchase{first, second} {
+		purchase.ProductID = p.ID
+		purchase.Unit = 1
+		purchase.CreatedAt = now
+	}
+
+	// Both purchases passed the check before either was stored, so only the
+	// check in the transaction stops the second one.
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *first))
+
+	var limitErr *domain.PurchaseLimitExceededError
+	if as.ErrorAs(repo.CreatePurchase(ctx, *second), &limitErr) {
+		as.Equal(0, limitErr.Remaining)
+	}
+
+	order, err := domain.NewOrder(second.UserID, []domain.Purchase{*second}, now)
+	as.Nil(err)
+	as.ErrorIs(repo.CreateOrder(ctx, *order), domain.ErrPurchaseLimitExceeded)
+
+	purchased, err := repo.FindUserProductPurchases(ctx, first.UserID, p.ID, time.Time{})
+	as.Nil(err)
+	as.Len(purchased, 1)
+}
+
+func TestPurchaseRepositoryPayPurchase(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewPurchase()
+	seedStock(t, db, p.ProductID, 5)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	now := time.Now()
+	as.Nil(p.MarkPaid(now))
+	as.Nil(repo.PayPurchase(ctx, *p))
+
+	// The reserved units are removed from the stock.
+	var stock, reserved int
+	as.Nil(db.QueryRow(`SELECT stock, reserved FROM inventories WHERE product_id = ?`, p.ProductID.String()).Scan(&stock, &reserved))
+	as.Equal(3, stock)
+	as.Equal(0, reserved)
+
+	var status string
+	as.Nil(db.QueryRow(`SELECT status FROM purchases WHERE id = ?`, p.ID.String()).Scan(&status))
+	as.Equal(string(domain.PurchaseStatusPaid), status)
+
+	msgs, err := sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	if as.Len(msgs, 1) {
+		as.Equal("purchase.status_changed", msgs[0].Name)
+	}
+
+	// The stock is committed only once.
+	as.ErrorIs(repo.PayPurchase(ctx, *p), usecase.ErrPurchaseStatusInvalid)
+
+	unknown := factories.NewPurchase("paid")
+	as.ErrorIs(repo.PayPurchase(ctx, *unknown), usecase.ErrPurchaseNotFound)
+}
+
+func TestPurchaseRepositoryCreateOrder(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	socks, shirt := factories.NewPurchase(), factories.NewPurchase()
+	seedStock(t, db, socks.ProductID, 5)
+	seedStock(t, db, shirt.ProductID, 1)
+
+	order, err := domain.NewOrder(socks.UserID, []domain.Purchase{*socks, *shirt}, time.Now())
+
+	as := assert.New(t)
+	as.Nil(err)
+
+	// Nothing is persisted when any line is out of stock.
+	as.ErrorIs(repo.CreateOrder(ctx, *order), usecase.ErrProductOutOfStock)
+
+	var n int
+	as.Nil(db.QueryRow(`SELECT COUNT(*) FROM purchases`).Scan(&n))
+	as.Equal(0, n)
+
+	var reserved int
+	as.Nil(db.QueryRow(`SELECT reserved FROM inventories WHERE product_id = ?`, socks.ProductID.String()).Scan(&reserved))
+	as.Equal(0, reserved)
+
+	exec(t, db, `UPDATE inventories SET stock = 5 WHERE product_id = ?`, shirt.ProductID.String())
+	as.Nil(repo.CreateOrder(ctx, *order))
+
+	for _, line := range order.Lines {
+		got, err := repo.FindPurchase(ctx, line.ID)
+		as.Nil(err)
+		as.Equal(&order.ID, got.OrderID)
+
+		as.Nil(db.QueryRow(`SELECT reserved FROM inventories WHERE product_id = ?`, line.ProductID.String()).Scan(&reserved))
+		as.Equal(line.Unit, reserved)
+	}
+
+	msgs, err := sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Len(msgs, 2)
+}
+
+func TestPurchaseRepositoryCancelPurchase(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewPurchase()
+	seedStock(t, db, p.ProductID, 5)
+
+	as := assert.New(t)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	as.Nil(p.Cancel(time.Now()))
+	as.Nil(repo.CancelPurchase(ctx, *p))
+
+	// The reserved units are returned.
+	var stock, reserved int
+	as.Nil(db.QueryRow(`SELECT stock, reserved FROM inventories WHERE product_id = ?`, p.ProductID.String()).Scan(&stock, &reserved))
+	as.Equal(5, stock)
+	as.Equal(0, reserved)
+
+	msgs, err := sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	if as.Len(msgs, 1) {
+		as.Equal("purchase.status_changed", msgs[0].Name)
+	}
+
+	// Nothing is persisted when the stock cannot be released.
+	other := factories.NewPurchase()
+	seedStock(t, db, other.ProductID, other.Unit)
+	as.Nil(repo.CreatePurchase(ctx, *other))
+	exec(t, db, `UPDATE inventories SET reserved = 0 WHERE product_id = ?`, other.ProductID.String())
+	as.Nil(other.Cancel(time.Now()))
+	as.ErrorIs(repo.CancelPurchase(ctx, *other), domain.ErrInsufficientReserved)
+
+	var status string
+	as.Nil(db.QueryRow(`SELECT status FROM purchases WHERE id = ?`, other.ID.String()).Scan(&status))
+	as.Equal(string(domain.PurchaseStatusPending), status)
+
+	as.ErrorIs(repo.CancelPurchase(ctx, *p), usecase.ErrPurchaseStatusInvalid)
+}
+
+func TestPurchaseRepositoryUpdatePurchase(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	p := factories.NewPurchase("paid")
+
+	as := assert.New(t)
+	seedStock(t, db, p.ProductID, p.Unit)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	got, err := repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(domain.PurchaseStatusPaid, got.Status)
+	as.Equal(p.Unit, got.Unit)
+
+	as.Nil(got.Fulfill(time.Now()))
+	as.Nil(repo.UpdatePurchase(ctx, *got))
+
+	got, err = repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(domain.PurchaseStatusFulfilled, got.Status)
+
+	msgs, err := sqlrepo.NewOutboxStore(db).Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Len(msgs, 1)
+
+	_, err = repo.FindPurchase(ctx, uuid.New())
+	as.ErrorIs(err, usecase.ErrPurchaseNotFound)
+	as.ErrorIs(repo.UpdatePurchase(ctx, *factories.NewPurchase()), usecase.ErrPurchaseNotFound)
+}
+
+func TestPurchaseRepositoryCreateRefund(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	p := factories.NewPurchase("paid")
+
+	as := assert.New(t)
+	seedStock(t, db, p.ProductID, p.Unit)
+	as.Nil(repo.CreatePurchase(ctx, *p))
+
+	got, err := repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+
+	refund, err := got.IssueRefund(types.Ptr(domain.NewMoney(4, "MYR")), "damaged", nil, now)
+	as.Nil(err)
+	as.Nil(repo.CreateRefund(ctx, *refund, *got))
+
+	refunds, err := repo.FindRefunds(ctx, p.ID)
+	as.Nil(err)
+	as.Equal([]domain.Refund{*refund}, refunds)
+
+	// The refund is checked against the stored refunds, not the stale ones.
+	stale, err := p.IssueRefund(types.Ptr(domain.NewMoney(8, "MYR")), "", nil, now)
+	as.Nil(err)
+	as.ErrorIs(repo.CreateRefund(ctx, *stale, *p), usecase.ErrRefundExceedsPaid)
+
+	got, err = repo.FindPurchase(ctx, p.ID)
+	as.Nil(err)
+	as.Equal(domain.PurchaseStatusPartiallyRefunded, got.Status)
+
+	refunds, err = repo.FindRefunds(ctx, p.ID)
+	as.Nil(err)
+	as.Len(refunds, 1)
+}
+
+func TestPurchaseRepositoryStatusConcurrency(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// race runs the changes concurrently, each on its own copy of the stored
+	// purchase and refunds, and returns their errors.
+	race := func(id uuid.UUID, changes ...func(p *domain.Purchase, refunds []domain.Refund) error) []error {
+		refunds, err := repo.FindRefunds(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		purchases := make([]*domain.Purchase, len(changes))
+		for i := range changes {
+			purchases[i], err = repo.FindPurchase(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		errs := make([]error, len(changes))
+
+		var wg sync.WaitGroup
+		wg.Add(len(changes))
+		for i, change := range changes {
+			go func(i int, change func(*domain.Purchase, []domain.Refund) error) {
+				defer wg.Done()
+
+				errs[i] = change(purchases[i], refunds)
+			}(i, change)
+		}
+		wg.Wait()
+
+		return errs
+	}
+
+	refund := func(amount int64) func(p *domain.Purchase, refunds []domain.Refund) error {
+		return func(p *domain.Purchase, refunds []domain.Refund) error {
+			ref, err := p.IssueRefund(types.Ptr(domain.NewMoney(amount, "MYR")), "", refunds, now)
+			if err != nil {
+				return err
+			}
+
+			return repo.CreateRefund(ctx, *ref, *p)
+		}
+	}
+
+	// succeeded returns the number of nil errors, and fails on any error
+	// other than the conflict.
+	succeeded := func(errs []error) int {
+		var n int
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				n++
+			case errors.Is(err, usecase.ErrPurchaseStatusInvalid):
+			default:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		return n
+	}
+
+	t.Run("fulfill and refund", func(t *testing.T) {
+		p := factories.NewPurchase("paid")
+		seedStock(t, db, p.ProductID, p.Unit)
+		if err := repo.CreatePurchase(ctx, *p); err != nil {
+			t.Fatal(err)
+		}
+
+		errs := race(p.ID, func(p *domain.Purchase, _ []domain.Refund) error {
+			if err := p.Fulfill(now); err != nil {
+				return err
+			}
+
+			return repo.UpdatePurchase(ctx, *p)
+		}, refund(10))
+
+		as := assert.New(t)
+		as.Equal(1, succeeded(errs))
+
+		got, err := repo.FindPurchase(ctx, p.ID)
+		as.Nil(err)
+
+		refunds, err := repo.FindRefunds(ctx, p.ID)
+		as.Nil(err)
+		if errs[0] == nil {
+			as.Equal(domain.PurchaseStatusFulfilled, got.Status)
+			as.Empty(refunds)
+		} else {
+			as.Equal(domain.PurchaseStatusRefunded, got.Status)
+			as.Len(refunds, 1)
+		}
+	})
+
+	t.Run("partial refunds that add up to the total", func(t *testing.T) {
+		p := factories.NewPurchase("paid")
+		seedStock(t, db, p.ProductID, p.Unit)
+		if err := repo.CreatePurchase(ctx, *p); err != nil {
+			t.Fatal(err)
+		}
+
+		as := assert.New(t)
+		as.Equal(1, succeeded(race(p.ID, refund(5), refund(5))))
+		as.Equal(1, succeeded(race(p.ID, refund(4), refund(1))))
+
+		got, err := repo.FindPurchase(ctx, p.ID)
+		as.Nil(err)
+		as.Equal(domain.PurchaseStatusPartiallyRefunded, got.Status)
+
+		refunds, err := repo.FindRefunds(ctx, p.ID)
+		as.Nil(err)
+
+		refundable, err := got.RefundableAmount(refunds)
+		as.Nil(err)
+		as.True(refundable.Amount > 0)
+	})
+}
+
+func TestPurchaseRepositoryCreatePurchaseCouponConcurrency(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	d := factories.NewDiscount("coupon")
+	d.Coupon.SingleUse = true
+	seedDiscount(t, db, d)
+
+	n := 20
+	errs := make(chan error, n)
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			p := factories.NewPurchase()
+			p.UserID = uuid.New()
+			p.CouponCodes = []string{d.Coupon.Code}
+			seedStock(t, db, p.ProductID, p.Unit)
+			errs <- repo.CreatePurchase(ctx, *p)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var redeemed, exhausted int
+	for err := range errs {
+		switch err {
+		case nil:
+			redeemed++
+		case usecase.ErrCouponExhausted:
+			exhausted++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	as := assert.New(t)
+	as.Equal(1, redeemed)
+	as.Equal(n-1, exhausted)
+
+	usage, err := repo.CountCouponRedemptions(ctx, d.Coupon.Code, uuid.New())
+	as.Nil(err)
+	as.Equal(1, usage.Redemptions)
+}
+
+func TestOutboxStore(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	store := sqlrepo.NewOutboxStore(db)
+	repo := sqlrepo.NewPurchaseRepository(db)
+
+	as := assert.New(t)
+	for i := 0; i < 3; i++ {
+		p := factories.NewPurchase()
+		as.Nil(p.MarkCreated(time.Now()))
+		seedStock(t, db, p.ProductID, p.Unit)
+		as.Nil(repo.CreatePurchase(ctx, *p))
+	}
+
+	msgs, err := store.Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Len(msgs, 3)
+
+	as.Nil(store.MarkFailed(ctx, msgs[0].ID, "publish error"))
+	as.ErrorIs(store.MarkFailed(ctx, uuid.New(), "publish error"), outbox.ErrMessageNotFound)
+
+	failed, err := store.Undelivered(ctx, 1)
+	as.Nil(err)
+	if as.Len(failed, 1) {
+		as.Equal(msgs[0].ID, failed[0].ID)
+		as.Equal(1, failed[0].Attempts)
+		as.Equal("publish error", failed[0].LastError)
+	}
+
+	as.Nil(store.MarkDelivered(ctx, msgs[0].ID, time.Now()))
+	as.ErrorIs(store.MarkDelivered(ctx, uuid.New(), time.Now()), outbox.ErrMessageNotFound)
+
+	rest, err := store.Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Equal(msgs[1:], rest)
+
+	// The dead messages are no longer relayed.
+	as.Nil(store.MarkDead(ctx, msgs[1].ID, "publish error", time.Now()))
+	as.ErrorIs(store.MarkDead(ctx, uuid.New(), "publish error", time.Now()), outbox.ErrMessageNotFound)
+
+	rest, err = store.Undelivered(ctx, 10)
+	as.Nil(err)
+	as.Equal(msgs[2:], rest)
+}
+
+func TestPurchaseUsecase(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	user := factories.NewUser()
+	seedUser(t, db, user)
+
+	p := factories.NewProduct("published")
+	seedProduct(t, db, p)
+	seedStock(t, db, p.ID, 2)
+
+	d := factories.NewDiscount()
+	d.ProductID = p.ID
+	seedDiscount(t, db, d)
+
+	uc := usecase.NewPurchaseUsecase(sqlrepo.NewPurchaseRepository(db), sqlrepo.NewIdempotencyRepository(db))
+	dto := usecase.PurchaseDto{
+		ProductID:      p.ID,
+		UserID:         user.ID,
+		Unit:           2,
+		IdempotencyKey: "order-1",
+	}
+
+	as := assert.New(t)
+	purchase, err := uc.Purchase(ctx, dto)
+	as.Nil(err)
+	as.Equal(domain.NewMoney(-5, "MYR"), purchase.Discount)
+
+	// The retry returns the original purchase.
+	retry, err := uc.Purchase(ctx, dto)
+	as.Nil(err)
+	as.Equal(purchase.ID, retry.ID)
+	as.Equal(purchase.Discount, retry.Discount)
+
+	dto.IdempotencyKey = "order-2"
+	_, err = uc.Purchase(ctx, dto)
+	as.ErrorIs(err, usecase.ErrProductOutOfStock)
+
+	// The key is released on failure.
+	_, err = uc.Purchase(ctx, dto)
+	as.ErrorIs(err, usecase.ErrProductOutOfStock)
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := sqlrepo.NewIdempotencyRepository(db)
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	key := domain.IdempotencyKey{
+		UserID:      uuid.New(),
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   now,
+	}
+
+	as := assert.New(t)
+	_, created, err := repo.LockIdempotencyKey(ctx, key)
+	as.Nil(err)
+	as.True(created)
+
+	// Keys are scoped to the user.
+	other := key
+	other.UserID = uuid.New()
+	_, created, err = repo.LockIdempotencyKey(ctx, other)
+	as.Nil(err)
+	as.True(created)
+
+	retry := key
+	retry.CreatedAt = now.Add(time.Second)
+	existing, created, err := repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.False(created)
+	as.False(existing.IsCompleted())
+
+	// The key is claimed again when it is stuck in progress.
+	retry.CreatedAt = now.Add(domain.IdempotencyKeyLockTimeout)
+	_, created, err = repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.True(created)
+
+	// The expired claim can no longer release or complete the key, and the
+	// purchase is not created.
+	p := factories.NewPurchase()
+	seedStock(t, db, p.ProductID, p.Unit)
+	purchaseRepo := sqlrepo.NewPurchaseRepository(db)
+	as.Nil(repo.DeleteIdempotencyKey(ctx, key))
+	as.ErrorIs(purchaseRepo.CreateIdempotentPurchase(ctx, *p, key), usecase.ErrIdempotencyKeyExpired)
+
+	_, err = purchaseRepo.FindPurchase(ctx, p.ID)
+	as.ErrorIs(err, usecase.ErrPurchaseNotFound)
+
+	existing, created, err = repo.LockIdempotencyKey(ctx, retry)
+	as.Nil(err)
+	as.False(created)
+	as.False(existing.IsCompleted())
+
+	as.Nil(purchaseRepo.CreateIdempotentPurchase(ctx, *p, retry))
+	existing, created, err = repo.LockIdempotencyKey(ctx, key)
+	as.Nil(err)
+	as.False(created)
+	if as.True(existing.IsCompleted()) {
+		as.Equal(p.ID, existing.Purchase.ID)
+	}
+}
+
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sqlrepo.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	if err := sqlrepo.Migrate(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func seedUser(t *testing.T, db *sql.DB, u *domain.User) {
+	t.Helper()
+
+	exec(t, db, `INSERT INTO users (id, name, status, banned_until, birth_date) VALUES (?, ?, ?, ?, ?)`,
+		u.ID.String(), u.Name, string(u.Status), u.BannedUntil, u.BirthDate)
+}
+
+func seedProduct(t *testing.T, db *sql.DB, p *domain.Product) {
+	t.Helper()
+
+	tiers, err := json.Marshal(p.PriceTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits, err := json.Marshal(p.PurchaseLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exec(t, db, `
+		INSERT INTO products (id, name, user_id, published_at, price_amount, price_currency, price_tiers, tax_category, min_age, purchase_limits)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID.String(), string(p.Name), p.UserID.String(), p.PublishedAt, p.Price.Amount, string(p.Price.Currency), string(tiers), string(p.TaxCategory), p.MinAge, string(limits))
+}
+
+func seedStock(t *testing.T, db *sql.DB, productID uuid.UUID, stock int) {
+	t.Helper()
+
+	exec(t, db, `
+		INSERT INTO inventories (product_id, stock) VALUES (?, ?)
+		ON CONFLICT (product_id) DO UPDATE SET stock = stock + excluded.stock`, productID.String(), stock)
+}
+
+func seedDiscount(t *testing.T, db *sql.DB, d *domain.Discount) {
+	t.Helper()
+
+	var capAmount, capCurrency, code any
+	if d.Cap != nil {
+		capAmount, capCurrency = d.Cap.Amount, string(d.Cap.Currency)
+	}
+
+	var coupon domain.Coupon
+	if d.Coupon != nil {
+		coupon, code = *d.Coupon, d.Coupon.Code
+	}
+
+	exec(t, db, `
+		INSERT INTO discounts (
+			id, product_id, name, kind, amount, currency, percent, rounding,
+			cap_amount, cap_currency, min_purchase_qty, stacking, group_name, priority,
+			starts_at, ends_at, coupon_code, coupon_single_use, coupon_max_per_user,
+			coupon_max_redemptions
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.ID, d.ProductID.String(), d.Name, string(d.Kind), d.Amount.Amount, string(d.Amount.Currency), d.Percent, string(d.Rounding),
+		capAmount, capCurrency, d.MinPurchaseQty, string(d.Stacking), d.Group, d.Priority,
+		d.StartsAt, d.EndsAt, code, coupon.SingleUse, coupon.MaxPerUser,
+		coupon.MaxRedemptions)
+}
+
+func exec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+package sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// nullTime stores the time in UTC, or NULL when t is nil.
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+func nullUUID(id *uuid.UUID) sql.NullString {
+	if id == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: id.String(), Valid: true}
+}
+
+func uuidPtr(s sql.NullString) (*uuid.UUID, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(s.String)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// jsonText encodes v as a JSON column.
+func jsonText(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// mustAffect returns err when no rows are affected.
+func mustAffect(res sql.Result, err error) error {
+	n, rowsErr := res.RowsAffected()
+	if rowsErr != nil {
+		return rowsErr
+	}
+
+	if n == 0 {
+		return err
+	}
+
+	return nil
+}
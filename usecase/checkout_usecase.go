@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type checkoutRepository interface {
+	// FindUser returns ErrUserIneligible if the user does not exist.
+	FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	// FindUserPurchaseTimes returns the creation times of the purchases by
+	// the user after the time, excluding the cancelled purchases.
+	FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error)
+	FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error)
+	// FindUserProductPurchases returns the units of the product bought by the
+	// user after the time, excluding the cancelled purchases.
+	FindUserProductPurchases(ctx context.Context, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error)
+	FindProductDiscount(ctx context.Context, productID uuid.UUID) ([]domain.Discount, error)
+	// CreateOrder persists the order and reserves the stock for every line in
+	// a single transaction. Nothing is persisted when any line fails, e.g.
+	// with ErrProductOutOfStock or domain.ErrPurchaseLimitExceeded. The
+	// events of every line are stored in the outbox in the same transaction.
+	CreateOrder(ctx context.Context, order domain.Order) error
+}
+
+type CheckoutUsecase struct {
+	repo checkoutRepository
+	svc  *domain.ProductService
+}
+
+// NewCheckoutUsecase returns a CheckoutUsecase. The options configure the
+// pricing and the eligibility, e.g. the tax calculator.
+func NewCheckoutUsecase(repo checkoutRepository, opts ...domain.ProductServiceOption) *CheckoutUsecase {
+	return &CheckoutUsecase{
+		repo: repo,
+		svc:  domain.NewProductService(opts...),
+	}
+}
+
+type CheckoutDto struct {
+	UserID uuid.UUID
+	Lines  []CheckoutLineDto
+}
+
+type CheckoutLineDto struct {
+	ProductID uuid.UUID
+	Unit      int
+}
+
+func (u *CheckoutUsecase) Checkout(ctx context.Context, dto CheckoutDto) (*domain.Order, error) {
+	user, err := u.repo.FindUser(ctx, dto.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	times, err := findPurchaseTimes(ctx, u.repo, u.svc, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every line is validated before anything is persisted.
+	lines := make([]domain.Purchase, len(dto.Lines))
+	for i, line := range dto.Lines {
+		p, err := u.prepareLine(ctx, user, times, line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i, err)
+		}
+
+		lines[i] = *p
+	}
+
+	order, err := domain.NewOrder(dto.UserID, lines, u.svc.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrOrderEmpty):
+			return nil, ErrOrderEmpty
+		case errors.Is(err, domain.ErrOrderDuplicateProduct):
+			return nil, ErrOrderDuplicateProduct
+		case errors.Is(err, domain.ErrCurrencyMismatch):
+			return nil, fmt.Errorf("%w: %w", ErrOrderCurrencyMismatch, err)
+		default:
+			return nil, err
+		}
+	}
+
+	if err := u.repo.CreateOrder(ctx, *order); err != nil {
+		return nil, purchaseLimitError(err)
+	}
+
+	return order, nil
+}
+
+func (u *CheckoutUsecase) prepareLine(ctx context.Context, user *domain.User, times []time.Time, line CheckoutLineDto) (*domain.Purchase, error) {
+	if line.Unit <= 0 {
+		return nil, ErrPurchaseUnitInvalid
+	}
+
+	p, err := u.repo.FindProduct(ctx, line.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	now := u.svc.Now()
+	if !p.IsPublishedAt(now) {
+		return nil, ErrProductNotFound
+	}
+
+	// The order counts as a single purchase towards the purchase limits.
+	if err := u.svc.CheckEligibility(domain.EligibilityRequest{
+		User:          *user,
+		Product:       *p,
+		Unit:          line.Unit,
+		PurchaseTimes: times,
+		At:            now,
+	}); err != nil {
+		return nil, eligibilityError(err)
+	}
+
+	if err := checkUserPurchaseLimits(ctx, u.repo, user.ID, p, line.Unit, now); err != nil {
+		return nil, err
+	}
+
+	ds, err := u.repo.FindProductDiscount(ctx, line.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase, err := u.svc.PreparePurchase(ctx, line.Unit, p, automaticDiscounts(ds))
+	if err != nil {
+		return nil, preparePurchaseError(err)
+	}
+
+	return purchase, nil
+}
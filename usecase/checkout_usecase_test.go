@@ -0,0 +1,204 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alextanhongpin/errors/causes"
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckoutFlow(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	t.Run("success", func(t *testing.T) {
+		f := newCheckoutFlow()
+		o, err := f.exec()
+		assert.Nil(t, err)
+		assert.Len(t, o.Lines, 2)
+
+		total, err := o.Total()
+		assert.Nil(t, err)
+		assert.Equal(t, domain.NewMoney(50, "MYR"), total)
+		f.repo.AssertNumberOfCalls(t, "CreateOrder", 1)
+		for _, line := range o.Lines {
+			events := line.Events()
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, "purchase.created", events[0].EventName())
+			}
+		}
+	})
+
+	t.Run("find user error", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.findUser.err = wantErr
+		_, err := f.exec()
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("user underage for a line", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.products[1].MinAge = 18
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrUserUnderage)
+		assert.ErrorContains(t, err, "line 1")
+	})
+
+	t.Run("purchase limit exceeded for a line", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.products[1].PurchaseLimits = domain.PurchaseLimits{{Max: 5}}
+		f.stub.purchased = []domain.PurchasedUnits{{Unit: 2, At: time.Now().Add(-time.Hour)}}
+		_, err := f.exec()
+		assert.True(t, usecase.ErrPurchaseLimitExceeded.Is(err))
+		assert.ErrorContains(t, err, "line 1")
+
+		var d causes.Detail
+		if assert.ErrorAs(t, err, &d) {
+			remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(d.Detail())
+			assert.True(t, ok)
+			assert.Equal(t, 3, remaining)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.args.Lines = nil
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrOrderEmpty)
+	})
+
+	t.Run("duplicate product", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.args.Lines[1].ProductID = f.args.Lines[0].ProductID
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrOrderDuplicateProduct)
+	})
+
+	t.Run("different currency", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.products[1].Price.Currency = "USD"
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrOrderCurrencyMismatch)
+	})
+
+	t.Run("line not published", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.products[1].PublishedAt = nil
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductNotFound)
+		f.repo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("line discount invalid", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.discounts[1] = []domain.Discount{*factories.NewDiscount("positive_amount")}
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrDiscountInvalid)
+		f.repo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("line unit invalid", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.args.Lines[1].Unit = -1
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrPurchaseUnitInvalid)
+		f.repo.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("find product error", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.findProductErr = wantErr
+		_, err := f.exec()
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("create order error", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.createOrder.err = usecase.ErrProductOutOfStock
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductOutOfStock)
+	})
+
+	t.Run("purchase limit exceeded when stored", func(t *testing.T) {
+		f := newCheckoutFlow()
+		f.stub.createOrder.err = &domain.PurchaseLimitExceededError{Remaining: 1}
+		_, err := f.exec()
+		assert.True(t, usecase.ErrPurchaseLimitExceeded.Is(err))
+
+		var d causes.Detail
+		if assert.ErrorAs(t, err, &d) {
+			remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(d.Detail())
+			assert.True(t, ok)
+			assert.Equal(t, 1, remaining)
+		}
+	})
+}
+
+type checkoutFlow struct {
+	repo *mocks.MockCheckoutRepository
+	args usecase.CheckoutDto
+	stub struct {
+		findUser       arg1[uuid.UUID, *domain.User]
+		products       []*domain.Product
+		discounts      [][]domain.Discount
+		purchased      []domain.PurchasedUnits
+		findProductErr error
+		createOrder    arg0[domain.Order]
+	}
+}
+
+func newCheckoutFlow() *checkoutFlow {
+	f := new(checkoutFlow)
+	f.args.UserID = uuid.New()
+	f.stub.findUser.args = f.args.UserID
+	f.stub.findUser.data = factories.NewUser()
+	f.stub.findUser.data.ID = f.args.UserID
+
+	// 2 units at 5$ off, and 4 units without discount.
+	for _, unit := range []int{2, 4} {
+		p := factories.NewProduct()
+		d := factories.NewDiscount()
+		d.ProductID = p.ID
+		d.MinPurchaseQty = 2
+		if unit == 4 {
+			d.MinPurchaseQty = 5
+		}
+
+		f.args.Lines = append(f.args.Lines, usecase.CheckoutLineDto{
+			ProductID: p.ID,
+			Unit:      unit,
+		})
+		f.stub.products = append(f.stub.products, p)
+		f.stub.discounts = append(f.stub.discounts, []domain.Discount{*d})
+	}
+
+	return f
+}
+
+func (f *checkoutFlow) exec() (*domain.Order, error) {
+	ctx := context.Background()
+
+	args := f.args
+	stub := f.stub
+
+	repo := new(mocks.MockCheckoutRepository)
+	repo.EXPECT().FindUser(ctx, stub.findUser.args).Return(stub.findUser.data, stub.findUser.err)
+	for i, p := range stub.products {
+		repo.EXPECT().FindProduct(ctx, p.ID).Return(p, stub.findProductErr)
+		repo.EXPECT().FindUserProductPurchases(ctx, args.UserID, p.ID, mock.Anything).Return(stub.purchased, nil)
+		repo.EXPECT().FindProductDiscount(ctx, p.ID).Return(stub.discounts[i], nil)
+	}
+	repo.EXPECT().CreateOrder(ctx, mock.AnythingOfType("domain.Order")).Return(stub.createOrder.err)
+	f.repo = repo
+
+	uc := usecase.NewCheckoutUsecase(repo)
+	return uc.Checkout(ctx, args)
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type eligibilityRepository interface {
+	FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error)
+}
+
+// findPurchaseTimes returns the purchases of the user that are needed by the
+// eligibility policies of the service.
+func findPurchaseTimes(ctx context.Context, repo eligibilityRepository, svc *domain.ProductService, userID uuid.UUID) ([]time.Time, error) {
+	lookback := svc.EligibilityLookback()
+	if lookback == 0 {
+		return nil, nil
+	}
+
+	return repo.FindUserPurchaseTimes(ctx, userID, svc.Now().Add(-lookback))
+}
+
+// eligibilityError maps the failed eligibility policy to the cause naming
+// the rule. Other policies are reported as ErrUserIneligible.
+func eligibilityError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUserInactive):
+		return fmt.Errorf("%w: %w", ErrUserInactive, err)
+	case errors.Is(err, domain.ErrUserBanned):
+		return fmt.Errorf("%w: %w", ErrUserBanned, err)
+	case errors.Is(err, domain.ErrPurchaseLimitReached):
+		return fmt.Errorf("%w: %w", ErrUserPurchaseLimitReached, err)
+	case errors.Is(err, domain.ErrUserUnderage):
+		return fmt.Errorf("%w: %w", ErrUserUnderage, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrUserIneligible, err)
+	}
+}
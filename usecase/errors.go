@@ -7,10 +7,55 @@ import (
 
 var (
 	// Product errors.
-	ErrProductNotFound      = causes.New(codes.NotFound, "product_not_found", "Product does not exist or may have been deleted.")
-	ErrProductUnauthorized  = causes.New(codes.Unauthorized, "product_unauthorized", "You do not have access to this product")
-	ErrProductNameBadFormat = causes.New(codes.BadRequest, "product_name_bad_format", "Product name can only contain alphanumeric characters and spaces.")
+	ErrProductNotFound          = causes.New(codes.NotFound, "product_not_found", "Product does not exist or may have been deleted.")
+	ErrProductUnauthorized      = causes.New(codes.Unauthorized, "product_unauthorized", "You do not have access to this product")
+	ErrProductNameBadFormat     = causes.New(codes.BadRequest, "product_name_bad_format", "Product name can only contain alphanumeric characters and spaces.")
+	ErrProductPriceTiersInvalid = causes.New(codes.PreconditionFailed, "product_price_tiers_invalid", "Product price tiers must be sorted by quantity and cannot overlap.")
+	ErrProductOutOfStock        = causes.New(codes.Conflict, "product_out_of_stock", "The product does not have enough stock left.")
+	ErrProductPriceInvalid      = causes.New(codes.BadRequest, "product_price_invalid", "Product price cannot be negative and must have a valid currency.")
+	ErrProductScheduleInvalid   = causes.New(codes.BadRequest, "product_schedule_invalid", "The unpublish time must be after the publish time.")
+	ErrProductNotDeleted        = causes.New(codes.Conflict, "product_not_deleted", "The product is not deleted.")
+	ErrProductRestoreExpired    = causes.New(codes.PreconditionFailed, "product_restore_expired", "The product was deleted too long ago to be restored.")
+	ErrProductQueryInvalid      = causes.New(codes.BadRequest, "product_query_invalid", "The product filters, sort or limit are not valid.")
+	ErrProductCursorInvalid     = causes.New(codes.BadRequest, "product_cursor_invalid", "The cursor is not valid for the sort order.")
+	ErrProductVersionConflict   = causes.New(codes.Conflict, "product_version_conflict", "The product was changed by someone else. Reload it and try again.")
+	ErrProductDiscountsExceeded = causes.New(codes.PreconditionFailed, "product_discounts_exceeded", "The product has too many discounts to apply.")
+	ErrProductLimitsInvalid     = causes.New(codes.PreconditionFailed, "product_limits_invalid", "Product purchase limits must have a positive maximum and cannot have a negative period.")
+
+	// User errors.
+	ErrUserIneligible           = causes.New(codes.Forbidden, "user_ineligible", "You are not allowed to make purchases.")
+	ErrUserInactive             = causes.New(codes.Forbidden, "user_inactive", "Your account is not active.")
+	ErrUserBanned               = causes.New(codes.Forbidden, "user_banned", "You are banned from making purchases.")
+	ErrUserPurchaseLimitReached = causes.New(codes.TooManyRequests, "user_purchase_limit_reached", "You have made too many purchases recently. Try again later.")
+	ErrUserUnderage             = causes.New(codes.Forbidden, "user_underage", "You do not meet the minimum age for this product.")
+
+	// Purchase errors.
+	ErrPurchaseNotFound      = causes.New(codes.NotFound, "purchase_not_found", "Purchase does not exist.")
+	ErrPurchaseUnitInvalid   = causes.New(codes.BadRequest, "purchase_unit_invalid", "The unit must be positive.")
+	ErrPurchaseUnauthorized  = causes.New(codes.Unauthorized, "purchase_unauthorized", "You do not have access to this purchase")
+	ErrPurchaseStatusInvalid = causes.New(codes.Conflict, "purchase_status_invalid", "The purchase cannot be changed in its current status.")
+	// ErrPurchaseLimitExceeded carries the units that the user can still buy.
+	ErrPurchaseLimitExceeded = causes.NewHint[int](codes.PreconditionFailed, "purchase_limit_exceeded", "You have reached the purchase limit for this product.")
+
+	// Order errors.
+	ErrOrderEmpty            = causes.New(codes.BadRequest, "order_empty", "The order must have at least one item.")
+	ErrOrderDuplicateProduct = causes.New(codes.BadRequest, "order_duplicate_product", "Each product can only appear once in an order.")
+	ErrOrderCurrencyMismatch = causes.New(codes.BadRequest, "order_currency_mismatch", "All items in an order must be in the same currency.")
+
+	// Idempotency errors.
+	ErrIdempotencyKeyReused     = causes.New(codes.BadRequest, "idempotency_key_reused", "The idempotency key was used for a different request.")
+	ErrIdempotencyKeyInProgress = causes.New(codes.Conflict, "idempotency_key_in_progress", "A request with the same idempotency key is still in progress.")
+	ErrIdempotencyKeyExpired    = causes.New(codes.Conflict, "idempotency_key_expired", "The idempotency key expired before the request completed.")
+
+	// Refund errors.
+	ErrRefundAmountInvalid = causes.New(codes.BadRequest, "refund_amount_invalid", "The refund amount must be positive and in the purchase currency.")
+	ErrRefundExceedsPaid   = causes.New(codes.PreconditionFailed, "refund_exceeds_paid", "The refund exceeds the amount paid.")
 
 	// Discount errors.
 	ErrDiscountInvalid = causes.New(codes.PreconditionFailed, "discount_invalid", "The discount cannot be applied")
+
+	// Coupon errors.
+	ErrCouponUnknown   = causes.New(codes.NotFound, "coupon_unknown", "The coupon code does not exist.")
+	ErrCouponExpired   = causes.New(codes.PreconditionFailed, "coupon_expired", "The coupon code has expired or is not active yet.")
+	ErrCouponExhausted = causes.New(codes.PreconditionFailed, "coupon_exhausted", "The coupon code has been fully redeemed.")
 )
@@ -0,0 +1,17 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+)
+
+// eventPublisher publishes the product events after the product is saved.
+// Unlike the purchase events, which the repositories store in the outbox in
+// the same transaction, the product events are best-effort: they are lost
+// when publishing fails after the save, or the process stops in between.
+// Consumers that cannot miss a product change should read the products
+// instead.
+type eventPublisher interface {
+	Publish(ctx context.Context, events ...domain.Event) error
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type productPurgeRepository interface {
+	// PurgeDeleted hard deletes the products that were deleted before the
+	// time, together with their discounts and inventories, and returns the
+	// number of purged products.
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// ProductPurger hard deletes the products once the retention after the
+// deletion has passed.
+type ProductPurger struct {
+	repo      productPurgeRepository
+	retention time.Duration
+	onError   func(error)
+	now       func() time.Time
+}
+
+type ProductPurgerOption func(*ProductPurger)
+
+// WithRetention sets how long the deleted products are kept. Defaults to 30
+// days. The retention should not be shorter than the restore grace period.
+func WithRetention(d time.Duration) ProductPurgerOption {
+	return func(p *ProductPurger) {
+		p.retention = d
+	}
+}
+
+// WithPurgerErrorHandler sets the handler for the errors that Run retries on
+// the next tick.
+func WithPurgerErrorHandler(fn func(error)) ProductPurgerOption {
+	return func(p *ProductPurger) {
+		p.onError = fn
+	}
+}
+
+// WithPurgerClock sets the clock that the retention is counted from.
+// Defaults to time.Now.
+func WithPurgerClock(now func() time.Time) ProductPurgerOption {
+	return func(p *ProductPurger) {
+		p.now = now
+	}
+}
+
+func NewProductPurger(repo productPurgeRepository, opts ...ProductPurgerOption) *ProductPurger {
+	p := &ProductPurger{
+		repo:      repo,
+		retention: 30 * 24 * time.Hour,
+		onError:   func(error) {},
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Run purges the products on every interval, until the context is done.
+func (p *ProductPurger) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil {
+			p.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// PurgeOnce purges the products deleted before the retention, and returns
+// the number of purged products.
+func (p *ProductPurger) PurgeOnce(ctx context.Context) (int, error) {
+	n, err := p.repo.PurgeDeleted(ctx, p.now().Add(-p.retention))
+	if err != nil {
+		return 0, fmt.Errorf("repo.PurgeDeleted: %w", err)
+	}
+
+	return n, nil
+}
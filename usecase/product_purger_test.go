@@ -0,0 +1,41 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductPurger(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(mocks.MockProductPurgeRepository)
+		repo.EXPECT().PurgeDeleted(context.Background(), now.Add(-time.Hour)).Return(2, nil)
+
+		p := usecase.NewProductPurger(repo, usecase.WithRetention(time.Hour), usecase.WithPurgerClock(clock))
+		n, err := p.PurgeOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(2, n)
+	})
+
+	t.Run("error when purging", func(t *testing.T) {
+		wantErr := errors.New("want error")
+
+		repo := new(mocks.MockProductPurgeRepository)
+		repo.EXPECT().PurgeDeleted(context.Background(), now.Add(-30*24*time.Hour)).Return(0, wantErr)
+
+		p := usecase.NewProductPurger(repo, usecase.WithPurgerClock(clock))
+		_, err := p.PurgeOnce(context.Background())
+		assert.ErrorIs(t, err, wantErr)
+	})
+}
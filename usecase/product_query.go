@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultProductListLimit = 20
+	maxProductListLimit     = 100
+)
+
+// ProductSort is the order of the listed products. Ties are broken by the ID.
+// The price sorts group the products by currency first, since the amounts in
+// different currencies cannot be compared.
+type ProductSort string
+
+const (
+	ProductSortName      ProductSort = "name"
+	ProductSortPriceAsc  ProductSort = "price"
+	ProductSortPriceDesc ProductSort = "-price"
+)
+
+func (s ProductSort) Valid() bool {
+	switch s {
+	case ProductSortName, ProductSortPriceAsc, ProductSortPriceDesc:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProductFilter narrows down the listed products. The zero value matches all
+// the products.
+type ProductFilter struct {
+	OwnerID    *uuid.UUID
+	Published  *bool
+	MinPrice   *domain.Money // Inclusive, and matches only the same currency.
+	MaxPrice   *domain.Money // Inclusive, and matches only the same currency.
+	NamePrefix string        // Case sensitive.
+}
+
+func (f ProductFilter) Valid() bool {
+	if f.MinPrice != nil && !f.MinPrice.Currency.Valid() {
+		return false
+	}
+
+	if f.MaxPrice != nil && !f.MaxPrice.Currency.Valid() {
+		return false
+	}
+
+	if f.MinPrice != nil && f.MaxPrice != nil {
+		if f.MinPrice.Currency != f.MaxPrice.Currency {
+			return false
+		}
+
+		if f.MinPrice.Amount > f.MaxPrice.Amount {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ProductQuery is the query for listing the products.
+type ProductQuery struct {
+	ProductFilter
+
+	// VisibleTo is the user that the unpublished products are listed for,
+	// since they are only visible to their owner. Deleted products are never
+	// listed.
+	VisibleTo uuid.UUID
+	At        time.Time // The time that Published is evaluated at.
+	Sort      ProductSort
+	After     *ProductCursor // Lists the products after the cursor, if set.
+	Limit     int
+}
+
+// ProductCursor is the position of the last product in a page.
+type ProductCursor struct {
+	Sort     ProductSort     `json:"sort"`
+	Name     string          `json:"name,omitempty"`
+	Currency domain.Currency `json:"currency,omitempty"`
+	Price    int64           `json:"price,omitempty"`
+	ID       uuid.UUID       `json:"id"`
+}
+
+func newProductCursor(sort ProductSort, p domain.Product) *ProductCursor {
+	return &ProductCursor{
+		Sort:     sort,
+		Name:     string(p.Name),
+		Currency: p.Price.Currency,
+		Price:    p.Price.Amount,
+		ID:       p.ID,
+	}
+}
+
+// encode returns the cursor as an opaque string.
+func (c *ProductCursor) encode() (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeProductCursor(s string) (*ProductCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var c ProductCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
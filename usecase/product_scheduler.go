@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+)
+
+type productScheduleRepository interface {
+	// FindScheduled returns the products that are published or expire
+	// within (from, to].
+	FindScheduled(ctx context.Context, from, to time.Time) ([]domain.Product, error)
+	// FindScheduleWatermark returns the time up to which the transitions
+	// were published, and false when none is stored.
+	FindScheduleWatermark(ctx context.Context) (time.Time, bool, error)
+	SaveScheduleWatermark(ctx context.Context, since time.Time) error
+}
+
+// ProductScheduler publishes the ProductPublished and ProductExpired events
+// once the scheduled times have passed. The time up to which the transitions
+// are published is stored, so that a restarted scheduler resumes from it.
+type ProductScheduler struct {
+	repo      productScheduleRepository
+	publisher eventPublisher
+	onError   func(error)
+	now       func() time.Time
+
+	// run serializes the runs, so that each window is published once.
+	run    sync.Mutex
+	mu     sync.RWMutex
+	since  time.Time
+	loaded bool
+}
+
+type ProductSchedulerOption func(*ProductScheduler)
+
+// WithSince sets the time to resume from when no watermark is stored, e.g.
+// on the first run. Defaults to the time the scheduler is created.
+func WithSince(t time.Time) ProductSchedulerOption {
+	return func(s *ProductScheduler) {
+		s.since = t
+	}
+}
+
+// WithSchedulerErrorHandler sets the handler for the errors that Run retries
+// on the next tick.
+func WithSchedulerErrorHandler(fn func(error)) ProductSchedulerOption {
+	return func(s *ProductScheduler) {
+		s.onError = fn
+	}
+}
+
+// WithSchedulerClock sets the clock that the transitions are published up
+// to. Defaults to time.Now.
+func WithSchedulerClock(now func() time.Time) ProductSchedulerOption {
+	return func(s *ProductScheduler) {
+		s.now = now
+	}
+}
+
+func NewProductScheduler(repo productScheduleRepository, publisher eventPublisher, opts ...ProductSchedulerOption) *ProductScheduler {
+	s := &ProductScheduler{
+		repo:      repo,
+		publisher: publisher,
+		onError:   func(error) {},
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.since.IsZero() {
+		s.since = s.now()
+	}
+
+	return s
+}
+
+// Since returns the time up to which the transitions are published.
+func (s *ProductScheduler) Since() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.since
+}
+
+// Run publishes the transitions on every interval, until the context is done.
+func (s *ProductScheduler) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil {
+			s.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce publishes the transitions since the previous run, and returns the
+// number of published events. On failure, the same transitions are retried on
+// the next run.
+func (s *ProductScheduler) RunOnce(ctx context.Context) (int, error) {
+	s.run.Lock()
+	defer s.run.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		return 0, err
+	}
+
+	from, to := s.Since(), s.now()
+
+	products, err := s.repo.FindScheduled(ctx, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("repo.FindScheduled: %w", err)
+	}
+
+	var events []domain.Event
+	for i := range products {
+		p := &products[i]
+		if within(p.PublishedAt, from, to) {
+			p.Publish(*p.PublishedAt, to)
+		}
+
+		if within(p.UnpublishAt, from, to) {
+			p.Expire(to)
+		}
+
+		events = append(events, p.PullEvents()...)
+	}
+
+	if len(events) > 0 {
+		if err := s.publisher.Publish(ctx, events...); err != nil {
+			return 0, fmt.Errorf("publisher.Publish: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.since = to
+	s.mu.Unlock()
+
+	// The events are already published, so the window is not retried when
+	// the watermark cannot be saved. It is saved again on the next run.
+	if err := s.repo.SaveScheduleWatermark(ctx, to); err != nil {
+		return len(events), fmt.Errorf("repo.SaveScheduleWatermark: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// load resumes from the stored watermark on the first run.
+func (s *ProductScheduler) load(ctx context.Context) error {
+	if s.loaded {
+		return nil
+	}
+
+	since, ok, err := s.repo.FindScheduleWatermark(ctx)
+	if err != nil {
+		return fmt.Errorf("repo.FindScheduleWatermark: %w", err)
+	}
+
+	if ok {
+		s.mu.Lock()
+		s.since = since
+		s.mu.Unlock()
+	}
+	s.loaded = true
+
+	return nil
+}
+
+// within returns true if t is within (from, to].
+func within(t *time.Time, from, to time.Time) bool {
+	return t != nil && t.After(from) && !t.After(to)
+}
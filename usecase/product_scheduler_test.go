@@ -0,0 +1,123 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductScheduler(t *testing.T) {
+	since := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(time.Minute)
+	clock := func() time.Time { return now }
+
+	// Published within the window, and expires after.
+	published := factories.NewProduct()
+	published.PublishedAt = types.Ptr(since.Add(time.Second))
+	published.UnpublishAt = types.Ptr(now.Add(time.Hour))
+
+	// Published before the window, and expires within.
+	expired := factories.NewProduct()
+	expired.PublishedAt = types.Ptr(since.Add(-time.Hour))
+	expired.UnpublishAt = types.Ptr(now)
+
+	t.Run("success", func(t *testing.T) {
+		repo := new(mocks.MockProductScheduleRepository)
+		repo.EXPECT().FindScheduleWatermark(context.Background()).Return(time.Time{}, false, nil)
+		repo.EXPECT().FindScheduled(context.Background(), since, now).Return([]domain.Product{*published, *expired}, nil)
+		repo.EXPECT().SaveScheduleWatermark(context.Background(), now).Return(nil)
+
+		publisher := event.NewInMemoryPublisher()
+		s := usecase.NewProductScheduler(repo, publisher, usecase.WithSince(since), usecase.WithSchedulerClock(clock))
+
+		n, err := s.RunOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(2, n)
+		as.Equal(now, s.Since())
+
+		events := publisher.Events()
+		if as.Len(events, 2) {
+			as.Equal(domain.ProductPublished{
+				ProductID:   published.ID,
+				PublishedAt: *published.PublishedAt,
+				At:          now,
+			}, events[0])
+			as.Equal(domain.ProductExpired{
+				ProductID: expired.ID,
+				ExpiredAt: now,
+				At:        now,
+			}, events[1])
+		}
+	})
+
+	t.Run("error when finding scheduled products", func(t *testing.T) {
+		wantErr := errors.New("want error")
+
+		repo := new(mocks.MockProductScheduleRepository)
+		repo.EXPECT().FindScheduleWatermark(context.Background()).Return(time.Time{}, false, nil)
+		repo.EXPECT().FindScheduled(context.Background(), since, now).Return(nil, wantErr)
+
+		s := usecase.NewProductScheduler(repo, event.NewInMemoryPublisher(), usecase.WithSince(since), usecase.WithSchedulerClock(clock))
+		_, err := s.RunOnce(context.Background())
+
+		as := assert.New(t)
+		as.ErrorIs(err, wantErr)
+
+		// The window is retried on the next run.
+		as.Equal(since, s.Since())
+	})
+
+	t.Run("resumes from the stored watermark", func(t *testing.T) {
+		watermark := since.Add(-time.Hour)
+
+		repo := new(mocks.MockProductScheduleRepository)
+		repo.EXPECT().FindScheduleWatermark(context.Background()).Return(watermark, true, nil).Once()
+		repo.EXPECT().FindScheduled(context.Background(), watermark, now).Return(nil, nil)
+		repo.EXPECT().SaveScheduleWatermark(context.Background(), now).Return(nil)
+
+		s := usecase.NewProductScheduler(repo, event.NewInMemoryPublisher(), usecase.WithSince(since), usecase.WithSchedulerClock(clock))
+		_, err := s.RunOnce(context.Background())
+
+		as := assert.New(t)
+		as.Nil(err)
+		as.Equal(now, s.Since())
+
+		// The watermark is only loaded on the first run.
+		repo.EXPECT().FindScheduled(context.Background(), now, now).Return(nil, nil)
+		_, err = s.RunOnce(context.Background())
+		as.Nil(err)
+	})
+
+	t.Run("error when saving the watermark", func(t *testing.T) {
+		wantErr := errors.New("want error")
+
+		repo := new(mocks.MockProductScheduleRepository)
+		repo.EXPECT().FindScheduleWatermark(context.Background()).Return(time.Time{}, false, nil)
+		repo.EXPECT().FindScheduled(context.Background(), since, now).Return([]domain.Product{*published}, nil)
+		repo.EXPECT().SaveScheduleWatermark(context.Background(), now).Return(wantErr)
+
+		publisher := event.NewInMemoryPublisher()
+		s := usecase.NewProductScheduler(repo, publisher, usecase.WithSince(since), usecase.WithSchedulerClock(clock))
+		n, err := s.RunOnce(context.Background())
+
+		as := assert.New(t)
+		as.ErrorIs(err, wantErr)
+		as.Equal(1, n)
+
+		// The published window is not retried.
+		as.Equal(now, s.Since())
+		as.Len(publisher.Events(), 1)
+	})
+}
@@ -2,26 +2,65 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/google/uuid"
 )
 
 type productRepository interface {
+	// FindByID returns the product even when it is soft deleted.
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.Product, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	// List returns up to the limit of products matching the query, in the
+	// sort order.
+	List(ctx context.Context, q ProductQuery) ([]domain.Product, error)
 	Create(ctx context.Context, name string, userID uuid.UUID) (*domain.Product, error)
+	// Update saves the product, including the publish schedule and the
+	// deletion, only if the stored version still matches the version, and
+	// returns ErrProductVersionConflict otherwise.
+	Update(ctx context.Context, p domain.Product, version int) error
 }
 
 type ProductUsecase struct {
-	productRepo productRepository
+	productRepo        productRepository
+	publisher          eventPublisher
+	restoreGracePeriod time.Duration
+	now                func() time.Time
 }
 
-func NewProduct(productRepo productRepository) *ProductUsecase {
-	return &ProductUsecase{
-		productRepo: productRepo,
+type ProductOption func(*ProductUsecase)
+
+// WithRestoreGracePeriod sets how long a deleted product can be restored.
+// Defaults to 7 days.
+func WithRestoreGracePeriod(d time.Duration) ProductOption {
+	return func(u *ProductUsecase) {
+		u.restoreGracePeriod = d
+	}
+}
+
+// WithProductClock sets the clock for the publishing and the deletion.
+// Defaults to time.Now.
+func WithProductClock(now func() time.Time) ProductOption {
+	return func(u *ProductUsecase) {
+		u.now = now
+	}
+}
+
+func NewProduct(productRepo productRepository, publisher eventPublisher, opts ...ProductOption) *ProductUsecase {
+	u := &ProductUsecase{
+		productRepo:        productRepo,
+		publisher:          publisher,
+		restoreGracePeriod: 7 * 24 * time.Hour,
+		now:                time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
 }
 
 func (u *ProductUsecase) View(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
@@ -30,13 +69,79 @@ func (u *ProductUsecase) View(ctx context.Context, id uuid.UUID) (*domain.Produc
 		return nil, fmt.Errorf("productRepo.FindByID: %w", err)
 	}
 
-	if !pdt.IsPublished() {
+	if !pdt.IsPublishedAt(u.now()) {
 		return nil, ErrProductNotFound
 	}
 
 	return pdt, nil
 }
 
+type ListProductsDto struct {
+	UserID uuid.UUID // The user listing the products, or uuid.Nil.
+	Filter ProductFilter
+	Sort   ProductSort // Defaults to ProductSortName.
+	Cursor string      // The NextCursor of the previous page.
+	Limit  int         // Defaults to 20, and cannot exceed 100.
+}
+
+type ProductPage struct {
+	Products   []domain.Product
+	NextCursor string // Empty on the last page.
+}
+
+// List returns a page of the products. Unlike View, which does not know the
+// user, the unpublished products are listed for their owner.
+func (u *ProductUsecase) List(ctx context.Context, dto ListProductsDto) (*ProductPage, error) {
+	if dto.Sort == "" {
+		dto.Sort = ProductSortName
+	}
+
+	if dto.Limit == 0 {
+		dto.Limit = defaultProductListLimit
+	}
+
+	if !dto.Sort.Valid() || !dto.Filter.Valid() || dto.Limit < 0 || dto.Limit > maxProductListLimit {
+		return nil, ErrProductQueryInvalid
+	}
+
+	q := ProductQuery{
+		ProductFilter: dto.Filter,
+		VisibleTo:     dto.UserID,
+		At:            u.now(),
+		Sort:          dto.Sort,
+		Limit:         dto.Limit + 1, // To check if there is a next page.
+	}
+
+	if dto.Cursor != "" {
+		after, err := decodeProductCursor(dto.Cursor)
+		if err != nil || after.Sort != dto.Sort {
+			return nil, ErrProductCursorInvalid
+		}
+
+		q.After = after
+	}
+
+	products, err := u.productRepo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("productRepo.List: %w", err)
+	}
+
+	page := &ProductPage{
+		Products: products,
+	}
+
+	if len(products) > dto.Limit {
+		page.Products = products[:dto.Limit]
+
+		page.NextCursor, err = newProductCursor(dto.Sort, page.Products[dto.Limit-1]).encode()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
 type CreateProductDto struct {
 	Name   string
 	UserID uuid.UUID
@@ -52,21 +157,205 @@ func (u *ProductUsecase) Create(ctx context.Context, dto CreateProductDto) (*dom
 		return nil, fmt.Errorf("productRepo.Create: %w", err)
 	}
 
+	pdt.MarkCreated(u.now())
+	if err := u.publisher.Publish(ctx, pdt.PullEvents()...); err != nil {
+		return nil, fmt.Errorf("publisher.Publish: %w", err)
+	}
+
 	return pdt, nil
 }
 
+// Delete soft deletes the product. The product is purged by the
+// ProductPurger once the retention has passed.
 func (u *ProductUsecase) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	_, err := u.change(ctx, id, userID, func(pdt *domain.Product) error {
+		pdt.Delete(u.now())
+		return nil
+	})
+
+	return err
+}
+
+// Restore undoes the deletion within the grace period.
+func (u *ProductUsecase) Restore(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	pdt, err := u.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("productRepo.FindByID: %w", err)
+	}
+
+	if !pdt.IsMine(userID) {
+		return nil, ErrProductUnauthorized
+	}
+
+	version := pdt.Version
+	if err := pdt.Restore(u.now(), u.restoreGracePeriod); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrProductNotDeleted):
+			return nil, fmt.Errorf("%w: %w", ErrProductNotDeleted, err)
+		case errors.Is(err, domain.ErrRestoreExpired):
+			return nil, fmt.Errorf("%w: %w", ErrProductRestoreExpired, err)
+		default:
+			return nil, err
+		}
+	}
+
+	if err := u.save(ctx, pdt, version); err != nil {
+		return nil, err
+	}
+
+	return pdt, nil
+}
+
+type UpdateProductDto struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	Name    string
+	Price   domain.Money
+	Version int // The version of the product that the changes are based on.
+}
+
+// Update renames and reprices the product. The update is rejected with
+// ErrProductVersionConflict when the product was changed since the given
+// version.
+func (u *ProductUsecase) Update(ctx context.Context, dto UpdateProductDto) (*domain.Product, error) {
+	name := domain.ProductName(dto.Name)
+	if !name.Valid() {
+		return nil, ErrProductNameBadFormat
+	}
+
+	if dto.Price.IsNegative() || !dto.Price.Currency.Valid() {
+		return nil, ErrProductPriceInvalid
+	}
+
+	pdt, err := u.findOwned(ctx, dto.ID, dto.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pdt.Version != dto.Version {
+		return nil, ErrProductVersionConflict
+	}
+
+	if err := pdt.Update(name, dto.Price, u.now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProductPriceTiersInvalid, err)
+	}
+
+	if err := u.save(ctx, pdt, dto.Version); err != nil {
+		return nil, err
+	}
+
+	return pdt, nil
+}
+
+type UpdatePriceTiersDto struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Tiers  domain.PriceTiers // Empty to remove the tiers.
+}
+
+// UpdatePriceTiers replaces the price tiers of the product.
+func (u *ProductUsecase) UpdatePriceTiers(ctx context.Context, dto UpdatePriceTiersDto) (*domain.Product, error) {
+	return u.change(ctx, dto.ID, dto.UserID, func(pdt *domain.Product) error {
+		if err := pdt.SetPriceTiers(dto.Tiers, u.now()); err != nil {
+			return fmt.Errorf("%w: %w", ErrProductPriceTiersInvalid, err)
+		}
+
+		return nil
+	})
+}
+
+// Publish publishes the product immediately, without an expiry.
+func (u *ProductUsecase) Publish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return u.change(ctx, id, userID, func(pdt *domain.Product) error {
+		now := u.now()
+		return pdt.Schedule(now, nil, now)
+	})
+}
+
+type SchedulePublishDto struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	PublishAt   time.Time  // Defaults to now when zero or in the past.
+	UnpublishAt *time.Time // Optional.
+}
+
+// SchedulePublish publishes the product from the PublishAt until the
+// UnpublishAt, replacing the previous schedule. A PublishAt in the past is
+// published from now, as the ProductScheduler only publishes the
+// transitions that happen after its previous run.
+func (u *ProductUsecase) SchedulePublish(ctx context.Context, dto SchedulePublishDto) (*domain.Product, error) {
+	return u.change(ctx, dto.ID, dto.UserID, func(pdt *domain.Product) error {
+		now := u.now()
+
+		publishAt := dto.PublishAt
+		if publishAt.Before(now) {
+			publishAt = now
+		}
+
+		if err := pdt.Schedule(publishAt, dto.UnpublishAt, now); err != nil {
+			return fmt.Errorf("%w: %w", ErrProductScheduleInvalid, err)
+		}
+
+		return nil
+	})
+}
+
+// Unpublish hides the product immediately, and cancels the schedule.
+func (u *ProductUsecase) Unpublish(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
+	return u.change(ctx, id, userID, func(pdt *domain.Product) error {
+		pdt.Unpublish(u.now())
+		return nil
+	})
+}
+
+// change applies the fn to the product owned by the user, and saves it
+// against the version that was read.
+func (u *ProductUsecase) change(ctx context.Context, id, userID uuid.UUID, fn func(*domain.Product) error) (*domain.Product, error) {
+	pdt, err := u.findOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	version := pdt.Version
+	if err := fn(pdt); err != nil {
+		return nil, err
+	}
+
+	if err := u.save(ctx, pdt, version); err != nil {
+		return nil, err
+	}
+
+	return pdt, nil
+}
+
+// findOwned returns the product owned by the user. Deleted products are
+// reported as ErrProductNotFound.
+func (u *ProductUsecase) findOwned(ctx context.Context, id, userID uuid.UUID) (*domain.Product, error) {
 	pdt, err := u.productRepo.FindByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("productRepo.FindByID: %w", err)
+		return nil, fmt.Errorf("productRepo.FindByID: %w", err)
+	}
+
+	if pdt.IsDeleted() {
+		return nil, ErrProductNotFound
 	}
 
 	if !pdt.IsMine(userID) {
-		return ErrProductUnauthorized
+		return nil, ErrProductUnauthorized
+	}
+
+	return pdt, nil
+}
+
+// save saves the product against the version, and publishes the events. The
+// events are best-effort, see eventPublisher.
+func (u *ProductUsecase) save(ctx context.Context, pdt *domain.Product, version int) error {
+	if err := u.productRepo.Update(ctx, *pdt, version); err != nil {
+		return fmt.Errorf("productRepo.Update: %w", err)
 	}
 
-	if err := u.productRepo.Delete(ctx, id); err != nil {
-		return fmt.Errorf("productRepo.Delete: %w", err)
+	if err := u.publisher.Publish(ctx, pdt.PullEvents()...); err != nil {
+		return fmt.Errorf("publisher.Publish: %w", err)
 	}
 
 	return nil
@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
 
 	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	"github.com/alextanhongpin/go-domain-test/event"
+	"github.com/alextanhongpin/go-domain-test/types"
 	"github.com/alextanhongpin/go-domain-test/usecase"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestProductUsecaseView(t *testing.T) {
@@ -42,12 +46,118 @@ func TestProductUsecaseView(t *testing.T) {
 	})
 }
 
+func TestProductUsecaseList(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	t.Run("success", func(t *testing.T) {
+		f := newListProductsFlow()
+
+		page, err := f.exec()
+		as := assert.New(t)
+		as.Nil(err)
+		as.Len(page.Products, 2)
+		as.NotEmpty(page.NextCursor)
+
+		// The next page starts after the last product.
+		next := newListProductsFlow()
+		next.args.Cursor = page.NextCursor
+		next.stub.list.args.After = &usecase.ProductCursor{
+			Sort:     usecase.ProductSortName,
+			Name:     string(page.Products[1].Name),
+			Currency: page.Products[1].Price.Currency,
+			Price:    page.Products[1].Price.Amount,
+			ID:       page.Products[1].ID,
+		}
+		next.stub.list.data = next.stub.list.data[:1]
+
+		page, err = next.exec()
+		as.Nil(err)
+		as.Len(page.Products, 1)
+		as.Empty(page.NextCursor)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		f := newListProductsFlow()
+		f.args.Sort = ""
+		f.args.Limit = 0
+		f.stub.list.args.Limit = 21
+
+		_, err := f.exec()
+		assert.Nil(t, err)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		tests := map[string]func(*usecase.ListProductsDto){
+			"unknown sort":     func(dto *usecase.ListProductsDto) { dto.Sort = "created_at" },
+			"negative limit":   func(dto *usecase.ListProductsDto) { dto.Limit = -1 },
+			"limit exceeded":   func(dto *usecase.ListProductsDto) { dto.Limit = 101 },
+			"invalid currency": func(dto *usecase.ListProductsDto) { dto.Filter.MinPrice = types.Ptr(domain.NewMoney(1, "")) },
+			"currency mismatch": func(dto *usecase.ListProductsDto) {
+				dto.Filter.MinPrice = types.Ptr(domain.NewMoney(1, "MYR"))
+				dto.Filter.MaxPrice = types.Ptr(domain.NewMoney(2, "SGD"))
+			},
+			"min above max": func(dto *usecase.ListProductsDto) {
+				dto.Filter.MinPrice = types.Ptr(domain.NewMoney(2, "MYR"))
+				dto.Filter.MaxPrice = types.Ptr(domain.NewMoney(1, "MYR"))
+			},
+		}
+
+		for name, fn := range tests {
+			fn := fn
+
+			t.Run(name, func(t *testing.T) {
+				f := newListProductsFlow()
+				fn(&f.args)
+
+				_, err := f.exec()
+				assert.ErrorIs(t, err, usecase.ErrProductQueryInvalid)
+			})
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		f := newListProductsFlow()
+		f.args.Cursor = "!@#"
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductCursorInvalid)
+	})
+
+	t.Run("cursor of another sort", func(t *testing.T) {
+		f := newListProductsFlow()
+		page, err := f.exec()
+		assert.Nil(t, err)
+
+		f = newListProductsFlow()
+		f.args.Sort = usecase.ProductSortPriceAsc
+		f.args.Cursor = page.NextCursor
+
+		_, err = f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductCursorInvalid)
+	})
+
+	t.Run("error when listing", func(t *testing.T) {
+		f := newListProductsFlow()
+		f.stub.list.err = wantErr
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, wantErr)
+	})
+}
+
 func TestProductUsecaseDeleteFlow(t *testing.T) {
 	wantErr := errors.New("want error")
 
 	t.Run("success", func(t *testing.T) {
 		f := newDeleteProductFlow()
 		assert.Nil(t, f.exec())
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			evt, ok := events[0].(domain.ProductDeleted)
+			assert.True(t, ok)
+			assert.Equal(t, f.args.userID, evt.UserID)
+		}
 	})
 
 	t.Run("unauthorized user id", func(t *testing.T) {
@@ -62,9 +172,15 @@ func TestProductUsecaseDeleteFlow(t *testing.T) {
 		assert.ErrorIs(t, f.exec(), wantErr)
 	})
 
-	t.Run("error when delete", func(t *testing.T) {
+	t.Run("already deleted", func(t *testing.T) {
 		f := newDeleteProductFlow()
-		f.stub.delete.err = wantErr
+		f.stub.findByID.data.Delete(time.Now())
+		assert.ErrorIs(t, f.exec(), usecase.ErrProductNotFound)
+	})
+
+	t.Run("error when update", func(t *testing.T) {
+		f := newDeleteProductFlow()
+		f.stub.update.err = wantErr
 		assert.ErrorIs(t, f.exec(), wantErr)
 	})
 }
@@ -74,7 +190,7 @@ func TestProductUsecaseDelete(t *testing.T) {
 	type stub struct {
 		findByID    *domain.Product
 		findByIDErr error
-		deleteErr   error
+		updateErr   error
 	}
 
 	type args struct {
@@ -110,9 +226,9 @@ func TestProductUsecaseDelete(t *testing.T) {
 			wantErr: wantErr,
 		},
 		{
-			name: "repo.delete failed",
+			name: "repo.update failed",
 			stubFn: func(s *stub) {
-				s.deleteErr = wantErr
+				s.updateErr = wantErr
 			},
 			wantErr: wantErr,
 		},
@@ -141,9 +257,9 @@ func TestProductUsecaseDelete(t *testing.T) {
 
 			repo := new(mocks.MockProductRepository)
 			repo.EXPECT().FindByID(context.Background(), args.id).Return(stub.findByID, stub.findByIDErr)
-			repo.EXPECT().Delete(context.Background(), args.id).Return(stub.deleteErr)
+			repo.EXPECT().Update(context.Background(), mock.Anything, 0).Return(stub.updateErr)
 
-			uc := usecase.NewProduct(repo)
+			uc := usecase.NewProduct(repo, event.NewInMemoryPublisher())
 			err := uc.Delete(context.Background(), args.id, args.userID)
 			assert.ErrorIs(err, tc.wantErr)
 			t.Logf("%s: %s\n", tc.name, err)
@@ -157,6 +273,13 @@ func TestProductUsecaseCreate(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		f := newCreateProductFlow()
 		assert.Nil(t, f.exec())
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			evt, ok := events[0].(domain.ProductCreated)
+			assert.True(t, ok)
+			assert.Equal(t, f.stub.create.data.ID, evt.ProductID)
+		}
 	})
 
 	t.Run("when input invalid name", func(t *testing.T) {
@@ -173,6 +296,346 @@ func TestProductUsecaseCreate(t *testing.T) {
 	})
 }
 
+func TestProductUsecaseUpdate(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	t.Run("success", func(t *testing.T) {
+		f := newUpdateProductFlow()
+
+		p, err := f.exec()
+		assert.Nil(t, err)
+		assert.Equal(t, domain.ProductName("striped socks"), p.Name)
+		assert.Equal(t, domain.NewMoney(12, "MYR"), p.Price)
+		assert.Equal(t, 1, p.Version)
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			evt, ok := events[0].(domain.ProductUpdated)
+			assert.True(t, ok)
+			assert.Equal(t, 1, evt.Version)
+		}
+	})
+
+	t.Run("when product deleted", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.stub.findByID.data = factories.NewProduct("deleted")
+		f.stub.findByID.args = f.stub.findByID.data.ID
+		f.args.ID = f.stub.findByID.data.ID
+		f.args.UserID = f.stub.findByID.data.UserID
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductNotFound)
+	})
+
+	t.Run("when input invalid name", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.args.Name = "!@#$!@#"
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductNameBadFormat)
+	})
+
+	t.Run("when input negative price", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.args.Price = domain.NewMoney(-1, "MYR")
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductPriceInvalid)
+	})
+
+	t.Run("when input invalid currency", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.args.Price = domain.NewMoney(12, "")
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductPriceInvalid)
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.args.UserID = uuid.New()
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.stub.findByID.data.Version = 2
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductVersionConflict)
+	})
+
+	t.Run("price tiers in another currency", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		p := factories.NewProduct("tiered")
+		f.stub.findByID.args = p.ID
+		f.stub.findByID.data = p
+		f.args.ID = p.ID
+		f.args.UserID = p.UserID
+		f.args.Price = domain.NewMoney(12, "USD")
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductPriceTiersInvalid)
+	})
+
+	t.Run("error when finding product by id", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.stub.findByID.err = wantErr
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("version conflict when update", func(t *testing.T) {
+		f := newUpdateProductFlow()
+		f.stub.update.err = usecase.ErrProductVersionConflict
+
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrProductVersionConflict)
+		assert.Empty(t, f.publisher.Events())
+	})
+}
+
+func TestProductUsecaseUpdatePriceTiers(t *testing.T) {
+	tiers := factories.NewProduct("tiered").PriceTiers
+
+	update := func(f *publishProductFlow, tiers domain.PriceTiers) func(*usecase.ProductUsecase) (*domain.Product, error) {
+		return func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.UpdatePriceTiers(context.Background(), usecase.UpdatePriceTiersDto{
+				ID:     f.args.id,
+				UserID: f.args.userID,
+				Tiers:  tiers,
+			})
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		p, err := f.exec(update(f, tiers))
+		assert.Nil(t, err)
+		assert.Equal(t, tiers, p.PriceTiers)
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "product.price_tiers_updated", events[0].EventName())
+		}
+	})
+
+	t.Run("invalid tiers", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		_, err := f.exec(update(f, domain.PriceTiers{tiers[1], tiers[0]}))
+		assert.ErrorIs(t, err, usecase.ErrProductPriceTiersInvalid)
+		assert.Empty(t, f.publisher.Events())
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.args.userID = uuid.New()
+
+		_, err := f.exec(update(f, tiers))
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+}
+
+func TestProductUsecasePublish(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	publish := func(f *publishProductFlow) func(*usecase.ProductUsecase) (*domain.Product, error) {
+		return func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.Publish(context.Background(), f.args.id, f.args.userID)
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		p, err := f.exec(publish(f))
+		assert.Nil(t, err)
+		assert.True(t, p.IsPublishedAt(time.Now()))
+		assert.Nil(t, p.UnpublishAt)
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "product.scheduled", events[0].EventName())
+		}
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.args.userID = uuid.New()
+
+		_, err := f.exec(publish(f))
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+
+	t.Run("error when finding product by id", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.stub.findByID.err = wantErr
+
+		_, err := f.exec(publish(f))
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("version conflict when update", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.stub.update.err = usecase.ErrProductVersionConflict
+
+		_, err := f.exec(publish(f))
+		assert.ErrorIs(t, err, usecase.ErrProductVersionConflict)
+		assert.Empty(t, f.publisher.Events())
+	})
+}
+
+func TestProductUsecaseSchedulePublish(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+	unpublishAt := publishAt.Add(time.Hour)
+
+	schedule := func(f *publishProductFlow, unpublishAt time.Time) func(*usecase.ProductUsecase) (*domain.Product, error) {
+		return func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.SchedulePublish(context.Background(), usecase.SchedulePublishDto{
+				ID:          f.args.id,
+				UserID:      f.args.userID,
+				PublishAt:   publishAt,
+				UnpublishAt: &unpublishAt,
+			})
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		p, err := f.exec(schedule(f, unpublishAt))
+		assert.Nil(t, err)
+		assert.False(t, p.IsPublishedAt(time.Now()))
+		assert.Equal(t, &publishAt, p.PublishedAt)
+		assert.Equal(t, &unpublishAt, p.UnpublishAt)
+	})
+
+	t.Run("publish at in the past", func(t *testing.T) {
+		f := newPublishProductFlow()
+		before := time.Now()
+
+		p, err := f.exec(func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.SchedulePublish(context.Background(), usecase.SchedulePublishDto{
+				ID:        f.args.id,
+				UserID:    f.args.userID,
+				PublishAt: before.Add(-time.Hour),
+			})
+		})
+		assert.Nil(t, err)
+
+		// Published from now, so that the scheduler still publishes it.
+		assert.False(t, p.PublishedAt.Before(before))
+	})
+
+	t.Run("unpublish before publish", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		_, err := f.exec(schedule(f, publishAt.Add(-time.Minute)))
+		assert.ErrorIs(t, err, usecase.ErrProductScheduleInvalid)
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.args.userID = uuid.New()
+
+		_, err := f.exec(schedule(f, unpublishAt))
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+}
+
+func TestProductUsecaseUnpublish(t *testing.T) {
+	unpublish := func(f *publishProductFlow) func(*usecase.ProductUsecase) (*domain.Product, error) {
+		return func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.Unpublish(context.Background(), f.args.id, f.args.userID)
+		}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.stub.findByID.data = factories.NewProduct("expiring")
+		f.stub.findByID.args = f.stub.findByID.data.ID
+		f.args.id = f.stub.findByID.data.ID
+
+		p, err := f.exec(unpublish(f))
+		assert.Nil(t, err)
+		assert.False(t, p.IsPublishedAt(time.Now()))
+		assert.Nil(t, p.UnpublishAt)
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "product.unpublished", events[0].EventName())
+		}
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newPublishProductFlow()
+		f.args.userID = uuid.New()
+
+		_, err := f.exec(unpublish(f))
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+}
+
+func TestProductUsecaseRestore(t *testing.T) {
+	restore := func(f *publishProductFlow) func(*usecase.ProductUsecase) (*domain.Product, error) {
+		return func(uc *usecase.ProductUsecase) (*domain.Product, error) {
+			return uc.Restore(context.Background(), f.args.id, f.args.userID)
+		}
+	}
+
+	newFlow := func() *publishProductFlow {
+		f := newPublishProductFlow()
+		f.stub.findByID.data = factories.NewProduct("published", "deleted")
+		f.stub.findByID.args = f.stub.findByID.data.ID
+		f.args.id = f.stub.findByID.data.ID
+
+		return f
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := newFlow()
+
+		p, err := f.exec(restore(f))
+		assert.Nil(t, err)
+		assert.False(t, p.IsDeleted())
+		assert.True(t, p.IsPublishedAt(time.Now()))
+
+		events := f.publisher.Events()
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "product.restored", events[0].EventName())
+		}
+	})
+
+	t.Run("unauthorized user id", func(t *testing.T) {
+		f := newFlow()
+		f.args.userID = uuid.New()
+
+		_, err := f.exec(restore(f))
+		assert.ErrorIs(t, err, usecase.ErrProductUnauthorized)
+	})
+
+	t.Run("not deleted", func(t *testing.T) {
+		f := newPublishProductFlow()
+
+		_, err := f.exec(restore(f))
+		assert.ErrorIs(t, err, usecase.ErrProductNotDeleted)
+		assert.ErrorIs(t, err, domain.ErrProductNotDeleted)
+	})
+
+	t.Run("after the grace period", func(t *testing.T) {
+		f := newFlow()
+		f.stub.findByID.data.DeletedAt = types.Ptr(time.Now().Add(-8 * 24 * time.Hour))
+
+		_, err := f.exec(restore(f))
+		assert.ErrorIs(t, err, usecase.ErrProductRestoreExpired)
+	})
+}
+
 type arg1[T1, T2 any] struct {
 	args T1
 	data T2
@@ -185,7 +648,8 @@ type arg0[T any] struct {
 }
 
 type viewProductFlow struct {
-	args struct {
+	publisher *event.InMemoryPublisher
+	args      struct {
 		id uuid.UUID
 	}
 	stub struct {
@@ -209,20 +673,68 @@ func (f *viewProductFlow) exec() error {
 	repo := new(mocks.MockProductRepository)
 	repo.EXPECT().FindByID(context.Background(), stub.findByID.args).Return(stub.findByID.data, stub.findByID.err)
 
-	uc := usecase.NewProduct(repo)
+	f.publisher = event.NewInMemoryPublisher()
+	uc := usecase.NewProduct(repo, f.publisher)
 	ctx := context.Background()
 	_, err := uc.View(ctx, args.id)
 	return err
 }
 
+type listProductsFlow struct {
+	now  time.Time
+	args usecase.ListProductsDto
+	stub struct {
+		list arg1[usecase.ProductQuery, []domain.Product]
+	}
+}
+
+func newListProductsFlow() *listProductsFlow {
+	f := new(listProductsFlow)
+	f.now = time.Now()
+
+	f.args = usecase.ListProductsDto{
+		UserID: uuid.New(),
+		Sort:   usecase.ProductSortName,
+		Limit:  2,
+	}
+
+	f.stub.list.args = usecase.ProductQuery{
+		VisibleTo: f.args.UserID,
+		At:        f.now,
+		Sort:      usecase.ProductSortName,
+		Limit:     3,
+	}
+	f.stub.list.data = []domain.Product{
+		*factories.NewProduct("published"),
+		*factories.NewProduct("published"),
+		*factories.NewProduct("published"),
+	}
+
+	return f
+}
+
+func (f *listProductsFlow) exec() (*usecase.ProductPage, error) {
+	args := f.args
+	stub := f.stub
+
+	repo := new(mocks.MockProductRepository)
+	repo.EXPECT().List(context.Background(), stub.list.args).Return(stub.list.data, stub.list.err)
+
+	uc := usecase.NewProduct(repo, event.NewInMemoryPublisher(), usecase.WithProductClock(func() time.Time {
+		return f.now
+	}))
+	return uc.List(context.Background(), args)
+}
+
 type deleteProductFlow struct {
-	args struct {
+	publisher *event.InMemoryPublisher
+	args      struct {
 		id     uuid.UUID
 		userID uuid.UUID
 	}
 	stub struct {
 		findByID arg1[uuid.UUID, *domain.Product]
-		delete   arg0[uuid.UUID]
+		update   arg0[int]
 	}
 }
 
@@ -236,7 +748,7 @@ func newDeleteProductFlow() *deleteProductFlow {
 
 	f.stub.findByID.args = p.ID
 	f.stub.findByID.data = p
-	f.stub.delete.args = p.ID
+	f.stub.update.args = 0
 
 	return f
 }
@@ -249,15 +761,19 @@ func (f *deleteProductFlow) exec() error {
 
 	repo := new(mocks.MockProductRepository)
 	repo.EXPECT().FindByID(context.Background(), stub.findByID.args).Return(stub.findByID.data, stub.findByID.err)
-	repo.EXPECT().Delete(context.Background(), stub.delete.args).Return(stub.delete.err)
+	repo.EXPECT().Update(context.Background(), mock.MatchedBy(func(p domain.Product) bool {
+		return p.ID == args.id && p.IsDeleted()
+	}), stub.update.args).Return(stub.update.err)
 
-	uc := usecase.NewProduct(repo)
+	f.publisher = event.NewInMemoryPublisher()
+	uc := usecase.NewProduct(repo, f.publisher)
 	return uc.Delete(ctx, args.id, args.userID)
 }
 
 type createProductFlow struct {
-	args usecase.CreateProductDto
-	stub struct {
+	publisher *event.InMemoryPublisher
+	args      usecase.CreateProductDto
+	stub      struct {
 		create arg1[usecase.CreateProductDto, *domain.Product]
 	}
 }
@@ -284,7 +800,93 @@ func (f *createProductFlow) exec() error {
 	repo.EXPECT().Create(context.Background(), stub.create.args.Name, stub.create.args.UserID).Return(stub.create.data, stub.create.err)
 
 	ctx := context.Background()
-	uc := usecase.NewProduct(repo)
+	f.publisher = event.NewInMemoryPublisher()
+	uc := usecase.NewProduct(repo, f.publisher)
 	_, err := uc.Create(ctx, args)
 	return err
 }
+
+type updateProductFlow struct {
+	publisher *event.InMemoryPublisher
+	args      usecase.UpdateProductDto
+	stub      struct {
+		findByID arg1[uuid.UUID, *domain.Product]
+		update   arg0[int]
+	}
+}
+
+func newUpdateProductFlow() *updateProductFlow {
+	p := factories.NewProduct()
+
+	f := new(updateProductFlow)
+
+	f.args = usecase.UpdateProductDto{
+		ID:      p.ID,
+		UserID:  p.UserID,
+		Name:    "striped socks",
+		Price:   domain.NewMoney(12, "MYR"),
+		Version: 0,
+	}
+
+	f.stub.findByID.args = p.ID
+	f.stub.findByID.data = p
+	f.stub.update.args = 0
+
+	return f
+}
+
+func (f *updateProductFlow) exec() (*domain.Product, error) {
+	args := f.args
+	stub := f.stub
+
+	repo := new(mocks.MockProductRepository)
+	repo.EXPECT().FindByID(context.Background(), stub.findByID.args).Return(stub.findByID.data, stub.findByID.err)
+	repo.EXPECT().Update(context.Background(), mock.MatchedBy(func(p domain.Product) bool {
+		return p.ID == args.ID && p.Version == stub.update.args+1
+	}), stub.update.args).Return(stub.update.err)
+
+	ctx := context.Background()
+	f.publisher = event.NewInMemoryPublisher()
+	uc := usecase.NewProduct(repo, f.publisher)
+	return uc.Update(ctx, args)
+}
+
+type publishProductFlow struct {
+	publisher *event.InMemoryPublisher
+	args      struct {
+		id     uuid.UUID
+		userID uuid.UUID
+	}
+	stub struct {
+		findByID arg1[uuid.UUID, *domain.Product]
+		update   arg0[int]
+	}
+}
+
+func newPublishProductFlow() *publishProductFlow {
+	p := factories.NewProduct("no_published_at")
+
+	f := new(publishProductFlow)
+
+	f.args.id = p.ID
+	f.args.userID = p.UserID
+
+	f.stub.findByID.args = p.ID
+	f.stub.findByID.data = p
+	f.stub.update.args = 0
+
+	return f
+}
+
+func (f *publishProductFlow) exec(op func(*usecase.ProductUsecase) (*domain.Product, error)) (*domain.Product, error) {
+	stub := f.stub
+
+	repo := new(mocks.MockProductRepository)
+	repo.EXPECT().FindByID(context.Background(), stub.findByID.args).Return(stub.findByID.data, stub.findByID.err)
+	repo.EXPECT().Update(context.Background(), mock.MatchedBy(func(p domain.Product) bool {
+		return p.ID == stub.findByID.args && p.Version == stub.update.args+1
+	}), stub.update.args).Return(stub.update.err)
+
+	f.publisher = event.NewInMemoryPublisher()
+	return op(usecase.NewProduct(repo, f.publisher))
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type purchaseLifecycleRepository interface {
+	refundRepository
+	// UpdatePurchase stores the purchase events in the outbox in the same
+	// transaction. It returns ErrPurchaseStatusInvalid if the stored status
+	// is no longer the status before the transition.
+	UpdatePurchase(ctx context.Context, purchase domain.Purchase) error
+	// PayPurchase stores the paid purchase and its events, and removes the
+	// reserved units from the stock, in the same transaction. It returns
+	// ErrPurchaseStatusInvalid if the stored purchase is no longer pending.
+	PayPurchase(ctx context.Context, purchase domain.Purchase) error
+	// CancelPurchase stores the cancelled purchase and its events, and
+	// releases the reserved units, in the same transaction. It returns
+	// ErrPurchaseStatusInvalid if the stored purchase is no longer pending.
+	CancelPurchase(ctx context.Context, purchase domain.Purchase) error
+}
+
+type PurchaseLifecycleUsecase struct {
+	repo purchaseLifecycleRepository
+	now  func() time.Time
+}
+
+type PurchaseLifecycleOption func(*PurchaseLifecycleUsecase)
+
+// WithLifecycleClock sets the clock for the status changes. Defaults to
+// time.Now.
+func WithLifecycleClock(now func() time.Time) PurchaseLifecycleOption {
+	return func(u *PurchaseLifecycleUsecase) {
+		u.now = now
+	}
+}
+
+func NewPurchaseLifecycleUsecase(repo purchaseLifecycleRepository, opts ...PurchaseLifecycleOption) *PurchaseLifecycleUsecase {
+	u := &PurchaseLifecycleUsecase{
+		repo: repo,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
+}
+
+// MarkPaid marks the purchase as paid, and commits the reserved stock.
+func (u *PurchaseLifecycleUsecase) MarkPaid(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	p, err := u.repo.FindPurchase(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindPurchase: %w", err)
+	}
+
+	if err := p.MarkPaid(u.now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPurchaseStatusInvalid, err)
+	}
+
+	if err := u.repo.PayPurchase(ctx, *p); err != nil {
+		return nil, fmt.Errorf("repo.PayPurchase: %w", err)
+	}
+
+	return p, nil
+}
+
+func (u *PurchaseLifecycleUsecase) Fulfill(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	p, err := u.repo.FindPurchase(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindPurchase: %w", err)
+	}
+
+	return u.update(ctx, p, p.Fulfill)
+}
+
+// Cancel cancels the unpaid purchase on behalf of the user, and returns the
+// reserved stock.
+func (u *PurchaseLifecycleUsecase) Cancel(ctx context.Context, id, userID uuid.UUID) (*domain.Purchase, error) {
+	p, err := u.repo.FindPurchase(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindPurchase: %w", err)
+	}
+
+	if !p.IsMine(userID) {
+		return nil, ErrPurchaseUnauthorized
+	}
+
+	if err := p.Cancel(u.now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPurchaseStatusInvalid, err)
+	}
+
+	if err := u.repo.CancelPurchase(ctx, *p); err != nil {
+		return nil, fmt.Errorf("repo.CancelPurchase: %w", err)
+	}
+
+	return p, nil
+}
+
+// Refund refunds the remaining amount of the purchase. The refund is recorded
+// in the refund ledger, like the refunds issued by the RefundUsecase.
+func (u *PurchaseLifecycleUsecase) Refund(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	p, err := u.repo.FindPurchase(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindPurchase: %w", err)
+	}
+
+	if _, err := issueRefund(ctx, u.repo, p, nil, "", u.now()); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (u *PurchaseLifecycleUsecase) update(ctx context.Context, p *domain.Purchase, transition func(time.Time) error) (*domain.Purchase, error) {
+	if err := transition(u.now()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPurchaseStatusInvalid, err)
+	}
+
+	if err := u.repo.UpdatePurchase(ctx, *p); err != nil {
+		return nil, fmt.Errorf("repo.UpdatePurchase: %w", err)
+	}
+
+	return p, nil
+}
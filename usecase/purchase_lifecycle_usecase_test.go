@@ -0,0 +1,194 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurchaseLifecycleFlow(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	t.Run("mark paid", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		p, err := f.exec(markPaid)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PurchaseStatusPaid, p.Status)
+
+		// The reserved stock is committed together with the status.
+		f.repo.AssertCalled(t, "PayPurchase", context.Background(), *p)
+		f.repo.AssertNotCalled(t, "UpdatePurchase", mock.Anything, mock.Anything)
+
+		events := p.Events()
+		if assert.Len(t, events, 1) {
+			evt, ok := events[0].(domain.PurchaseStatusChanged)
+			assert.True(t, ok)
+			assert.Equal(t, domain.PurchaseStatusPending, evt.From)
+			assert.Equal(t, domain.PurchaseStatusPaid, evt.To)
+		}
+	})
+
+	t.Run("fulfill", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.findPurchase.data = factories.NewPurchase("paid")
+		p, err := f.exec(fulfill)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PurchaseStatusFulfilled, p.Status)
+	})
+
+	t.Run("refund", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.findPurchase.data = factories.NewPurchase("fulfilled")
+		p, err := f.exec(refund)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PurchaseStatusRefunded, p.Status)
+
+		// The remaining amount is recorded in the refund ledger.
+		f.repo.AssertCalled(t, "CreateRefund", context.Background(), mock.MatchedBy(func(r domain.Refund) bool {
+			return r.PurchaseID == p.ID && r.Amount == domain.NewMoney(10, "MYR")
+		}), *p)
+	})
+
+	t.Run("refund unpaid purchase", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		_, err := f.exec(refund)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseStatusInvalid)
+		f.repo.AssertNotCalled(t, "CreateRefund", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		p, err := f.exec(cancel)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.PurchaseStatusCancelled, p.Status)
+
+		// The reserved stock is released together with the status.
+		f.repo.AssertCalled(t, "CancelPurchase", context.Background(), *p)
+		f.repo.AssertNotCalled(t, "UpdatePurchase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cancel by other user", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.args.userID = uuid.New()
+		_, err := f.exec(cancel)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseUnauthorized)
+	})
+
+	t.Run("cancel paid purchase", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.findPurchase.data = factories.NewPurchase("paid")
+		_, err := f.exec(cancel)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseStatusInvalid)
+		assert.ErrorIs(t, err, domain.ErrPurchaseInvalidTransition)
+		f.repo.AssertNotCalled(t, "CancelPurchase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cancel purchase error", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.cancelPurchase.err = wantErr
+		_, err := f.exec(cancel)
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("invalid transition", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		_, err := f.exec(fulfill)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseStatusInvalid)
+	})
+
+	t.Run("find purchase error", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.findPurchase.err = usecase.ErrPurchaseNotFound
+		_, err := f.exec(markPaid)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseNotFound)
+	})
+
+	t.Run("pay purchase error", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.payPurchase.err = usecase.ErrPurchaseStatusInvalid
+		_, err := f.exec(markPaid)
+		assert.ErrorIs(t, err, usecase.ErrPurchaseStatusInvalid)
+	})
+
+	t.Run("update purchase error", func(t *testing.T) {
+		f := newPurchaseLifecycleFlow()
+		f.stub.findPurchase.data = factories.NewPurchase("paid")
+		f.stub.updatePurchase.err = wantErr
+		_, err := f.exec(fulfill)
+		assert.ErrorIs(t, err, wantErr)
+	})
+}
+
+type purchaseTransition int
+
+const (
+	markPaid purchaseTransition = iota
+	fulfill
+	cancel
+	refund
+)
+
+type purchaseLifecycleFlow struct {
+	repo *mocks.MockPurchaseLifecycleRepository
+	args struct {
+		id     uuid.UUID
+		userID uuid.UUID
+	}
+	stub struct {
+		findPurchase   arg1[uuid.UUID, *domain.Purchase]
+		updatePurchase arg0[domain.Purchase]
+		payPurchase    arg0[domain.Purchase]
+		cancelPurchase arg0[domain.Purchase]
+	}
+}
+
+func newPurchaseLifecycleFlow() *purchaseLifecycleFlow {
+	p := factories.NewPurchase()
+
+	f := new(purchaseLifecycleFlow)
+	f.args.id = p.ID
+	f.args.userID = p.UserID
+
+	f.stub.findPurchase.args = p.ID
+	f.stub.findPurchase.data = p
+
+	return f
+}
+
+func (f *purchaseLifecycleFlow) exec(transition purchaseTransition) (*domain.Purchase, error) {
+	ctx := context.Background()
+
+	args := f.args
+	stub := f.stub
+
+	repo := new(mocks.MockPurchaseLifecycleRepository)
+	repo.EXPECT().FindPurchase(ctx, stub.findPurchase.args).Return(stub.findPurchase.data, stub.findPurchase.err)
+	repo.EXPECT().UpdatePurchase(ctx, mock.AnythingOfType("domain.Purchase")).Return(stub.updatePurchase.err)
+	repo.EXPECT().PayPurchase(ctx, mock.AnythingOfType("domain.Purchase")).Return(stub.payPurchase.err)
+	repo.EXPECT().CancelPurchase(ctx, mock.AnythingOfType("domain.Purchase")).Return(stub.cancelPurchase.err)
+	repo.EXPECT().FindRefunds(ctx, mock.Anything).Return(nil, nil)
+	repo.EXPECT().CreateRefund(ctx, mock.Anything, mock.Anything).Return(nil)
+	f.repo = repo
+
+	uc := usecase.NewPurchaseLifecycleUsecase(repo)
+	switch transition {
+	case markPaid:
+		return uc.MarkPaid(ctx, args.id)
+	case fulfill:
+		return uc.Fulfill(ctx, args.id)
+	case cancel:
+		return uc.Cancel(ctx, args.id, args.userID)
+	case refund:
+		return uc.Refund(ctx, args.id)
+	default:
+		panic("unknown transition")
+	}
+}
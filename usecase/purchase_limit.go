@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type purchaseLimitRepository interface {
+	FindUserProductPurchases(ctx context.Context, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error)
+}
+
+// checkUserPurchaseLimits returns ErrPurchaseLimitExceeded when the user
+// cannot buy the units of the product without exceeding its purchase limits.
+func checkUserPurchaseLimits(ctx context.Context, repo purchaseLimitRepository, userID uuid.UUID, p *domain.Product, unit int, at time.Time) error {
+	if err := p.PurchaseLimits.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrProductLimitsInvalid, err)
+	}
+
+	since, ok := p.PurchaseLimits.Since(at)
+	if !ok {
+		return nil
+	}
+
+	purchased, err := repo.FindUserProductPurchases(ctx, userID, p.ID, since)
+	if err != nil {
+		return err
+	}
+
+	return purchaseLimitError(p.PurchaseLimits.Check(unit, purchased, at))
+}
+
+// purchaseLimitError maps domain.ErrPurchaseLimitExceeded to
+// ErrPurchaseLimitExceeded with the remaining units. The repositories check
+// the limits again when the purchase is stored, so concurrent purchases
+// cannot exceed them.
+func purchaseLimitError(err error) error {
+	var limitErr *domain.PurchaseLimitExceededError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrPurchaseLimitExceeded.Wrap(limitErr.Remaining), err)
+}
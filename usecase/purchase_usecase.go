@@ -2,59 +2,305 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/google/uuid"
 )
 
 type purchaseRepository interface {
-	CheckUserEligibility(ctx context.Context, userID uuid.UUID) error
+	// FindUser returns ErrUserIneligible if the user does not exist.
+	FindUser(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	// FindUserPurchaseTimes returns the creation times of the purchases by
+	// the user after the time, excluding the cancelled purchases.
+	FindUserPurchaseTimes(ctx context.Context, userID uuid.UUID, since time.Time) ([]time.Time, error)
 	FindProduct(ctx context.Context, productID uuid.UUID) (*domain.Product, error)
+	// FindUserProductPurchases returns the units of the product bought by the
+	// user after the time, excluding the cancelled purchases.
+	FindUserProductPurchases(ctx context.Context, userID, productID uuid.UUID, since time.Time) ([]domain.PurchasedUnits, error)
 	FindProductDiscount(ctx context.Context, productID uuid.UUID) ([]domain.Discount, error)
+	// FindDiscountByCouponCode returns ErrCouponUnknown if the code does not exist.
+	FindDiscountByCouponCode(ctx context.Context, code string) (*domain.Discount, error)
+	CountCouponRedemptions(ctx context.Context, code string, userID uuid.UUID) (*domain.CouponUsage, error)
+	// CreatePurchase also reserves the units from the available stock,
+	// records the redemption of the purchase coupon codes, and stores the
+	// purchase events in the outbox in the same transaction. It returns
+	// ErrProductOutOfStock when there is not enough stock left,
+	// ErrCouponExhausted if a coupon has been redeemed up to its caps since it
+	// was checked, and domain.ErrPurchaseLimitExceeded if the purchase limits
+	// of the product are exceeded by the purchases since then.
 	CreatePurchase(ctx context.Context, purchase domain.Purchase) error
+	// CreateIdempotentPurchase creates the purchase like CreatePurchase, and
+	// stores it as the outcome of the claimed key in the same transaction. It
+	// returns ErrIdempotencyKeyExpired if the claim has expired and the key
+	// has been claimed again since.
+	CreateIdempotentPurchase(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey) error
+}
+
+type idempotencyRepository interface {
+	// LockIdempotencyKey claims the key of the user. When the key is claimed
+	// earlier, it returns the existing key instead, with created set to
+	// false. A key that is expired at the creation time of the given key is
+	// claimed again.
+	LockIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) (existing *domain.IdempotencyKey, created bool, err error)
+	// DeleteIdempotencyKey releases the key when the request fails, so that
+	// it can be retried.
+	DeleteIdempotencyKey(ctx context.Context, key domain.IdempotencyKey) error
 }
 
 type PurchaseUsecase struct {
-	repo purchaseRepository
-	svc  *domain.ProductService
+	repo     purchaseRepository
+	idemRepo idempotencyRepository
+	svc      *domain.ProductService
 }
 
-func NewPurchaseUsecase(repo purchaseRepository) *PurchaseUsecase {
+// NewPurchaseUsecase returns a PurchaseUsecase. The options configure the
+// pricing and the eligibility, e.g. the tax calculator.
+func NewPurchaseUsecase(repo purchaseRepository, idemRepo idempotencyRepository, opts ...domain.ProductServiceOption) *PurchaseUsecase {
 	return &PurchaseUsecase{
-		repo: repo,
-		svc:  domain.NewProductService(),
+		repo:     repo,
+		idemRepo: idemRepo,
+		svc:      domain.NewProductService(opts...),
 	}
 }
 
 type PurchaseDto struct {
-	ProductID uuid.UUID
-	UserID    uuid.UUID
-	Unit      int
+	ProductID      uuid.UUID
+	UserID         uuid.UUID
+	Unit           int
+	CouponCodes    []string // Optional.
+	IdempotencyKey string   // Optional.
 }
 
-func (u *PurchaseUsecase) Purchase(ctx context.Context, dto PurchaseDto) error {
-	if err := u.repo.CheckUserEligibility(ctx, dto.UserID); err != nil {
-		return err
+// hash returns the fingerprint of the payload, excluding the idempotency key.
+// The payload is encoded as JSON, so that the fields cannot run into each
+// other, e.g. a coupon code that contains a comma.
+func (dto PurchaseDto) hash() (string, error) {
+	b, err := json.Marshal(struct {
+		ProductID   uuid.UUID
+		UserID      uuid.UUID
+		Unit        int
+		CouponCodes []string
+	}{dto.ProductID, dto.UserID, dto.Unit, dto.CouponCodes})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Purchase creates the purchase. When the idempotency key is given, retries
+// with the same key and payload by the same user return the original
+// purchase. A request that does not complete within the lock timeout, e.g.
+// because the key could not be released, can be retried after it.
+func (u *PurchaseUsecase) Purchase(ctx context.Context, dto PurchaseDto) (*domain.Purchase, error) {
+	if dto.IdempotencyKey == "" {
+		return u.purchase(ctx, dto, nil)
+	}
+
+	hash, err := dto.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	lock := domain.IdempotencyKey{
+		UserID:      dto.UserID,
+		Key:         dto.IdempotencyKey,
+		RequestHash: hash,
+		CreatedAt:   u.svc.Now(),
+	}
+	key, created, err := u.idemRepo.LockIdempotencyKey(ctx, lock)
+	if err != nil {
+		return nil, fmt.Errorf("idemRepo.LockIdempotencyKey: %w", err)
+	}
+
+	if !created {
+		if key.RequestHash != hash {
+			return nil, ErrIdempotencyKeyReused
+		}
+
+		if !key.IsCompleted() {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		return key.Purchase, nil
+	}
+
+	p, err := u.purchase(ctx, dto, &lock)
+	if err != nil {
+		if deleteErr := u.idemRepo.DeleteIdempotencyKey(ctx, lock); deleteErr != nil {
+			return nil, errors.Join(err, deleteErr)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Preview returns the priced purchase without reserving the stock or
+// creating the purchase.
+func (u *PurchaseUsecase) Preview(ctx context.Context, dto PurchaseDto) (*domain.Purchase, error) {
+	return u.prepare(ctx, dto)
+}
+
+// purchase creates the purchase. When the key is given, the purchase is
+// stored as its outcome.
+func (u *PurchaseUsecase) purchase(ctx context.Context, dto PurchaseDto, key *domain.IdempotencyKey) (*domain.Purchase, error) {
+	req, err := u.prepare(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.MarkCreated(u.svc.Now()); err != nil {
+		return nil, err
+	}
+
+	if key == nil {
+		err = u.repo.CreatePurchase(ctx, *req)
+	} else {
+		err = u.repo.CreateIdempotentPurchase(ctx, *req, *key)
+	}
+	if err != nil {
+		return nil, purchaseLimitError(err)
+	}
+
+	return req, nil
+}
+
+// prepare validates the request and prices the purchase.
+func (u *PurchaseUsecase) prepare(ctx context.Context, dto PurchaseDto) (*domain.Purchase, error) {
+	if dto.Unit <= 0 {
+		return nil, ErrPurchaseUnitInvalid
+	}
+
+	user, err := u.repo.FindUser(ctx, dto.UserID)
+	if err != nil {
+		return nil, err
 	}
 
 	p, err := u.repo.FindProduct(ctx, dto.ProductID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if !p.IsPublished() {
-		return ErrProductNotFound
+	now := u.svc.Now()
+	if !p.IsPublishedAt(now) {
+		return nil, ErrProductNotFound
+	}
+
+	times, err := findPurchaseTimes(ctx, u.repo, u.svc, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.svc.CheckEligibility(domain.EligibilityRequest{
+		User:          *user,
+		Product:       *p,
+		Unit:          dto.Unit,
+		PurchaseTimes: times,
+		At:            now,
+	}); err != nil {
+		return nil, eligibilityError(err)
+	}
+
+	if err := checkUserPurchaseLimits(ctx, u.repo, user.ID, p, dto.Unit, now); err != nil {
+		return nil, err
 	}
 
 	ds, err := u.repo.FindProductDiscount(ctx, dto.ProductID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	req, err := u.svc.PreparePurchase(ctx, dto.Unit, p, ds)
+	discounts := automaticDiscounts(ds)
+
+	coupons := make(map[int64]string)
+	for _, code := range dto.CouponCodes {
+		d, err := u.findCouponDiscount(ctx, dto, code)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := coupons[d.ID]; ok {
+			continue
+		}
+
+		coupons[d.ID] = code
+		discounts = append(discounts, *d)
+	}
+
+	req, err := u.svc.PreparePurchase(ctx, dto.Unit, p, discounts)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrDiscountInvalid, err)
+		return nil, preparePurchaseError(err)
+	}
+
+	req.UserID = dto.UserID
+	for _, id := range req.AppliedDiscountIDs {
+		if code, ok := coupons[id]; ok {
+			req.CouponCodes = append(req.CouponCodes, code)
+		}
+	}
+
+	return req, nil
+}
+
+func (u *PurchaseUsecase) findCouponDiscount(ctx context.Context, dto PurchaseDto, code string) (*domain.Discount, error) {
+	d, err := u.repo.FindDiscountByCouponCode(ctx, code)
+	if err != nil {
+		return nil, err
 	}
 
-	return u.repo.CreatePurchase(ctx, *req)
+	if d.Coupon == nil || d.ProductID != dto.ProductID {
+		return nil, ErrCouponUnknown
+	}
+
+	if !d.IsActiveAt(u.svc.Now()) {
+		return nil, ErrCouponExpired
+	}
+
+	usage, err := u.repo.CountCouponRedemptions(ctx, code, dto.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Coupon.CanRedeem(*usage) {
+		return nil, ErrCouponExhausted
+	}
+
+	return d, nil
+}
+
+// automaticDiscounts excludes the coupon discounts, which only apply when
+// the code is redeemed.
+func automaticDiscounts(ds []domain.Discount) []domain.Discount {
+	var res []domain.Discount
+	for _, d := range ds {
+		if d.Coupon == nil {
+			res = append(res, d)
+		}
+	}
+
+	return res
+}
+
+// preparePurchaseError maps the pricing errors. Unknown tax categories are
+// misconfigurations, and are not the user's fault.
+func preparePurchaseError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrTaxCategoryUnknown):
+		return err
+	case errors.Is(err, domain.ErrPriceTiersInvalid):
+		return fmt.Errorf("%w: %w", ErrProductPriceTiersInvalid, err)
+	case errors.Is(err, domain.ErrDiscountsExceeded):
+		return fmt.Errorf("%w: %w", ErrProductDiscountsExceeded, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrDiscountInvalid, err)
+	}
 }
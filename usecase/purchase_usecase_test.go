@@ -3,14 +3,19 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/alextanhongpin/errors/causes"
 	"github.com/alextanhongpin/go-domain-test/domain"
 	"github.com/alextanhongpin/go-domain-test/domain/factories"
 	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/alextanhongpin/go-domain-test/types"
 	"github.com/alextanhongpin/go-domain-test/usecase"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestPurchaseFlow(t *testing.T) {
@@ -18,14 +23,90 @@ func TestPurchaseFlow(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		f := newPurchaseFlow()
 		assert.Nil(t, f.exec())
+
+		// The events are stored in the outbox together with the purchase.
+		f.repo.AssertCalled(t, "CreatePurchase", context.Background(), mock.MatchedBy(func(p domain.Purchase) bool {
+			events := p.Events()
+			if len(events) != 1 {
+				return false
+			}
+
+			evt, ok := events[0].(domain.PurchaseCreated)
+			return ok &&
+				evt.PurchaseID == p.ID &&
+				evt.ProductID == f.args.ProductID &&
+				evt.UserID == f.args.UserID &&
+				evt.Total == domain.NewMoney(10, "MYR")
+		}))
+	})
+
+	t.Run("invalid unit", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.args.Unit = 0
+		assert.ErrorIs(t, f.exec(), usecase.ErrPurchaseUnitInvalid)
+		f.repo.AssertNotCalled(t, "FindUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("find user error", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findUser.err = wantErr
+		assert.ErrorIs(t, f.exec(), wantErr)
+	})
+
+	t.Run("user inactive", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findUser.data.Status = domain.UserStatusSuspended
+
+		err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrUserInactive)
+		assert.ErrorIs(t, err, domain.ErrUserInactive)
+	})
+
+	t.Run("user banned", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findUser.data.BannedUntil = types.Ptr(time.Now().Add(time.Hour))
+		assert.ErrorIs(t, f.exec(), usecase.ErrUserBanned)
+	})
+
+	t.Run("user underage", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProduct.data.MinAge = 18
+		f.stub.findUser.data.BirthDate = types.Ptr(time.Now().AddDate(-17, 0, 0))
+		assert.ErrorIs(t, f.exec(), usecase.ErrUserUnderage)
+	})
+
+	t.Run("purchase limit reached", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.opts = []domain.ProductServiceOption{
+			domain.WithEligibilityPolicies(domain.MaxPurchasesPolicy{Max: 1, Period: time.Hour}),
+		}
+		f.stub.findUserPurchaseTimes.data = []time.Time{time.Now().Add(-time.Minute)}
+		assert.ErrorIs(t, f.exec(), usecase.ErrUserPurchaseLimitReached)
+
+		f.stub.findUserPurchaseTimes.data = nil
+		assert.Nil(t, f.exec())
 	})
 
-	t.Run("check user eligibility error", func(t *testing.T) {
+	t.Run("find user purchase times error", func(t *testing.T) {
 		f := newPurchaseFlow()
-		f.stub.checkUserEligibility.err = wantErr
+		f.opts = []domain.ProductServiceOption{
+			domain.WithEligibilityPolicies(domain.MaxPurchasesPolicy{Max: 1, Period: time.Hour}),
+		}
+		f.stub.findUserPurchaseTimes.err = wantErr
 		assert.ErrorIs(t, f.exec(), wantErr)
 	})
 
+	t.Run("other policy", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.opts = []domain.ProductServiceOption{
+			domain.WithEligibilityPolicies(rejectPolicy{wantErr}),
+		}
+
+		err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrUserIneligible)
+		assert.ErrorIs(t, err, wantErr)
+	})
+
 	t.Run("find product error", func(t *testing.T) {
 		f := newPurchaseFlow()
 		f.stub.findProduct.err = wantErr
@@ -38,6 +119,68 @@ func TestPurchaseFlow(t *testing.T) {
 		assert.ErrorIs(t, f.exec(), usecase.ErrProductNotFound)
 	})
 
+	t.Run("purchase limit exceeded", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProduct.data.PurchaseLimits = domain.PurchaseLimits{{Max: 3, Period: 24 * time.Hour}}
+		f.stub.findUserProductPurchases.data = []domain.PurchasedUnits{{Unit: 2, At: time.Now().Add(-time.Hour)}}
+
+		err := f.exec()
+
+		as := assert.New(t)
+		as.True(usecase.ErrPurchaseLimitExceeded.Is(err))
+		as.ErrorIs(err, domain.ErrPurchaseLimitExceeded)
+
+		var d causes.Detail
+		if as.ErrorAs(err, &d) {
+			remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(d.Detail())
+			as.True(ok)
+			as.Equal(1, remaining)
+		}
+
+		f.args.Unit = 1
+		f.stub.findUserProductPurchases.data[0].At = time.Now().Add(-25 * time.Hour)
+		as.Nil(f.reload())
+		as.Nil(f.exec())
+	})
+
+	t.Run("purchase limit exceeded when stored", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.createPurchase.err = &domain.PurchaseLimitExceededError{Remaining: 1}
+
+		err := f.exec()
+
+		as := assert.New(t)
+		as.True(usecase.ErrPurchaseLimitExceeded.Is(err))
+		as.ErrorIs(err, domain.ErrPurchaseLimitExceeded)
+
+		var d causes.Detail
+		if as.ErrorAs(err, &d) {
+			remaining, ok := usecase.ErrPurchaseLimitExceeded.Unwrap(d.Detail())
+			as.True(ok)
+			as.Equal(1, remaining)
+		}
+	})
+
+	t.Run("find user product purchases error", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProduct.data.PurchaseLimits = domain.PurchaseLimits{{Max: 3}}
+		f.stub.findUserProductPurchases.err = wantErr
+		assert.ErrorIs(t, f.exec(), wantErr)
+	})
+
+	t.Run("product limits invalid", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProduct.data.PurchaseLimits = domain.PurchaseLimits{{Max: 0}}
+		assert.ErrorIs(t, f.exec(), usecase.ErrProductLimitsInvalid)
+	})
+
+	t.Run("product price tiers invalid", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProduct.data = factories.NewProduct("tiered")
+		f.stub.findProduct.data.PriceTiers[1].MinQty = 9
+		assert.ErrorIs(t, f.exec(), usecase.ErrProductPriceTiersInvalid)
+	})
+
 	t.Run("find product discount error", func(t *testing.T) {
 		f := newPurchaseFlow()
 		f.stub.findProductDiscount.err = wantErr
@@ -50,6 +193,40 @@ func TestPurchaseFlow(t *testing.T) {
 		assert.ErrorIs(t, f.exec(), usecase.ErrDiscountInvalid)
 	})
 
+	t.Run("discount in different currency", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProductDiscount.data = append(f.stub.findProductDiscount.data, *factories.NewDiscount("usd"))
+		assert.ErrorIs(t, f.exec(), domain.ErrCurrencyMismatch)
+	})
+
+	t.Run("discount is expired", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProductDiscount.data = []domain.Discount{*factories.NewDiscount("expired")}
+		if err := f.reload(); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, f.exec())
+		assert.True(t, f.stub.createPurchase.args.Discount.IsZero())
+	})
+
+	t.Run("coupon discount is not applied automatically", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.findProductDiscount.data = []domain.Discount{*factories.NewDiscount("coupon")}
+		if err := f.reload(); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Nil(t, f.exec())
+		assert.True(t, f.stub.createPurchase.args.Discount.IsZero())
+	})
+
+	t.Run("out of stock", func(t *testing.T) {
+		f := newPurchaseFlow()
+		f.stub.createPurchase.err = usecase.ErrProductOutOfStock
+		assert.ErrorIs(t, f.exec(), usecase.ErrProductOutOfStock)
+	})
+
 	t.Run("create purchase error", func(t *testing.T) {
 		f := newPurchaseFlow()
 		f.stub.createPurchase.err = wantErr
@@ -57,13 +234,258 @@ func TestPurchaseFlow(t *testing.T) {
 	})
 }
 
+func TestPurchasePreview(t *testing.T) {
+	f := newPurchaseFlow()
+	u := f.build(&idempotencyRepository{MockIdempotencyRepository: new(mocks.MockIdempotencyRepository)})
+
+	p, err := u.Preview(context.Background(), f.args)
+
+	as := assert.New(t)
+	as.Nil(err)
+	as.Equal(domain.NewMoney(-5, "MYR"), p.Discount)
+	as.Empty(p.Events())
+	f.repo.AssertNotCalled(t, "CreatePurchase", mock.Anything, mock.Anything)
+}
+
+func TestPurchaseFlowCoupon(t *testing.T) {
+	var wantErr = errors.New("want error")
+	t.Run("success", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		assert.Nil(t, f.exec())
+		assert.Equal(t, []string{"SAVE5"}, f.stub.createPurchase.args.CouponCodes)
+		assert.Equal(t, domain.NewMoney(-10, "MYR"), f.stub.createPurchase.args.Discount)
+	})
+
+	t.Run("duplicate codes are redeemed once", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.args.CouponCodes = []string{"SAVE5", "SAVE5"}
+		assert.Nil(t, f.exec())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.findDiscountByCouponCode.err = usecase.ErrCouponUnknown
+		assert.ErrorIs(t, f.exec(), usecase.ErrCouponUnknown)
+	})
+
+	t.Run("for another product", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.findDiscountByCouponCode.data.ProductID = uuid.New()
+		assert.ErrorIs(t, f.exec(), usecase.ErrCouponUnknown)
+	})
+
+	t.Run("without coupon", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.findDiscountByCouponCode.data.Coupon = nil
+		assert.ErrorIs(t, f.exec(), usecase.ErrCouponUnknown)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.findDiscountByCouponCode.data = factories.NewDiscount("coupon", "expired")
+		f.stub.findDiscountByCouponCode.data.ProductID = f.args.ProductID
+		assert.ErrorIs(t, f.exec(), usecase.ErrCouponExpired)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.countCouponRedemptions.data.UserRedemptions = 1
+		assert.ErrorIs(t, f.exec(), usecase.ErrCouponExhausted)
+	})
+
+	t.Run("count coupon redemptions error", func(t *testing.T) {
+		f := newPurchaseFlow().withCoupon()
+		f.stub.countCouponRedemptions.err = wantErr
+		assert.ErrorIs(t, f.exec(), wantErr)
+	})
+}
+
+func TestPurchaseIdempotency(t *testing.T) {
+	ctx := context.Background()
+
+	newFlow := func() *purchaseFlow {
+		f := newPurchaseFlow()
+		f.args.IdempotencyKey = uuid.NewString()
+		return f
+	}
+
+	t.Run("retry with same payload", func(t *testing.T) {
+		f := newFlow()
+		u := f.build(newIdempotencyRepository(nil))
+
+		as := assert.New(t)
+		p1, err := u.Purchase(ctx, f.args)
+		as.Nil(err)
+
+		p2, err := u.Purchase(ctx, f.args)
+		as.Nil(err)
+		as.Equal(p1, p2)
+		f.repo.AssertNumberOfCalls(t, "CreateIdempotentPurchase", 1)
+	})
+
+	t.Run("retry with different payload", func(t *testing.T) {
+		f := newFlow()
+		u := f.build(newIdempotencyRepository(nil))
+
+		as := assert.New(t)
+		_, err := u.Purchase(ctx, f.args)
+		as.Nil(err)
+
+		args := f.args
+		args.Unit++
+		_, err = u.Purchase(ctx, args)
+		as.ErrorIs(err, usecase.ErrIdempotencyKeyReused)
+		f.repo.AssertNumberOfCalls(t, "CreateIdempotentPurchase", 1)
+	})
+
+	t.Run("retry after failure", func(t *testing.T) {
+		f := newFlow()
+		f.stub.createPurchase.err = usecase.ErrProductOutOfStock
+		idemRepo := newIdempotencyRepository(nil)
+
+		as := assert.New(t)
+		_, err := f.build(idemRepo).Purchase(ctx, f.args)
+		as.ErrorIs(err, usecase.ErrProductOutOfStock)
+
+		f.stub.createPurchase.err = nil
+		_, err = f.build(idemRepo).Purchase(ctx, f.args)
+		as.Nil(err)
+	})
+
+	t.Run("retry with ambiguous coupon codes", func(t *testing.T) {
+		code := "SAVE5,SAVE10"
+
+		f := newFlow().withCoupon()
+		f.args.CouponCodes = []string{code}
+		f.stub.findDiscountByCouponCode.args = code
+		f.stub.findDiscountByCouponCode.data.Coupon.Code = code
+		f.stub.countCouponRedemptions.args = code
+		if err := f.reload(); err != nil {
+			t.Fatal(err)
+		}
+		u := f.build(newIdempotencyRepository(nil))
+
+		as := assert.New(t)
+		_, err := u.Purchase(ctx, f.args)
+		as.Nil(err)
+
+		args := f.args
+		args.CouponCodes = []string{"SAVE5", "SAVE10"}
+		_, err = u.Purchase(ctx, args)
+		as.ErrorIs(err, usecase.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("retry after the lock timeout", func(t *testing.T) {
+		wantErr := errors.New("want error")
+
+		f := newFlow()
+		now := time.Now()
+		f.opts = append(f.opts, domain.WithClock(func() time.Time {
+			return now
+		}))
+
+		// The first request fails, and the key is not released.
+		f.stub.createPurchase.err = wantErr
+		idemRepo := newIdempotencyRepository(nil)
+		idemRepo.deleteErr = wantErr
+
+		as := assert.New(t)
+		_, err := f.build(idemRepo).Purchase(ctx, f.args)
+		as.ErrorIs(err, wantErr)
+
+		f.stub.createPurchase.err = nil
+		u := f.build(idemRepo)
+		_, err = u.Purchase(ctx, f.args)
+		as.ErrorIs(err, usecase.ErrIdempotencyKeyInProgress)
+
+		now = now.Add(domain.IdempotencyKeyLockTimeout)
+		_, err = u.Purchase(ctx, f.args)
+		as.Nil(err)
+		f.repo.AssertNumberOfCalls(t, "CreateIdempotentPurchase", 1)
+	})
+
+	t.Run("claim expired", func(t *testing.T) {
+		f := newFlow()
+		idemRepo := newIdempotencyRepository(nil)
+		u := f.build(idemRepo)
+
+		// The key is claimed again by a retry while the first request is in
+		// progress.
+		idemRepo.onComplete = func() error {
+			idemRepo.onComplete = nil
+			idemRepo.keys = make(map[idempotencyKeyID]domain.IdempotencyKey)
+			return nil
+		}
+
+		as := assert.New(t)
+		_, err := u.Purchase(ctx, f.args)
+		as.ErrorIs(err, usecase.ErrIdempotencyKeyExpired)
+	})
+
+	t.Run("lock error", func(t *testing.T) {
+		wantErr := errors.New("want error")
+
+		f := newFlow()
+		idemRepo := &idempotencyRepository{MockIdempotencyRepository: new(mocks.MockIdempotencyRepository)}
+		idemRepo.EXPECT().LockIdempotencyKey(ctx, mock.Anything).Return(nil, false, wantErr)
+
+		_, err := f.build(idemRepo).Purchase(ctx, f.args)
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("concurrent duplicates", func(t *testing.T) {
+		// Holds the first request in progress until the duplicates return.
+		release := make(chan struct{})
+
+		f := newFlow()
+		u := f.build(newIdempotencyRepository(func() error {
+			<-release
+			return nil
+		}))
+
+		n := 10
+		type result struct {
+			purchase *domain.Purchase
+			err      error
+		}
+		results := make(chan result, n)
+		for i := 0; i < n; i++ {
+			go func() {
+				p, err := u.Purchase(ctx, f.args)
+				results <- result{p, err}
+			}()
+		}
+
+		as := assert.New(t)
+		for i := 0; i < n-1; i++ {
+			res := <-results
+			as.ErrorIs(res.err, usecase.ErrIdempotencyKeyInProgress)
+		}
+
+		close(release)
+		res := <-results
+		as.Nil(res.err)
+
+		p, err := u.Purchase(ctx, f.args)
+		as.Nil(err)
+		as.Equal(res.purchase, p)
+		f.repo.AssertNumberOfCalls(t, "CreateIdempotentPurchase", 1)
+	})
+}
+
 type purchaseFlow struct {
+	repo *mocks.MockPurchaseRepository
+	opts []domain.ProductServiceOption
 	args usecase.PurchaseDto
 	stub struct {
-		checkUserEligibility arg0[uuid.UUID]
-		findProduct          arg1[uuid.UUID, *domain.Product]
-		findProductDiscount  arg1[uuid.UUID, []domain.Discount]
-		createPurchase       arg0[domain.Purchase]
+		findUser                 arg1[uuid.UUID, *domain.User]
+		findUserPurchaseTimes    arg1[uuid.UUID, []time.Time]
+		findProduct              arg1[uuid.UUID, *domain.Product]
+		findUserProductPurchases arg1[uuid.UUID, []domain.PurchasedUnits]
+		findProductDiscount      arg1[uuid.UUID, []domain.Discount]
+		findDiscountByCouponCode arg1[string, *domain.Discount]
+		countCouponRedemptions   arg1[string, *domain.CouponUsage]
+		createPurchase           arg0[domain.Purchase]
 	}
 }
 
@@ -80,10 +502,15 @@ func newPurchaseFlow() *purchaseFlow {
 		Unit:      2,
 	}
 
-	f.stub.checkUserEligibility.args = f.args.UserID
+	u := factories.NewUser()
+	u.ID = f.args.UserID
+	f.stub.findUser.args = f.args.UserID
+	f.stub.findUser.data = u
+	f.stub.findUserPurchaseTimes.args = f.args.UserID
 
 	f.stub.findProduct.args = f.args.ProductID
 	f.stub.findProduct.data = p
+	f.stub.findUserProductPurchases.args = f.args.ProductID
 
 	f.stub.findProductDiscount.args = f.args.ProductID
 	f.stub.findProductDiscount.data = []domain.Discount{*d}
@@ -96,29 +523,183 @@ func newPurchaseFlow() *purchaseFlow {
 	return f
 }
 
+// withCoupon redeems a coupon code for another 5$ off.
+func (f *purchaseFlow) withCoupon() *purchaseFlow {
+	d := factories.NewDiscount("coupon")
+	d.ID = 2
+	d.ProductID = f.args.ProductID
+
+	f.args.CouponCodes = []string{d.Coupon.Code}
+
+	f.stub.findDiscountByCouponCode.args = d.Coupon.Code
+	f.stub.findDiscountByCouponCode.data = d
+
+	f.stub.countCouponRedemptions.args = d.Coupon.Code
+	f.stub.countCouponRedemptions.data = &domain.CouponUsage{}
+
+	if err := f.reload(); err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
 func (f *purchaseFlow) reload() error {
+	var ds []domain.Discount
+	for _, d := range f.stub.findProductDiscount.data {
+		if d.Coupon == nil {
+			ds = append(ds, d)
+		}
+	}
+
+	coupon := f.stub.findDiscountByCouponCode.data
+	if coupon != nil {
+		ds = append(ds, *coupon)
+	}
+
 	svc := domain.NewProductService()
-	req, err := svc.PreparePurchase(context.Background(), f.args.Unit, f.stub.findProduct.data, f.stub.findProductDiscount.data)
+	req, err := svc.PreparePurchase(context.Background(), f.args.Unit, f.stub.findProduct.data, ds)
 	if err != nil {
 		return err
 	}
+
+	req.UserID = f.args.UserID
+	for _, id := range req.AppliedDiscountIDs {
+		if coupon != nil && coupon.ID == id {
+			req.CouponCodes = append(req.CouponCodes, coupon.Coupon.Code)
+		}
+	}
 	f.stub.createPurchase.args = *req
 
 	return nil
 }
 
 func (f *purchaseFlow) exec() error {
+	u := f.build(&idempotencyRepository{MockIdempotencyRepository: new(mocks.MockIdempotencyRepository)})
+	_, err := u.Purchase(context.Background(), f.args)
+	return err
+}
+
+func (f *purchaseFlow) build(idemRepo *idempotencyRepository) *usecase.PurchaseUsecase {
 	args := f.args
 	stub := f.stub
 
 	ctx := context.Background()
 
 	repo := new(mocks.MockPurchaseRepository)
-	repo.EXPECT().CheckUserEligibility(ctx, stub.checkUserEligibility.args).Return(stub.checkUserEligibility.err)
+	repo.EXPECT().FindUser(ctx, stub.findUser.args).Return(stub.findUser.data, stub.findUser.err)
+	repo.EXPECT().FindUserPurchaseTimes(ctx, stub.findUserPurchaseTimes.args, mock.Anything).Return(stub.findUserPurchaseTimes.data, stub.findUserPurchaseTimes.err)
 	repo.EXPECT().FindProduct(ctx, stub.findProduct.args).Return(stub.findProduct.data, stub.findProduct.err)
+	repo.EXPECT().FindUserProductPurchases(ctx, args.UserID, stub.findUserProductPurchases.args, mock.Anything).Return(stub.findUserProductPurchases.data, stub.findUserProductPurchases.err)
 	repo.EXPECT().FindProductDiscount(ctx, stub.findProductDiscount.args).Return(stub.findProductDiscount.data, stub.findProductDiscount.err)
-	repo.EXPECT().CreatePurchase(ctx, stub.createPurchase.args).Return(stub.createPurchase.err)
+	repo.EXPECT().FindDiscountByCouponCode(ctx, stub.findDiscountByCouponCode.args).Return(stub.findDiscountByCouponCode.data, stub.findDiscountByCouponCode.err)
+	repo.EXPECT().CountCouponRedemptions(ctx, stub.countCouponRedemptions.args, args.UserID).Return(stub.countCouponRedemptions.data, stub.countCouponRedemptions.err)
+	repo.EXPECT().CreatePurchase(ctx, matchPurchase(stub.createPurchase.args)).Return(stub.createPurchase.err)
+	repo.EXPECT().CreateIdempotentPurchase(ctx, matchPurchase(stub.createPurchase.args), mock.Anything).RunAndReturn(func(ctx context.Context, purchase domain.Purchase, key domain.IdempotencyKey) error {
+		if stub.createPurchase.err != nil {
+			return stub.createPurchase.err
+		}
+
+		return idemRepo.complete(key, purchase)
+	})
+	f.repo = repo
+
+	return usecase.NewPurchaseUsecase(repo, idemRepo, f.opts...)
+}
+
+// rejectPolicy rejects every purchase with the err.
+type rejectPolicy struct {
+	err error
+}
+
+func (p rejectPolicy) Check(req domain.EligibilityRequest) error {
+	return p.err
+}
+
+type idempotencyKeyID struct {
+	userID uuid.UUID
+	key    string
+}
 
-	u := usecase.NewPurchaseUsecase(repo)
-	return u.Purchase(ctx, args)
+// idempotencyRepository is an in-memory idempotency repository. The keys are
+// completed by the purchase repository, which calls complete.
+type idempotencyRepository struct {
+	*mocks.MockIdempotencyRepository
+
+	mu   sync.Mutex
+	keys map[idempotencyKeyID]domain.IdempotencyKey
+	// onComplete runs before the outcome is stored, and the purchase is not
+	// created when it returns an error.
+	onComplete func() error
+	// deleteErr fails the release of the key.
+	deleteErr error
+}
+
+func newIdempotencyRepository(onComplete func() error) *idempotencyRepository {
+	r := &idempotencyRepository{
+		MockIdempotencyRepository: new(mocks.MockIdempotencyRepository),
+		keys:                      make(map[idempotencyKeyID]domain.IdempotencyKey),
+		onComplete:                onComplete,
+	}
+	r.EXPECT().LockIdempotencyKey(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, key domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		id := idempotencyKeyID{key.UserID, key.Key}
+		if k, ok := r.keys[id]; ok && !k.IsExpired(key.CreatedAt) {
+			return &k, false, nil
+		}
+
+		r.keys[id] = key
+		return &key, true, nil
+	})
+	r.EXPECT().DeleteIdempotencyKey(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, key domain.IdempotencyKey) error {
+		if r.deleteErr != nil {
+			return r.deleteErr
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		delete(r.keys, idempotencyKeyID{key.UserID, key.Key})
+		return nil
+	})
+
+	return r
+}
+
+// complete stores the purchase on the key, unless the claim is no longer
+// owned.
+func (r *idempotencyRepository) complete(key domain.IdempotencyKey, purchase domain.Purchase) error {
+	if r.onComplete != nil {
+		if err := r.onComplete(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyKeyID{key.UserID, key.Key}
+	k, ok := r.keys[id]
+	if !ok || k.IsCompleted() || !k.CreatedAt.Equal(key.CreatedAt) {
+		return usecase.ErrIdempotencyKeyExpired
+	}
+
+	k.Purchase = &purchase
+	r.keys[id] = k
+	return nil
+}
+
+// matchPurchase matches the purchase, except for the generated ID and
+// timestamps.
+func matchPurchase(want domain.Purchase) any {
+	return mock.MatchedBy(func(got domain.Purchase) bool {
+		want.ID = got.ID
+		want.CreatedAt = got.CreatedAt
+		want.UpdatedAt = got.UpdatedAt
+		want.EventRecorder = got.EventRecorder
+
+		return assert.ObjectsAreEqual(want, got)
+	})
 }
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/google/uuid"
+)
+
+type refundRepository interface {
+	// FindPurchase returns ErrPurchaseNotFound if the purchase does not exist.
+	FindPurchase(ctx context.Context, id uuid.UUID) (*domain.Purchase, error)
+	FindRefunds(ctx context.Context, purchaseID uuid.UUID) ([]domain.Refund, error)
+	// CreateRefund persists the refund together with the purchase status in a
+	// single transaction. To guard against concurrent refunds, it returns
+	// ErrRefundExceedsPaid when the refunds total would exceed the purchase
+	// total, and ErrPurchaseStatusInvalid when the stored status is no longer
+	// the status before the refund, or does not match the refunds total. The
+	// purchase events are stored in the outbox in the same transaction.
+	CreateRefund(ctx context.Context, refund domain.Refund, purchase domain.Purchase) error
+}
+
+type RefundUsecase struct {
+	repo refundRepository
+	now  func() time.Time
+}
+
+type RefundOption func(*RefundUsecase)
+
+// WithRefundClock sets the clock for the refunds. Defaults to time.Now.
+func WithRefundClock(now func() time.Time) RefundOption {
+	return func(u *RefundUsecase) {
+		u.now = now
+	}
+}
+
+func NewRefundUsecase(repo refundRepository, opts ...RefundOption) *RefundUsecase {
+	u := &RefundUsecase{
+		repo: repo,
+		now:  time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
+}
+
+type RefundDto struct {
+	PurchaseID uuid.UUID
+	Amount     *domain.Money // Refunds the remaining amount when nil.
+	Reason     string
+}
+
+func (u *RefundUsecase) Refund(ctx context.Context, dto RefundDto) (*domain.Refund, error) {
+	p, err := u.repo.FindPurchase(ctx, dto.PurchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindPurchase: %w", err)
+	}
+
+	return issueRefund(ctx, u.repo, p, dto.Amount, dto.Reason, u.now())
+}
+
+// issueRefund records the refund of the amount against the refund ledger of
+// the purchase, and updates the purchase status.
+func issueRefund(ctx context.Context, repo refundRepository, p *domain.Purchase, amount *domain.Money, reason string, now time.Time) (*domain.Refund, error) {
+	refunds, err := repo.FindRefunds(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FindRefunds: %w", err)
+	}
+
+	refund, err := p.IssueRefund(amount, reason, refunds, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefundExceedsPaid):
+			return nil, fmt.Errorf("%w: %w", ErrRefundExceedsPaid, err)
+		case errors.Is(err, domain.ErrRefundAmountInvalid),
+			errors.Is(err, domain.ErrCurrencyMismatch):
+			return nil, fmt.Errorf("%w: %w", ErrRefundAmountInvalid, err)
+		case errors.Is(err, domain.ErrPurchaseInvalidTransition):
+			return nil, fmt.Errorf("%w: %w", ErrPurchaseStatusInvalid, err)
+		default:
+			return nil, err
+		}
+	}
+
+	if err := repo.CreateRefund(ctx, *refund, *p); err != nil {
+		return nil, fmt.Errorf("repo.CreateRefund: %w", err)
+	}
+
+	return refund, nil
+}
@@ -0,0 +1,133 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alextanhongpin/go-domain-test/domain"
+	"github.com/alextanhongpin/go-domain-test/domain/factories"
+	mocks "github.com/alextanhongpin/go-domain-test/mocks/github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/alextanhongpin/go-domain-test/types"
+	"github.com/alextanhongpin/go-domain-test/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefundFlow(t *testing.T) {
+	wantErr := errors.New("want error")
+
+	t.Run("full refund", func(t *testing.T) {
+		f := newRefundFlow()
+		r, err := f.exec()
+		assert.Nil(t, err)
+		assert.Equal(t, domain.NewMoney(10, "MYR"), r.Amount)
+		f.repo.AssertCalled(t, "CreateRefund", context.Background(), *r, mock.MatchedBy(func(p domain.Purchase) bool {
+			events := p.Events()
+			return p.Status == domain.PurchaseStatusRefunded &&
+				len(events) == 1 &&
+				events[0].EventName() == "purchase.status_changed"
+		}))
+	})
+
+	t.Run("partial refund", func(t *testing.T) {
+		f := newRefundFlow()
+		f.args.Amount = types.Ptr(domain.NewMoney(3, "MYR"))
+		r, err := f.exec()
+		assert.Nil(t, err)
+		assert.Equal(t, domain.NewMoney(3, "MYR"), r.Amount)
+		f.repo.AssertCalled(t, "CreateRefund", context.Background(), *r, mock.MatchedBy(func(p domain.Purchase) bool {
+			return p.Status == domain.PurchaseStatusPartiallyRefunded
+		}))
+	})
+
+	t.Run("exceeds paid", func(t *testing.T) {
+		f := newRefundFlow()
+		f.args.Amount = types.Ptr(domain.NewMoney(3, "MYR"))
+		f.stub.findRefunds.data = []domain.Refund{{
+			PurchaseID: f.args.PurchaseID,
+			Amount:     domain.NewMoney(8, "MYR"),
+		}}
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrRefundExceedsPaid)
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		f := newRefundFlow()
+		f.args.Amount = types.Ptr(domain.NewMoney(3, "USD"))
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrRefundAmountInvalid)
+	})
+
+	t.Run("not paid", func(t *testing.T) {
+		f := newRefundFlow()
+		f.stub.findPurchase.data = factories.NewPurchase()
+		f.stub.findPurchase.data.ID = f.args.PurchaseID
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrPurchaseStatusInvalid)
+	})
+
+	t.Run("find purchase error", func(t *testing.T) {
+		f := newRefundFlow()
+		f.stub.findPurchase.err = usecase.ErrPurchaseNotFound
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrPurchaseNotFound)
+	})
+
+	t.Run("find refunds error", func(t *testing.T) {
+		f := newRefundFlow()
+		f.stub.findRefunds.err = wantErr
+		_, err := f.exec()
+		assert.ErrorIs(t, err, wantErr)
+	})
+
+	t.Run("create refund error", func(t *testing.T) {
+		f := newRefundFlow()
+		f.stub.createRefund.err = usecase.ErrRefundExceedsPaid
+		_, err := f.exec()
+		assert.ErrorIs(t, err, usecase.ErrRefundExceedsPaid)
+	})
+}
+
+type refundFlow struct {
+	repo *mocks.MockRefundRepository
+	args usecase.RefundDto
+	stub struct {
+		findPurchase arg1[uuid.UUID, *domain.Purchase]
+		findRefunds  arg1[uuid.UUID, []domain.Refund]
+		createRefund arg0[domain.Refund]
+	}
+}
+
+func newRefundFlow() *refundFlow {
+	p := factories.NewPurchase("paid")
+
+	f := new(refundFlow)
+	f.args = usecase.RefundDto{
+		PurchaseID: p.ID,
+		Reason:     "damaged",
+	}
+
+	f.stub.findPurchase.args = p.ID
+	f.stub.findPurchase.data = p
+	f.stub.findRefunds.args = p.ID
+
+	return f
+}
+
+func (f *refundFlow) exec() (*domain.Refund, error) {
+	ctx := context.Background()
+
+	args := f.args
+	stub := f.stub
+
+	repo := new(mocks.MockRefundRepository)
+	repo.EXPECT().FindPurchase(ctx, stub.findPurchase.args).Return(stub.findPurchase.data, stub.findPurchase.err)
+	repo.EXPECT().FindRefunds(ctx, stub.findRefunds.args).Return(stub.findRefunds.data, stub.findRefunds.err)
+	repo.EXPECT().CreateRefund(ctx, mock.Anything, mock.Anything).Return(stub.createRefund.err)
+	f.repo = repo
+
+	uc := usecase.NewRefundUsecase(repo)
+	return uc.Refund(ctx, args)
+}